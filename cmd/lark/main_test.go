@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/yjwong/lark-cli/internal/larktest"
+	"github.com/yjwong/lark-cli/internal/scopes"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// runMainEnv makes the test binary run the CLI instead of the tests, so
+// each case runs in its own process like a real invocation
+const runMainEnv = "LARK_TEST_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+type goldenCase struct {
+	name  string
+	args  []string
+	stdin string
+	setup [][]string // commands run first in the same config directory
+}
+
+var goldenCases = []goldenCase{
+	// Calendar
+	{name: "cal_list", args: []string{"cal", "list", "--from", "2026-10-20", "--to", "2026-10-21"}},
+	{name: "cal_list_conflicts", args: []string{"cal", "list", "--from", "2026-10-20", "--to", "2026-10-21", "--detect-conflicts", "--buffer-minutes", "15"}},
+	{name: "cal_list_chunked", args: []string{"cal", "list", "--from", "2026-09-01", "--to", "2026-11-30"}},
+	{name: "cal_list_table", args: []string{"cal", "list", "--from", "2026-10-20", "--to", "2026-10-21", "-o", "table"}},
+	{name: "cal_show", args: []string{"cal", "show", "evt_standup_0"}},
+	{name: "cal_create", args: []string{"cal", "create", "--summary", "Design review", "--start", "2026-10-22T14:00:00+08:00", "--duration", "45m", "--attendee", "guest@partner.com"}},
+	{name: "cal_create_recurring", args: []string{"cal", "create", "--summary", "Design review", "--start", "2026-10-22T14:00:00+08:00", "--duration", "45m", "--repeat", "weekly", "--on", "TH", "--until", "2026-12-31", "--exclude-self"}},
+	{name: "cal_update", args: []string{"cal", "update", "evt_standup_0", "--summary", "Team standup", "--location", "Room 4", "--scope", "all"}},
+	{name: "cal_update_series_this", args: []string{"cal", "update", "evt_standup_0", "--summary", "Team standup"}},
+	{name: "cal_delete", args: []string{"cal", "delete", "evt_standup_0"}},
+	{name: "cal_search", args: []string{"cal", "search", "standup", "--from", "2026-10-20", "--to", "2026-10-21"}},
+	{name: "cal_freebusy", args: []string{"cal", "freebusy", "--from", "2026-10-20", "--to", "2026-10-20", "--user", "ou_alice"}},
+	{name: "cal_common_freetime", args: []string{"cal", "common-freetime", "--from", "2026-10-20", "--to", "2026-10-20", "--users", "ou_alice,ou_bob"}},
+	{name: "cal_lookup_user", args: []string{"cal", "lookup-user", "--email", "alice@example.com"}},
+	{name: "cal_rsvp", args: []string{"cal", "rsvp", "evt_standup_0", "--accept"}},
+	{name: "cal_attendee_list", args: []string{"cal", "attendee", "list", "evt_standup_0"}},
+	{name: "cal_attendee_list_names", args: []string{"cal", "attendee", "list", "evt_standup_0", "--resolve-names"}},
+	{name: "cal_attendee_add", args: []string{"cal", "attendee", "add", "evt_standup_0", "--email", "guest@partner.com"}},
+	{name: "cal_attendee_remove", args: []string{"cal", "attendee", "remove", "evt_standup_0", "--id", "att_bob"}},
+	{name: "cal_export", args: []string{"cal", "export", "--from", "2026-10-20", "--to", "2026-10-21"}},
+	{name: "cal_import_dry_run", args: []string{"cal", "import", "-", "--dry-run"}, stdin: importICS},
+	{name: "cal_invalid_visibility", args: []string{"cal", "create", "--summary", "x", "--start", "2026-10-22T14:00:00+08:00", "--duration", "30m", "--visibility", "secret"}},
+
+	// Contacts
+	{name: "contact_get", args: []string{"contact", "get", "ou_alice"}},
+	{name: "contact_list_dept", args: []string{"contact", "list-dept", "od_eng"}},
+	{name: "contact_search", args: []string{"contact", "search", "alice"}},
+	{name: "contact_search_dept", args: []string{"contact", "search-dept", "engineering"}},
+
+	// Documents, Drive and Wiki
+	{name: "doc_get", args: []string{"doc", "get", "doxDesign"}},
+	{name: "doc_blocks", args: []string{"doc", "blocks", "doxDesign"}},
+	{name: "doc_list", args: []string{"doc", "list"}},
+	{name: "doc_comments", args: []string{"doc", "comments", "doxDesign"}},
+	{name: "doc_comments_names", args: []string{"doc", "comments", "doxDesign", "--resolve-names"}},
+	{name: "doc_search", args: []string{"doc", "search", "design"}},
+	{name: "doc_wiki", args: []string{"doc", "wiki", "wikHandbook"}},
+	{name: "doc_wiki_children", args: []string{"doc", "wiki-children", "wikHandbook"}},
+	{name: "doc_wiki_search", args: []string{"doc", "wiki-search", "onboarding"}},
+
+	// Sheets
+	{name: "sheet_list", args: []string{"sheet", "list", "shtBudget"}},
+	{name: "sheet_read", args: []string{"sheet", "read", "shtBudget", "--sheet", "s1", "--range", "A1:C3"}},
+
+	// Bitable
+	{name: "bitable_tables", args: []string{"bitable", "tables", "bscTracker"}},
+	{name: "bitable_fields", args: []string{"bitable", "fields", "bscTracker", "tblTasks"}},
+	{name: "bitable_records", args: []string{"bitable", "records", "bscTracker", "tblTasks"}},
+
+	// Messages
+	{name: "chat_search", args: []string{"chat", "search", "eng"}},
+	{name: "msg_history", args: []string{"msg", "history", "--chat-id", "oc_eng"}},
+	{name: "msg_history_text", args: []string{"msg", "history", "--chat-id", "oc_eng", "--format", "text"}},
+	{name: "msg_history_markdown", args: []string{"msg", "history", "--chat-id", "oc_design", "--format", "markdown"}},
+	{name: "msg_history_text_types", args: []string{"msg", "history", "--chat-id", "oc_design", "--format", "text", "-o", "table"}},
+	{name: "msg_history_threaded", args: []string{"msg", "history", "--chat-id", "oc_eng", "--threaded", "--format", "text"}},
+	{name: "msg_history_transcript", args: []string{"msg", "history", "--chat-id", "oc_eng", "--transcript"}},
+	{name: "msg_history_names", args: []string{"msg", "history", "--chat-id", "oc_eng", "--resolve-names", "--format", "text"}},
+	{name: "msg_history_names_unresolved", args: []string{"msg", "history", "--chat-id", "oc_design", "--resolve-names", "--limit", "2"}},
+	{name: "msg_history_transcript_names", args: []string{"msg", "history", "--chat-id", "oc_eng", "--transcript", "--resolve-names", "-o", "go-template={{.content}}"}},
+	{name: "msg_thread", args: []string{"msg", "thread", "om_2", "--format", "markdown"}},
+	{name: "msg_thread_plain", args: []string{"msg", "thread", "om_d2"}},
+	{name: "msg_thread_until", args: []string{"msg", "thread", "om_d2", "--until", "2026-10-21"}},
+	{name: "msg_thread_transcript", args: []string{"msg", "thread", "om_2", "--transcript", "-o", "go-template={{.content}}"}},
+	{name: "msg_sync", args: []string{"msg", "sync", "--chat-id", "oc_eng"}},
+	{name: "msg_sync_incremental", args: []string{"msg", "sync", "--chat-id", "oc_eng", "--skip-reactions", "--quiet"}, setup: [][]string{
+		{"msg", "sync", "--chat-id", "oc_eng", "--quiet"},
+	}},
+	{name: "msg_sync_empty_since", args: []string{"msg", "sync", "--chat-id", "oc_quiet", "--since", "2026-10-01", "--quiet"}, setup: [][]string{
+		{"msg", "sync", "--chat-id", "oc_quiet", "--quiet"},
+	}},
+	{name: "msg_search", args: []string{"msg", "search", "example.com/specs"}, setup: msgArchiveSetup},
+	{name: "msg_search_reply", args: []string{"msg", "search", "closing ticket", "--limit", "5"}, setup: msgArchiveSetup},
+	{name: "msg_search_filters", args: []string{"msg", "search", "ship*", "--chat", "oc_eng", "--from", "ou_me", "--since", "2026-10-20"}, setup: msgArchiveSetup},
+	{name: "msg_search_recent", args: []string{"msg", "search", "--chat", "oc_design", "--limit", "2", "-o", "table"}, setup: msgArchiveSetup},
+	{name: "msg_search_blank", args: []string{"msg", "search", "  ", "--chat", "oc_design", "--limit", "2"}, setup: msgArchiveSetup},
+	{name: "msg_history_bad_format", args: []string{"msg", "history", "--chat-id", "oc_eng", "--format", "html"}},
+	{name: "msg_send", args: []string{"msg", "send", "--to", "oc_eng", "--text", "Deploy is **done**"}},
+	{name: "msg_send_markdown", args: []string{"msg", "send", "--to", "oc_eng", "--text", "# Release\\n\\n~~v1~~ **v2** is out :THUMBSUP: @all\\n\\n```sh\\nmake deploy\\n```\\n\\n- fixed `2 \\* 3`"}},
+	{name: "msg_send_files", args: []string{"msg", "send", "--to", "oc_eng", "--text", "Build 1234\\n{{video}}", "--file", "testdata/files/report.pdf", "--file", "testdata/files/demo.mp4"}},
+	{name: "msg_send_file_only", args: []string{"msg", "send", "--to", "oc_eng", "--file", "testdata/files/build.log", "--quiet"}},
+	{name: "msg_send_file_empty", args: []string{"msg", "send", "--to", "oc_eng", "--file", "testdata/files/empty.log"}},
+	{name: "msg_send_card", args: []string{"msg", "send", "--to", "oc_eng", "--card", "testdata/cards/deploy.yaml", "--vars", "testdata/cards/vars.yaml", "--var", "version=v1.4.3"}},
+	{name: "msg_send_template", args: []string{"msg", "send", "--to", "oc_eng", "--template", "testdata/templates/release.md", "--var", "version=1.2.3", "--var", "summary=Builds are **twice** as fast.", "--image", "testdata/cards/chart.png"}},
+	{name: "msg_send_template_missing_var", args: []string{"msg", "send", "--to", "oc_eng", "--template", "testdata/templates/release.md", "--var", "version=1.2.3"}},
+	{name: "msg_schedule", args: []string{"msg", "schedule", "--at", "2026-10-20T09:00", "--to", "oc_eng", "--template", "testdata/templates/release.md", "--vars", "testdata/cards/vars.yaml", "--var", "summary=Weekly release", "--var", "notes=Rollout starts at 10:00.", "--image", "testdata/cards/chart.png", "--file", "testdata/files/report.pdf"}},
+	{name: "msg_schedule_missing_image", args: []string{"msg", "schedule", "--at", "2026-10-20T09:00", "--to", "oc_eng", "--template", "testdata/templates/release.md", "--vars", "testdata/cards/vars.yaml", "--var", "summary=Weekly release"}},
+	{name: "msg_schedule_no_time", args: []string{"msg", "schedule", "--to", "oc_eng", "--text", "Hi"}},
+	{name: "msg_outbox_run", args: []string{"msg", "outbox", "run"}, setup: [][]string{
+		{"msg", "schedule", "--at", "2026-01-05T09:00", "--to", "oc_eng", "--text", "Standup in 5 minutes"},
+		{"msg", "schedule", "--at", "2026-01-05T09:00", "--to", "oc_eng", "--parent-id", "om_missing", "--text", "Following up"},
+		{"msg", "schedule", "--at", "2099-01-05T09:00", "--to", "oc_eng", "--text", "Happy new year"},
+	}},
+	{name: "msg_outbox_list", args: []string{"msg", "outbox", "list", "-o", "table"}, setup: [][]string{
+		{"msg", "schedule", "--at", "2026-01-05T09:00", "--to", "oc_eng", "--text", "Standup in 5 minutes"},
+		{"msg", "schedule", "--at", "2099-01-05T09:00", "--to", "oc_eng", "--text", "Happy new year"},
+		{"msg", "schedule", "--at", "2099-01-06T09:00", "--to", "oc_eng", "--text", "Back to work"},
+		{"msg", "outbox", "run"},
+		{"msg", "outbox", "cancel", "3"},
+	}},
+	{name: "msg_outbox_cancel_sent", args: []string{"msg", "outbox", "cancel", "1"}, setup: [][]string{
+		{"msg", "schedule", "--at", "2026-01-05T09:00", "--to", "oc_eng", "--text", "Standup in 5 minutes"},
+		{"msg", "outbox", "run"},
+	}},
+	{name: "msg_outbox_retry", args: []string{"msg", "outbox", "retry", "1"}, setup: [][]string{
+		{"msg", "schedule", "--at", "2026-01-05T09:00", "--to", "oc_eng", "--parent-id", "om_missing", "--text", "Following up"},
+		{"msg", "outbox", "run"},
+	}},
+	{name: "msg_outbox_retry_pending", args: []string{"msg", "outbox", "retry", "1"}, setup: [][]string{
+		{"msg", "schedule", "--at", "2099-01-05T09:00", "--to", "oc_eng", "--text", "Happy new year"},
+	}},
+	{name: "msg_card_preview", args: []string{"msg", "card", "preview", "testdata/cards/deploy.yaml", "--vars", "testdata/cards/vars.yaml", "--var", "failed=true"}},
+	{name: "msg_card_missing_var", args: []string{"msg", "card", "preview", "testdata/cards/deploy.yaml", "--var", "service=api"}},
+	{name: "msg_card_invalid", args: []string{"msg", "card", "preview", "testdata/cards/invalid.json"}},
+	{name: "msg_react", args: []string{"msg", "react", "--message-id", "om_1", "--reaction", "THUMBSUP"}},
+	{name: "msg_react_list", args: []string{"msg", "react", "list", "--message-id", "om_1"}},
+	{name: "msg_react_list_names", args: []string{"msg", "react", "list", "--message-id", "om_1", "--resolve-names"}},
+	{name: "msg_react_list_names_cached", args: []string{"msg", "react", "list", "--message-id", "om_1", "--resolve-names"}, setup: [][]string{
+		{"msg", "history", "--chat-id", "oc_eng", "--resolve-names"},
+	}},
+	{name: "msg_watch", args: []string{"msg", "watch", "--chat-id", "oc_eng", "--count", "3", "--quiet"}},
+	{name: "msg_watch_reactions", args: []string{"msg", "watch", "--events", "reaction", "--count", "1", "--quiet"}},
+	{name: "msg_recall", args: []string{"msg", "recall", "om_1"}},
+	{name: "msg_edit", args: []string{"msg", "edit", "om_sent", "--text", "Deploy is **done**\\nRolled out to all regions"}},
+	{name: "msg_edit_text_image", args: []string{"msg", "edit", "om_sent", "--text", "x", "--msg-type", "text", "--image", "testdata/cards/chart.png"}},
+	{name: "msg_forward", args: []string{"msg", "forward", "om_1", "--to", "oc_design"}},
+	{name: "msg_merge_forward", args: []string{"msg", "merge-forward", "om_1", "om_2", "om_3", "--to", "ou_alice"}},
+	{name: "msg_pin", args: []string{"msg", "pin", "om_1"}},
+	{name: "msg_unpin", args: []string{"msg", "unpin", "om_1"}},
+	{name: "msg_urgent", args: []string{"msg", "urgent", "om_sent", "--users", "ou_alice,ou_bob"}},
+	{name: "msg_urgent_mixed_ids", args: []string{"msg", "urgent", "om_sent", "--users", "ou_alice,12345"}},
+
+	// Minutes
+	{name: "minutes_get", args: []string{"minutes", "get", "obcnMinute"}},
+	{name: "minutes_transcript", args: []string{"minutes", "transcript", "obcnMinute"}},
+	{name: "minutes_transcript_out", args: []string{"minutes", "transcript", "obcnMinute", "--out", "/dev/null"}},
+	{name: "minutes_transcript_legacy_output", args: []string{"minutes", "transcript", "obcnMinute", "-o", "/dev/null"}},
+	{name: "minutes_transcript_yaml", args: []string{"minutes", "transcript", "obcnMinute", "-o", "yaml"}},
+
+	// Errors
+	{name: "api_error", args: []string{"cal", "show", "evt_missing"}},
+}
+
+// msgArchiveSetup archives two chats for the msg search cases
+var msgArchiveSetup = [][]string{
+	{"msg", "sync", "--chat-id", "oc_eng", "--quiet"},
+	{"msg", "sync", "--chat-id", "oc_design", "--skip-reactions", "--quiet"},
+}
+
+const importICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:partner-kickoff@partner.com\r\n" +
+	"SUMMARY:Partner kickoff\r\n" +
+	"DTSTART;TZID=America/New_York:20261021T090000\r\n" +
+	"DTEND;TZID=America/New_York:20261021T100000\r\n" +
+	"URL:https://meet.partner.com/kickoff\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup-series@lark\r\n" +
+	"SUMMARY:Already imported\r\n" +
+	"DTSTART:20261020T010000Z\r\n" +
+	"DTEND:20261020T011500Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestGolden(t *testing.T) {
+	fixtures, err := larktest.LoadFixtures(filepath.Join("testdata", "fixtures"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := larktest.NewServer(fixtures)
+	defer server.Close()
+
+	for _, tc := range goldenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configDir := newConfigDir(t, server.URL)
+			for _, args := range tc.setup {
+				if out, code := runLark(t, configDir, args, ""); code != 0 {
+					t.Fatalf("setup lark %s failed:\n%s", strings.Join(args, " "), out)
+				}
+			}
+			server.Reset()
+			stdout, exitCode := runLark(t, configDir, tc.args, tc.stdin)
+			got := formatGolden(tc.args, stdout, exitCode, server.Requests())
+
+			path := filepath.Join("testdata", "golden", tc.name+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test ./cmd/lark -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("output differs from %s (run go test ./cmd/lark -update to accept)\n--- got\n%s\n--- want\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+// newConfigDir creates a config directory for the fake server with a
+// logged-in user
+func newConfigDir(t *testing.T, baseURL string) string {
+	t.Helper()
+
+	configDir := t.TempDir()
+	writeFile(t, filepath.Join(configDir, "config.yaml"), fmt.Sprintf(`app_id: "cli_test"
+base_url: %q
+defaults:
+  timezone: "Asia/Singapore"
+  reminder_minutes: 15
+`, baseURL))
+	writeFile(t, filepath.Join(configDir, "tokens.json"), fmt.Sprintf(`{
+  "access_token": "u-test",
+  "refresh_token": "r-test",
+  "expires_at": "2099-01-01T00:00:00Z",
+  "refresh_token_expires_at": "2099-01-01T00:00:00Z",
+  "scope": %q
+}`, allScopes()))
+	writeFile(t, filepath.Join(configDir, "tenant_tokens.json"), `{
+  "tenant_access_token": "t-test",
+  "expires_at": "2099-01-01T00:00:00Z"
+}`)
+	return configDir
+}
+
+// runLark runs the CLI with a config directory, returning stdout and the
+// exit code
+func runLark(t *testing.T, configDir string, args []string, stdin string) (string, int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = []string{
+		runMainEnv + "=1",
+		"LARK_CONFIG_DIR=" + configDir,
+		"LARK_APP_SECRET=test-secret",
+		"HOME=" + configDir,
+		"TZ=UTC",
+		"PATH=" + os.Getenv("PATH"),
+	}
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stdout
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatal(err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	return stdout.String(), exitCode
+}
+
+// allScopes grants every scope group so no command is refused locally
+func allScopes() string {
+	var all []string
+	for _, group := range scopes.Groups {
+		all = append(all, group.Scopes...)
+	}
+	return strings.Join(all, " ")
+}
+
+// volatile matches output that changes on every run
+var volatile = []struct {
+	pattern *regexp.Regexp
+	repl    string
+}{
+	{regexp.MustCompile(`(?m)^DTSTAMP:\d{8}T\d{6}Z\r?$`), "DTSTAMP:<now>"},
+	{regexp.MustCompile(`"last_sync": "[^"]*"`), `"last_sync": "<now>"`},
+	{regexp.MustCompile(`"sent_at": "[^"]*"`), `"sent_at": "<now>"`},
+}
+
+func formatGolden(args []string, stdout string, exitCode int, requests []larktest.Request) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "$ lark %s\n", strings.Join(args, " "))
+	fmt.Fprintf(&b, "exit: %d\n", exitCode)
+	b.WriteString("--- output\n")
+	for _, v := range volatile {
+		stdout = v.pattern.ReplaceAllString(stdout, v.repl)
+	}
+	b.WriteString(stdout)
+	b.WriteString("--- requests\n")
+	for _, r := range requests {
+		line := r.Method + " " + r.Path
+		if r.Query != "" {
+			line += "?" + r.Query
+		}
+		b.WriteString(line + "\n")
+		if r.Body != "" {
+			b.WriteString(r.Body + "\n")
+		}
+	}
+	return b.String()
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -22,10 +22,6 @@ func (c *Client) ListBitableTables(appToken string) ([]BitableTable, error) {
 			return nil, err
 		}
 
-		if resp.Code != 0 {
-			return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-		}
-
 		allTables = append(allTables, resp.Data.Items...)
 
 		if !resp.Data.HasMore || resp.Data.PageToken == "" {
@@ -54,10 +50,6 @@ func (c *Client) ListBitableFields(appToken, tableID string) ([]BitableField, er
 			return nil, err
 		}
 
-		if resp.Code != 0 {
-			return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-		}
-
 		allFields = append(allFields, resp.Data.Items...)
 
 		if !resp.Data.HasMore || resp.Data.PageToken == "" {
@@ -120,9 +112,5 @@ func (c *Client) ListBitableRecords(appToken, tableID string, opts *BitableRecor
 		return nil, false, "", err
 	}
 
-	if resp.Code != 0 {
-		return nil, false, "", fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data.Items, resp.Data.HasMore, resp.Data.PageToken, nil
 }
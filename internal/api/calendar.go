@@ -12,10 +12,6 @@ func (c *Client) GetPrimaryCalendar() (*Calendar, error) {
 		return nil, err
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error (code %d): %s", resp.Code, resp.Msg)
-	}
-
 	// Primary calendar returns an array of calendars
 	if len(resp.Data.Calendars) > 0 && resp.Data.Calendars[0].Calendar != nil {
 		return resp.Data.Calendars[0].Calendar, nil
@@ -38,10 +34,6 @@ func (c *Client) GetCalendar(calendarID string) (*Calendar, error) {
 		return nil, err
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error (code %d): %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data.Calendar, nil
 }
 
@@ -61,10 +53,6 @@ func (c *Client) ListCalendars() ([]Calendar, error) {
 			return nil, err
 		}
 
-		if resp.Code != 0 {
-			return nil, fmt.Errorf("API error (code %d): %s", resp.Code, resp.Msg)
-		}
-
 		allCalendars = append(allCalendars, resp.Data.Calendars...)
 
 		if !resp.Data.HasMore {
@@ -44,9 +44,22 @@ func (c *Client) SearchChats(opts *SearchChatsOptions) ([]Chat, bool, string, er
 		return nil, false, "", err
 	}
 
-	if resp.Code != 0 {
-		return nil, false, "", fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
+	return resp.Data.Items, resp.Data.HasMore, resp.Data.PageToken, nil
+}
+
+// GetChat retrieves a chat's info
+func (c *Client) GetChat(chatID string) (*Chat, error) {
+	path := fmt.Sprintf("/im/v1/chats/%s", url.PathEscape(chatID))
+
+	var resp GetChatResponse
+	if err := c.GetWithTenantToken(path, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("chat %s not found", chatID)
 	}
 
-	return resp.Data.Items, resp.Data.HasMore, resp.Data.PageToken, nil
+	chat := resp.Data
+	chat.ChatID = chatID
+	return chat, nil
 }
@@ -9,16 +9,25 @@ import (
 	"time"
 
 	"github.com/yjwong/lark-cli/internal/auth"
+	"github.com/yjwong/lark-cli/internal/config"
 )
 
 const (
-	baseURL        = "https://open.larksuite.com/open-apis"
+	apiPathPrefix  = "/open-apis"
 	defaultTimeout = 30 * time.Second
+
+	// Retry settings for rate-limited (99991400) responses, and 5xx
+	// responses to idempotent requests
+	defaultMaxRetries = 3
+	baseRetryDelay    = 500 * time.Millisecond
+	maxRetryDelay     = 30 * time.Second
 )
 
 // Client is the Lark API client
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
+	maxRetries int
 }
 
 // NewClient creates a new API client
@@ -27,210 +36,214 @@ func NewClient() *Client {
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
+		baseURL:    config.GetBaseURL() + apiPathPrefix,
+		maxRetries: defaultMaxRetries,
 	}
 }
 
-// doRequest performs an authenticated HTTP request
-func (c *Client) doRequest(method, path string, body interface{}, result interface{}) error {
-	// Ensure we have a valid token
+// tokenSource returns a valid bearer token, refreshing it first if needed
+type tokenSource func() (string, error)
+
+// userToken returns the user access token
+func userToken() (string, error) {
 	if err := auth.EnsureValidToken(); err != nil {
-		return err
+		return "", err
 	}
+	return auth.GetTokenStore().GetAccessToken(), nil
+}
 
-	var reqBody io.Reader
+// tenantToken returns the tenant (app-level) access token
+func tenantToken() (string, error) {
+	if err := auth.EnsureValidTenantToken(); err != nil {
+		return "", err
+	}
+	return auth.GetTenantTokenStore().GetAccessToken(), nil
+}
+
+// doRequest performs an authenticated JSON request.
+// Lark error envelopes (code != 0) and non-2xx statuses are returned as *Error,
+// so a nil error means result holds a successful response.
+func (c *Client) doRequest(method, path string, body interface{}, result interface{}, token tokenSource) error {
+	var payload []byte
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonBody)
+		payload = jsonBody
 	}
 
-	url := baseURL + path
-	req, err := http.NewRequest(method, url, reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+	return c.doRaw(method, path, payload, "application/json; charset=utf-8", result, token)
+}
 
-	// Set headers
-	token := auth.GetTokenStore().GetAccessToken()
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+// doRaw sends a pre-encoded body and decodes the JSON response into result,
+// retrying rate-limited responses, and 5xx responses to idempotent
+// requests, with backoff
+func (c *Client) doRaw(method, path string, payload []byte, contentType string, result interface{}, token tokenSource) error {
+	return c.doRawWithProgress(method, path, payload, contentType, result, token, nil)
+}
 
-	// Execute request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
+// doRawWithProgress is doRaw, calling progress as the body is sent
+func (c *Client) doRawWithProgress(method, path string, payload []byte, contentType string, result interface{}, token tokenSource, progress func(sent int64)) error {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(method, path, payload, contentType, token, progress)
+		if err != nil {
+			return err
+		}
 
-	// Read response
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
 
-	// Parse response
-	if result != nil {
-		if err := json.Unmarshal(respBody, result); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
+		if apiErr := decodeError(resp, respBody); apiErr != nil {
+			if shouldRetry(method, apiErr, resp.Header) && attempt < c.maxRetries {
+				time.Sleep(retryDelay(attempt, resp.Header))
+				continue
+			}
+			return apiErr
 		}
-	}
 
-	return nil
-}
+		if result != nil {
+			if err := json.Unmarshal(respBody, result); err != nil {
+				return fmt.Errorf("failed to parse response: %w", err)
+			}
+		}
 
-// Get performs a GET request
-func (c *Client) Get(path string, result interface{}) error {
-	return c.doRequest("GET", path, nil, result)
+		return nil
+	}
 }
 
-// Post performs a POST request
-func (c *Client) Post(path string, body interface{}, result interface{}) error {
-	return c.doRequest("POST", path, body, result)
-}
+// download performs a GET request that returns binary data, retrying
+// rate-limited and 5xx responses. The caller must close the returned body.
+func (c *Client) download(path string, token tokenSource) (io.ReadCloser, string, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send("GET", path, nil, "", token, nil)
+		if err != nil {
+			return nil, "", err
+		}
 
-// Patch performs a PATCH request
-func (c *Client) Patch(path string, body interface{}, result interface{}) error {
-	return c.doRequest("PATCH", path, body, result)
-}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp.Body, resp.Header.Get("Content-Type"), nil
+		}
 
-// Delete performs a DELETE request
-func (c *Client) Delete(path string, result interface{}) error {
-	return c.doRequest("DELETE", path, nil, result)
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		apiErr := decodeError(resp, respBody)
+		if shouldRetry("GET", apiErr, resp.Header) && attempt < c.maxRetries {
+			time.Sleep(retryDelay(attempt, resp.Header))
+			continue
+		}
+		return nil, "", apiErr
+	}
 }
 
-// doRequestWithTenantToken performs an HTTP request using tenant access token
-func (c *Client) doRequestWithTenantToken(method, path string, body interface{}, result interface{}) error {
-	// Ensure we have a valid tenant token
-	if err := auth.EnsureValidTenantToken(); err != nil {
-		return err
+// send executes a single HTTP request with a fresh bearer token
+func (c *Client) send(method, path string, payload []byte, contentType string, token tokenSource, progress func(sent int64)) (*http.Response, error) {
+	accessToken, err := token()
+	if err != nil {
+		return nil, err
 	}
 
 	var reqBody io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+		if progress != nil {
+			reqBody = &progressReader{r: reqBody, fn: progress}
 		}
-		reqBody = bytes.NewBuffer(jsonBody)
 	}
 
-	url := baseURL + path
-	req, err := http.NewRequest(method, url, reqBody)
+	req, err := http.NewRequest(method, c.baseURL+path, reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if payload != nil {
+		req.ContentLength = int64(len(payload))
 	}
 
-	// Set headers with tenant token
-	token := auth.GetTenantTokenStore().GetAccessToken()
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 
-	// Execute request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	defer resp.Body.Close()
+	return resp, nil
+}
 
-	// Read response
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
+// progressReader reports how much of a request body has been read
+type progressReader struct {
+	r    io.Reader
+	sent int64
+	fn   func(sent int64)
+}
 
-	// Parse response
-	if result != nil {
-		if err := json.Unmarshal(respBody, result); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
-		}
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.fn(p.sent)
 	}
+	return n, err
+}
 
-	return nil
+// Get performs a GET request
+func (c *Client) Get(path string, result interface{}) error {
+	return c.doRequest("GET", path, nil, result, userToken)
+}
+
+// Post performs a POST request
+func (c *Client) Post(path string, body interface{}, result interface{}) error {
+	return c.doRequest("POST", path, body, result, userToken)
+}
+
+// Patch performs a PATCH request
+func (c *Client) Patch(path string, body interface{}, result interface{}) error {
+	return c.doRequest("PATCH", path, body, result, userToken)
+}
+
+// Delete performs a DELETE request
+func (c *Client) Delete(path string, result interface{}) error {
+	return c.doRequest("DELETE", path, nil, result, userToken)
 }
 
 // PostWithTenantToken performs a POST request using tenant access token
 func (c *Client) PostWithTenantToken(path string, body interface{}, result interface{}) error {
-	return c.doRequestWithTenantToken("POST", path, body, result)
+	return c.doRequest("POST", path, body, result, tenantToken)
 }
 
 // GetWithTenantToken performs a GET request using tenant access token
 func (c *Client) GetWithTenantToken(path string, result interface{}) error {
-	return c.doRequestWithTenantToken("GET", path, nil, result)
+	return c.doRequest("GET", path, nil, result, tenantToken)
+}
+
+// PutWithTenantToken performs a PUT request using tenant access token
+func (c *Client) PutWithTenantToken(path string, body interface{}, result interface{}) error {
+	return c.doRequest("PUT", path, body, result, tenantToken)
+}
+
+// PatchWithTenantToken performs a PATCH request using tenant access token
+func (c *Client) PatchWithTenantToken(path string, body interface{}, result interface{}) error {
+	return c.doRequest("PATCH", path, body, result, tenantToken)
 }
 
 // DeleteWithTenantToken performs a DELETE request using tenant access token
 func (c *Client) DeleteWithTenantToken(path string, result interface{}) error {
-	return c.doRequestWithTenantToken("DELETE", path, nil, result)
+	return c.doRequest("DELETE", path, nil, result, tenantToken)
 }
 
 // DownloadWithTenantToken performs a GET request that returns binary data
 // The caller is responsible for closing the returned ReadCloser
 func (c *Client) DownloadWithTenantToken(path string) (io.ReadCloser, string, error) {
-	// Ensure we have a valid tenant token
-	if err := auth.EnsureValidTenantToken(); err != nil {
-		return nil, "", err
-	}
-
-	url := baseURL + path
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers with tenant token
-	token := auth.GetTenantTokenStore().GetAccessToken()
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	// Execute request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, "", fmt.Errorf("request failed: %w", err)
-	}
-
-	// Check for error response (non-2xx status)
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, "", fmt.Errorf("API error (HTTP %d): %s", resp.StatusCode, string(body))
-	}
-
-	contentType := resp.Header.Get("Content-Type")
-	return resp.Body, contentType, nil
+	return c.download(path, tenantToken)
 }
 
 // Download performs a GET request that returns binary data using user access token
 // The caller is responsible for closing the returned ReadCloser
 func (c *Client) Download(path string) (io.ReadCloser, string, error) {
-	// Ensure we have a valid token
-	if err := auth.EnsureValidToken(); err != nil {
-		return nil, "", err
-	}
-
-	url := baseURL + path
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers with user token
-	token := auth.GetTokenStore().GetAccessToken()
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	// Execute request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, "", fmt.Errorf("request failed: %w", err)
-	}
-
-	// Check for error response (non-2xx status)
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, "", fmt.Errorf("API error (HTTP %d): %s", resp.StatusCode, string(body))
-	}
-
-	contentType := resp.Header.Get("Content-Type")
-	return resp.Body, contentType, nil
+	return c.download(path, userToken)
 }
@@ -20,11 +20,28 @@ func (c *Client) GetUser(userID string, idType string) (*ContactUser, error) {
 		return nil, err
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
+	return resp.Data.User, nil
+}
+
+// BatchGetUsers retrieves users by open_id, at most 50 at a time. Users the
+// app can't see are left out of the result.
+func (c *Client) BatchGetUsers(openIDs []string) ([]ContactUser, error) {
+	if len(openIDs) > 50 {
+		return nil, fmt.Errorf("at most 50 users can be fetched at once, got %d", len(openIDs))
 	}
 
-	return resp.Data.User, nil
+	params := url.Values{}
+	for _, id := range openIDs {
+		params.Add("user_ids", id)
+	}
+	params.Set("user_id_type", "open_id")
+
+	var resp BatchGetUsersResponse
+	if err := c.GetWithTenantToken("/contact/v3/users/batch?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Data.Items, nil
 }
 
 // ListUsersByDepartment retrieves users directly under a department
@@ -51,10 +68,6 @@ func (c *Client) ListUsersByDepartment(deptID string, pageSize int, pageToken st
 		return nil, false, "", err
 	}
 
-	if resp.Code != 0 {
-		return nil, false, "", fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data.Items, resp.Data.HasMore, resp.Data.PageToken, nil
 }
 
@@ -68,10 +81,6 @@ func (c *Client) GetDepartment(deptID string) (*Department, error) {
 		return nil, err
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data.Department, nil
 }
 
@@ -103,10 +112,6 @@ func (c *Client) SearchUsers(query string, pageSize int, pageToken string) ([]Se
 		return nil, false, "", err
 	}
 
-	if resp.Code != 0 {
-		return nil, false, "", fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data.Users, resp.Data.HasMore, resp.Data.PageToken, nil
 }
 
@@ -135,9 +140,5 @@ func (c *Client) SearchDepartments(query string, pageSize int, pageToken string)
 		return nil, false, "", err
 	}
 
-	if resp.Code != 0 {
-		return nil, false, "", fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data.Items, resp.Data.HasMore, resp.Data.PageToken, nil
 }
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lark error codes that callers commonly branch on
+const (
+	// CodeRateLimited is returned when the app exceeds its request frequency limit
+	CodeRateLimited = 99991400
+)
+
+// Error is a Lark Open API error decoded from the code/msg response envelope
+type Error struct {
+	Code       int    `json:"code"`
+	Msg        string `json:"msg"`
+	LogID      string `json:"log_id,omitempty"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	var msg string
+	if e.Code != 0 {
+		msg = fmt.Sprintf("API error (code %d): %s", e.Code, e.Msg)
+	} else {
+		msg = fmt.Sprintf("API error (HTTP %d): %s", e.HTTPStatus, e.Msg)
+	}
+	if e.LogID != "" {
+		msg += " [log_id: " + e.LogID + "]"
+	}
+	return msg
+}
+
+// Details returns the structured fields included in CLI error output
+func (e *Error) Details() map[string]interface{} {
+	details := map[string]interface{}{
+		"lark_code": e.Code,
+	}
+	if e.HTTPStatus != 0 {
+		details["http_status"] = e.HTTPStatus
+	}
+	if e.LogID != "" {
+		details["log_id"] = e.LogID
+	}
+	return details
+}
+
+// shouldRetry reports whether a request that failed with apiErr may be sent
+// again. A rate-limited request, or one the server asked to be retried with
+// Retry-After, wasn't processed, so any method is retried. A 5xx may come
+// after a write was committed, so only idempotent methods are retried;
+// retrying a POST could send a message or create an event twice.
+func shouldRetry(method string, apiErr *Error, header http.Header) bool {
+	if apiErr.Code == CodeRateLimited || apiErr.HTTPStatus == http.StatusTooManyRequests {
+		return true
+	}
+	if header.Get("Retry-After") != "" {
+		return true
+	}
+	if apiErr.HTTPStatus < 500 {
+		return false
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// IsCode reports whether err is a Lark API error with the given code
+func IsCode(err error, code int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// decodeError extracts an API error from a response, or returns nil if the
+// response is a success. Both the HTTP status and the code/msg envelope are checked.
+func decodeError(resp *http.Response, body []byte) *Error {
+	var envelope struct {
+		Code  int             `json:"code"`
+		Msg   string          `json:"msg"`
+		Error json.RawMessage `json:"error"`
+	}
+	parsed := json.Unmarshal(body, &envelope) == nil
+
+	logID := resp.Header.Get("X-Tt-Logid")
+	if parsed && len(envelope.Error) > 0 {
+		var detail struct {
+			LogID string `json:"log_id"`
+		}
+		if json.Unmarshal(envelope.Error, &detail) == nil && detail.LogID != "" {
+			logID = detail.LogID
+		}
+	}
+
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if parsed && envelope.Code != 0 {
+		return &Error{
+			Code:       envelope.Code,
+			Msg:        envelope.Msg,
+			LogID:      logID,
+			HTTPStatus: resp.StatusCode,
+		}
+	}
+	if success {
+		return nil
+	}
+
+	msg := envelope.Msg
+	if msg == "" {
+		msg = strings.TrimSpace(string(body))
+	}
+	if msg == "" {
+		msg = http.StatusText(resp.StatusCode)
+	}
+	return &Error{
+		Msg:        msg,
+		LogID:      logID,
+		HTTPStatus: resp.StatusCode,
+	}
+}
+
+// retryDelay returns how long to wait before the next attempt.
+// Server hints (Retry-After, x-ogw-ratelimit-reset) take precedence over
+// exponential backoff with jitter.
+func retryDelay(attempt int, header http.Header) time.Duration {
+	if d, ok := serverRetryHint(header); ok {
+		switch {
+		case d < 0:
+			return 0
+		case d > maxRetryDelay:
+			return maxRetryDelay
+		}
+		return d
+	}
+
+	d := baseRetryDelay << attempt
+	if d > maxRetryDelay || d <= 0 {
+		d = maxRetryDelay
+	}
+	// Jitter in [d/2, d) so concurrent callers don't retry in lockstep
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+// serverRetryHint parses rate limit headers into a wait duration
+func serverRetryHint(header http.Header) (time.Duration, bool) {
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t), true
+		}
+	}
+	if v := header.Get("X-Ogw-Ratelimit-Reset"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+	return 0, false
+}
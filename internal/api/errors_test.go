@@ -0,0 +1,160 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		body   string
+		want   *Error
+	}{
+		{"success", 200, nil, `{"code":0,"msg":"success","data":{}}`, nil},
+		{"success without envelope", 204, nil, ``, nil},
+		{
+			"lark error with HTTP 200", 200, http.Header{"X-Tt-Logid": {"hdr-log"}},
+			`{"code":230002,"msg":"Bot is not in the chat"}`,
+			&Error{Code: 230002, Msg: "Bot is not in the chat", LogID: "hdr-log", HTTPStatus: 200},
+		},
+		{
+			"log_id in error detail wins", 400, http.Header{"X-Tt-Logid": {"hdr-log"}},
+			`{"code":99991663,"msg":"Invalid access token","error":{"log_id":"body-log"}}`,
+			&Error{Code: 99991663, Msg: "Invalid access token", LogID: "body-log", HTTPStatus: 400},
+		},
+		{
+			"rate limited", 429, nil, `{"code":99991400,"msg":"request trigger frequency limit"}`,
+			&Error{Code: CodeRateLimited, Msg: "request trigger frequency limit", HTTPStatus: 429},
+		},
+		{"plain text body", 502, nil, "Bad Gateway\n", &Error{Msg: "Bad Gateway", HTTPStatus: 502}},
+		{"empty body", 503, nil, "", &Error{Msg: "Service Unavailable", HTTPStatus: 503}},
+		{"envelope without code", 500, nil, `{"msg":"internal"}`, &Error{Msg: "internal", HTTPStatus: 500}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: tt.header}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+			got := decodeError(resp, []byte(tt.body))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeError = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 0; attempt < 4; attempt++ {
+		d := baseRetryDelay << attempt
+		for i := 0; i < 20; i++ {
+			got := retryDelay(attempt, http.Header{})
+			if got < d/2 || got > d {
+				t.Fatalf("attempt %d: delay %v outside [%v, %v]", attempt, got, d/2, d)
+			}
+		}
+	}
+	if got := retryDelay(20, http.Header{}); got < maxRetryDelay/2 || got > maxRetryDelay {
+		t.Errorf("large attempt: delay %v not capped at %v", got, maxRetryDelay)
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"Retry-After seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{"Retry-After capped", http.Header{"Retry-After": {"600"}}, maxRetryDelay},
+		{"Retry-After in the past", http.Header{"Retry-After": {"Mon, 02 Jan 2006 15:04:05 GMT"}}, 0},
+		{"rate limit reset", http.Header{"X-Ogw-Ratelimit-Reset": {"2"}}, 2 * time.Second},
+		{"Retry-After wins", http.Header{"Retry-After": {"1"}, "X-Ogw-Ratelimit-Reset": {"5"}}, time.Second},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(0, tt.header); got != tt.want {
+			t.Errorf("%s: delay = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	rateLimited := &Error{Code: CodeRateLimited, HTTPStatus: 200}
+	tooMany := &Error{HTTPStatus: 429}
+	serverError := &Error{HTTPStatus: 500}
+	badRequest := &Error{Code: 99992402, HTTPStatus: 400}
+	retryAfter := http.Header{"Retry-After": {"1"}}
+
+	tests := []struct {
+		method string
+		err    *Error
+		header http.Header
+		want   bool
+	}{
+		{"POST", rateLimited, nil, true},
+		{"PATCH", tooMany, nil, true},
+		{"GET", serverError, nil, true},
+		{"PUT", serverError, nil, true},
+		{"DELETE", serverError, nil, true},
+		{"POST", serverError, nil, false},
+		{"PATCH", serverError, nil, false},
+		{"POST", serverError, retryAfter, true},
+		{"GET", badRequest, nil, false},
+	}
+	for _, tt := range tests {
+		header := tt.header
+		if header == nil {
+			header = http.Header{}
+		}
+		if got := shouldRetry(tt.method, tt.err, header); got != tt.want {
+			t.Errorf("shouldRetry(%s, %+v, %v) = %v, want %v", tt.method, tt.err, tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestDoRequestRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if r.URL.Path == "/rate-limited" && n == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"code":99991400,"msg":"request trigger frequency limit"}`))
+			return
+		}
+		if r.URL.Path == "/flaky" && n == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer server.Close()
+
+	c := &Client{httpClient: server.Client(), baseURL: server.URL, maxRetries: 1}
+	token := func() (string, error) { return "t", nil }
+
+	tests := []struct {
+		method, path string
+		wantErr      bool
+		wantCalls    int32
+	}{
+		{"POST", "/rate-limited", false, 2},
+		{"POST", "/flaky", true, 1},
+		{"GET", "/flaky", false, 2},
+	}
+	for _, tt := range tests {
+		calls.Store(0)
+		err := c.doRequest(tt.method, tt.path, nil, nil, token)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s %s: err = %v", tt.method, tt.path, err)
+		}
+		if got := calls.Load(); got != tt.wantCalls {
+			t.Errorf("%s %s: %d calls, want %d", tt.method, tt.path, got, tt.wantCalls)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package api
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -10,8 +9,17 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits on files uploaded for messages
+const (
+	MaxMessageFileSize  = 30 << 20 // im/v1/files
+	MaxMessageImageSize = 10 << 20 // im/v1/images
 
-	"github.com/yjwong/lark-cli/internal/auth"
+	uploadTimeout = 5 * time.Minute
 )
 
 // ListMessagesOptions contains optional parameters for ListMessages
@@ -75,10 +83,6 @@ func (c *Client) ListMessages(containerIDType, containerID string, opts *ListMes
 		return nil, false, "", err
 	}
 
-	if resp.Code != 0 {
-		return nil, false, "", fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data.Items, resp.Data.HasMore, resp.Data.PageToken, nil
 }
 
@@ -113,10 +117,6 @@ func (c *Client) ListMessageReactions(messageID string, opts *ListMessageReactio
 		return nil, false, "", err
 	}
 
-	if resp.Code != 0 {
-		return nil, false, "", fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data.Items, resp.Data.HasMore, resp.Data.PageToken, nil
 }
 
@@ -134,10 +134,6 @@ func (c *Client) GetMessageResource(messageID, fileKey, resourceType string) (io
 
 // UploadMessageImage uploads an image for message sending and returns the image key
 func (c *Client) UploadMessageImage(filePath string) (string, error) {
-	if err := auth.EnsureValidTenantToken(); err != nil {
-		return "", err
-	}
-
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open image: %w", err)
@@ -161,47 +157,145 @@ func (c *Client) UploadMessageImage(filePath string) (string, error) {
 		return "", fmt.Errorf("failed to finalize upload: %w", err)
 	}
 
-	url := baseURL + "/im/v1/images"
-	req, err := http.NewRequest("POST", url, &buf)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	var uploadResp UploadImageResponse
+	if err := c.doRaw("POST", "/im/v1/images", buf.Bytes(), writer.FormDataContentType(), &uploadResp, tenantToken); err != nil {
+		return "", err
 	}
 
-	token := auth.GetTenantTokenStore().GetAccessToken()
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if uploadResp.Data.ImageKey == "" {
+		return "", fmt.Errorf("API error: missing image_key")
+	}
 
-	resp, err := c.httpClient.Do(req)
+	return uploadResp.Data.ImageKey, nil
+}
+
+// MessageFileType returns the im/v1/files file_type for a file name:
+// pdf, doc, xls, ppt, mp4, opus, or stream for anything else
+func MessageFileType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf":
+		return "pdf"
+	case ".doc", ".docx":
+		return "doc"
+	case ".xls", ".xlsx":
+		return "xls"
+	case ".ppt", ".pptx":
+		return "ppt"
+	case ".mp4":
+		return "mp4"
+	case ".opus":
+		return "opus"
+	}
+	return "stream"
+}
+
+// UploadFileOptions contains optional parameters for UploadMessageFile
+type UploadFileOptions struct {
+	FileType string    // Detected from the file name if empty
+	Duration int       // Length in milliseconds, for mp4 and opus
+	Progress io.Writer // If set, upload progress is written here
+}
+
+// UploadMessageFile uploads a file for message sending and returns the file key
+func (c *Client) UploadMessageFile(filePath string, opts UploadFileOptions) (string, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	name := filepath.Base(filePath)
+	fileType := opts.FileType
+	if fileType == "" {
+		fileType = MessageFileType(name)
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	fields := [][2]string{{"file_type", fileType}, {"file_name", name}}
+	if opts.Duration > 0 {
+		fields = append(fields, [2]string{"duration", strconv.Itoa(opts.Duration)})
+	}
+	for _, f := range fields {
+		if err := writer.WriteField(f[0], f[1]); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", f[0], err)
+		}
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file form: %w", err)
+	}
+	size, err := io.Copy(part, file)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if size == 0 {
+		return "", fmt.Errorf("%s is empty", name)
+	}
+	if size > MaxMessageFileSize {
+		return "", fmt.Errorf("%s is %s, more than the %s limit", name, FormatSize(size), FormatSize(MaxMessageFileSize))
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize upload: %w", err)
 	}
 
-	var uploadResp UploadImageResponse
-	if err := json.Unmarshal(respBody, &uploadResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+	var progress func(int64)
+	if opts.Progress != nil {
+		progress = uploadProgress(opts.Progress, name, int64(buf.Len()))
 	}
 
-	if uploadResp.Code != 0 {
-		return "", fmt.Errorf("API error %d: %s", uploadResp.Code, uploadResp.Msg)
+	// Large files can take longer than the default timeout to send
+	uploader := *c
+	uploader.httpClient = &http.Client{Timeout: uploadTimeout}
+
+	var uploadResp UploadFileResponse
+	if err := uploader.doRawWithProgress("POST", "/im/v1/files", buf.Bytes(), writer.FormDataContentType(), &uploadResp, tenantToken, progress); err != nil {
+		return "", err
 	}
 
-	if uploadResp.Data.ImageKey == "" {
-		return "", fmt.Errorf("API error: missing image_key")
+	if uploadResp.Data.FileKey == "" {
+		return "", fmt.Errorf("API error: missing file_key")
 	}
 
-	return uploadResp.Data.ImageKey, nil
+	return uploadResp.Data.FileKey, nil
+}
+
+// uploadProgress writes a progress line each time another percent is sent,
+// ending with a newline once the whole body has gone
+func uploadProgress(w io.Writer, name string, total int64) func(int64) {
+	last := -1
+	return func(sent int64) {
+		percent := int(sent * 100 / total)
+		if percent == last {
+			return
+		}
+		last = percent
+		fmt.Fprintf(w, "\rUploading %s: %s / %s (%d%%)", name, FormatSize(sent), FormatSize(total), percent)
+		if sent >= total {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// FormatSize formats a byte count for people, e.g. 1.5 MB
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 3; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
 }
 
 // SendMessage sends a message to a user or chat
 // receiveIDType: "open_id", "user_id", "email", "chat_id"
 // receiveID: the recipient identifier
-// msgType: "text" or "post"
+// msgType: "text", "post" or "interactive"
 // content: JSON string of message content (format depends on msgType)
 func (c *Client) SendMessage(receiveIDType, receiveID, msgType, content string) (*SendMessageResponse, error) {
 	path := fmt.Sprintf("/im/v1/messages?receive_id_type=%s", receiveIDType)
@@ -217,15 +311,11 @@ func (c *Client) SendMessage(receiveIDType, receiveID, msgType, content string)
 		return nil, err
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return &resp, nil
 }
 
 // ReplyMessage replies to a message by message_id
-// msgType: "text" or "post"
+// msgType: "text", "post" or "interactive"
 // content: JSON string of message content (format depends on msgType)
 // rootID: optional root message ID for thread replies
 // replyInThread: whether to reply in thread
@@ -244,10 +334,6 @@ func (c *Client) ReplyMessage(messageID, msgType, content, rootID string, replyI
 		return nil, err
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return &resp, nil
 }
 
@@ -261,13 +347,109 @@ func (c *Client) RecallMessage(messageID string) error {
 		return err
 	}
 
-	if resp.Code != 0 {
-		return fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
+	return nil
+}
+
+// UpdateMessage replaces the content of a text or post message the bot sent
+// msgType: "text" or "post"
+// content: JSON string of message content (format depends on msgType)
+func (c *Client) UpdateMessage(messageID, msgType, content string) (*SendMessageResponse, error) {
+	path := fmt.Sprintf("/im/v1/messages/%s", messageID)
+
+	req := UpdateMessageRequest{
+		MsgType: msgType,
+		Content: content,
+	}
+
+	var resp SendMessageResponse
+	if err := c.PutWithTenantToken(path, req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ForwardMessage forwards a message to a user or chat
+// receiveIDType: "open_id", "user_id", "email", "chat_id"
+func (c *Client) ForwardMessage(messageID, receiveIDType, receiveID string) (*SendMessageResponse, error) {
+	path := fmt.Sprintf("/im/v1/messages/%s/forward?receive_id_type=%s", messageID, receiveIDType)
+
+	req := ForwardMessageRequest{
+		ReceiveID: receiveID,
+	}
+
+	var resp SendMessageResponse
+	if err := c.PostWithTenantToken(path, req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// MergeForwardMessages forwards messages from one chat as a single merged
+// message. Messages that couldn't be forwarded are listed in the response.
+// receiveIDType: "open_id", "user_id", "email", "chat_id"
+func (c *Client) MergeForwardMessages(messageIDs []string, receiveIDType, receiveID string) (*MergeForwardResponse, error) {
+	path := fmt.Sprintf("/im/v1/messages/merge_forward?receive_id_type=%s", receiveIDType)
+
+	req := MergeForwardRequest{
+		ReceiveID:     receiveID,
+		MessageIDList: messageIDs,
+	}
+
+	var resp MergeForwardResponse
+	if err := c.PostWithTenantToken(path, req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// PinMessage pins a message in its chat
+func (c *Client) PinMessage(messageID string) (*Pin, error) {
+	req := PinMessageRequest{
+		MessageID: messageID,
+	}
+
+	var resp PinMessageResponse
+	if err := c.PostWithTenantToken("/im/v1/pins", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Data.Pin, nil
+}
+
+// UnpinMessage removes a message from its chat's pins
+func (c *Client) UnpinMessage(messageID string) error {
+	path := fmt.Sprintf("/im/v1/pins/%s", messageID)
+
+	var resp BaseResponse
+	if err := c.DeleteWithTenantToken(path, &resp); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// UrgentMessage buzzes users about a message the bot sent. It returns the
+// user IDs that couldn't be buzzed.
+// urgentType: "app", "sms" or "phone"
+// userIDType: "open_id", "user_id" or "union_id"
+func (c *Client) UrgentMessage(messageID, urgentType, userIDType string, userIDs []string) ([]string, error) {
+	path := fmt.Sprintf("/im/v1/messages/%s/urgent_%s?user_id_type=%s", messageID, urgentType, userIDType)
+
+	req := UrgentMessageRequest{
+		UserIDList: userIDs,
+	}
+
+	var resp UrgentMessageResponse
+	if err := c.PatchWithTenantToken(path, req, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Data.InvalidUserIDList, nil
+}
+
 // DeleteMessageReaction removes a reaction from a message
 func (c *Client) DeleteMessageReaction(messageID, reactionID string) (*MessageReaction, error) {
 	path := fmt.Sprintf("/im/v1/messages/%s/reactions/%s", messageID, reactionID)
@@ -277,10 +459,6 @@ func (c *Client) DeleteMessageReaction(messageID, reactionID string) (*MessageRe
 		return nil, err
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data, nil
 }
 
@@ -300,9 +478,20 @@ func (c *Client) AddMessageReaction(messageID, emojiType string) (*MessageReacti
 		return nil, err
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
+	return resp.Data, nil
+}
+
+// GetMessage retrieves a single message by ID
+func (c *Client) GetMessage(messageID string) (*Message, error) {
+	path := fmt.Sprintf("/im/v1/messages/%s", messageID)
+
+	var resp MessageListResponse
+	if err := c.GetWithTenantToken(path, &resp); err != nil {
+		return nil, err
 	}
 
-	return resp.Data, nil
+	if len(resp.Data.Items) == 0 {
+		return nil, fmt.Errorf("message %s not found", messageID)
+	}
+	return &resp.Data.Items[0], nil
 }
@@ -35,6 +35,7 @@ type Attendee struct {
 	Type            string `json:"type,omitempty"` // user, chat, resource, third_party
 	AttendeeID      string `json:"attendee_id,omitempty"`
 	UserID          string `json:"user_id,omitempty"`
+	ChatID          string `json:"chat_id,omitempty"`
 	DisplayName     string `json:"display_name,omitempty"`
 	RsvpStatus      string `json:"rsvp_status,omitempty"` // needs_action, accept, tentative, decline
 	IsOptional      bool   `json:"is_optional,omitempty"`
@@ -261,6 +262,8 @@ type OutputError struct {
 
 // OutputAuthStatus is the auth status response for CLI
 type OutputAuthStatus struct {
+	Profile       string          `json:"profile,omitempty"`
+	Mode          string          `json:"mode,omitempty"` // user or bot
 	Authenticated bool            `json:"authenticated"`
 	User          string          `json:"user,omitempty"`
 	ExpiresAt     time.Time       `json:"expires_at,omitempty"`
@@ -269,6 +272,21 @@ type OutputAuthStatus struct {
 	ScopeGroups   map[string]bool `json:"scope_groups,omitempty"`
 }
 
+// OutputProfile is a configuration profile for CLI output
+type OutputProfile struct {
+	Name      string `json:"name"`
+	Directory string `json:"directory"`
+	AppID     string `json:"app_id,omitempty"`
+	Default   bool   `json:"default"`
+	Active    bool   `json:"active"`
+}
+
+// OutputProfileList is the list profiles response for CLI
+type OutputProfileList struct {
+	Profiles []OutputProfile `json:"profiles"`
+	Count    int             `json:"count"`
+}
+
 // OutputSuccess is a generic success response
 type OutputSuccess struct {
 	Success bool   `json:"success"`
@@ -407,6 +425,56 @@ type OutputFreeTimeSlot struct {
 	LengthMinutes int    `json:"length_minutes"`
 }
 
+// --- ICS Import Types ---
+
+// OutputICSImport is the cal import response for CLI
+type OutputICSImport struct {
+	Imported []OutputICSImportItem `json:"imported"`
+	Skipped  []OutputICSImportItem `json:"skipped"`
+	DryRun   bool                  `json:"dry_run,omitempty"`
+}
+
+// OutputICSImportItem is one VEVENT from an imported file
+type OutputICSImportItem struct {
+	UID     string `json:"uid"`
+	Summary string `json:"summary"`
+	Start   string `json:"start,omitempty"`
+	EventID string `json:"event_id,omitempty"`
+	Reason  string `json:"reason,omitempty"` // why the event was skipped
+}
+
+// --- Slot Finder Types ---
+
+// OutputFindSlot is the find-slot response for CLI
+type OutputFindSlot struct {
+	Query  OutputFindSlotQuery `json:"query"`
+	Slots  []OutputSlot        `json:"slots"`
+	Booked *OutputEvent        `json:"booked,omitempty"`
+}
+
+// OutputFindSlotQuery describes the query parameters
+type OutputFindSlotQuery struct {
+	Required        []string `json:"required"`
+	Optional        []string `json:"optional,omitempty"`
+	From            string   `json:"from"`
+	To              string   `json:"to"`
+	DurationMinutes int      `json:"duration_minutes"`
+	WorkHours       string   `json:"work_hours,omitempty"`
+	BufferMinutes   int      `json:"buffer_minutes,omitempty"`
+	Timezone        string   `json:"timezone"`
+	TZAware         bool     `json:"tz_aware,omitempty"`
+}
+
+// OutputSlot is a proposed meeting slot for CLI output
+type OutputSlot struct {
+	Rank                int      `json:"rank"`
+	Start               string   `json:"start"`
+	End                 string   `json:"end"`
+	Conflicts           []string `json:"conflicts,omitempty"`
+	OptionalAvailable   []string `json:"optional_available,omitempty"`
+	OptionalUnavailable []string `json:"optional_unavailable,omitempty"`
+}
+
 // --- Contact Types ---
 
 // ContactUserStatus represents a user's status
@@ -478,6 +546,14 @@ type GetUserResponse struct {
 	} `json:"data,omitempty"`
 }
 
+// BatchGetUsersResponse is the response from GET /contact/v3/users/batch
+type BatchGetUsersResponse struct {
+	BaseResponse
+	Data struct {
+		Items []ContactUser `json:"items,omitempty"`
+	} `json:"data,omitempty"`
+}
+
 // FindByDepartmentResponse is the response from GET /contact/v3/users/find_by_department
 type FindByDepartmentResponse struct {
 	BaseResponse
@@ -919,6 +995,7 @@ type DocumentCommentsResponse struct {
 type OutputCommentReply struct {
 	ReplyID    string `json:"reply_id"`
 	UserID     string `json:"user_id"`
+	UserName   string `json:"user_name,omitempty"` // set with --resolve-names
 	CreateTime string `json:"create_time"`
 	Text       string `json:"text"`
 }
@@ -927,6 +1004,7 @@ type OutputCommentReply struct {
 type OutputDocumentComment struct {
 	CommentID  string               `json:"comment_id"`
 	UserID     string               `json:"user_id"`
+	UserName   string               `json:"user_name,omitempty"` // set with --resolve-names
 	CreateTime string               `json:"create_time"`
 	IsSolved   bool                 `json:"is_solved"`
 	IsWhole    bool                 `json:"is_whole"`
@@ -1046,7 +1124,8 @@ type MessageReactionListResponse struct {
 // OutputMessageSender is the simplified sender format for CLI output
 type OutputMessageSender struct {
 	ID   string `json:"id"`
-	Type string `json:"type"` // user, app, anonymous, unknown
+	Type string `json:"type"`           // user, app, anonymous, unknown
+	Name string `json:"name,omitempty"` // set with --resolve-names
 }
 
 // OutputMessageMention is the simplified mention format for CLI output
@@ -1058,15 +1137,26 @@ type OutputMessageMention struct {
 
 // OutputMessage is the simplified message format for CLI output
 type OutputMessage struct {
-	MessageID  string                 `json:"message_id"`
-	MsgType    string                 `json:"msg_type"`
-	Content    string                 `json:"content"`
-	Sender     *OutputMessageSender   `json:"sender,omitempty"`
-	CreateTime string                 `json:"create_time"`
-	Mentions   []OutputMessageMention `json:"mentions,omitempty"`
-	IsReply    bool                   `json:"is_reply,omitempty"`
-	ThreadID   string                 `json:"thread_id,omitempty"`
-	Deleted    bool                   `json:"deleted,omitempty"`
+	MessageID   string                    `json:"message_id"`
+	MsgType     string                    `json:"msg_type"`
+	Content     string                    `json:"content"`
+	Attachments []OutputMessageAttachment `json:"attachments,omitempty"`
+	Sender      *OutputMessageSender      `json:"sender,omitempty"`
+	CreateTime  string                    `json:"create_time"`
+	Mentions    []OutputMessageMention    `json:"mentions,omitempty"`
+	IsReply     bool                      `json:"is_reply,omitempty"`
+	ThreadID    string                    `json:"thread_id,omitempty"`
+	ParentID    string                    `json:"parent_id,omitempty"` // set on threaded replies to another reply
+	Deleted     bool                      `json:"deleted,omitempty"`
+	Replies     []OutputMessage           `json:"replies,omitempty"`
+}
+
+// OutputMessageAttachment is an image or file in a message. Type is the
+// resource type to pass to 'lark msg resource'.
+type OutputMessageAttachment struct {
+	Type string `json:"type"` // image or file
+	Key  string `json:"key"`
+	Name string `json:"name,omitempty"`
 }
 
 // OutputMessageList is the message list response for CLI
@@ -1074,6 +1164,33 @@ type OutputMessageList struct {
 	Messages []OutputMessage `json:"messages"`
 	Count    int             `json:"count"`
 	ChatID   string          `json:"chat_id"`
+	ChatName string          `json:"chat_name,omitempty"` // set with --resolve-names
+}
+
+// OutputMessageThread is a thread for CLI output: the root message with its
+// replies nested under it
+type OutputMessageThread struct {
+	ChatID   string        `json:"chat_id"`
+	ThreadID string        `json:"thread_id,omitempty"`
+	RootID   string        `json:"root_id"`
+	Count    int           `json:"count"`
+	Message  OutputMessage `json:"message"`
+}
+
+// OutputMessageTranscript is a conversation exported as a transcript
+type OutputMessageTranscript struct {
+	ChatID   string `json:"chat_id"`
+	ThreadID string `json:"thread_id,omitempty"`
+	Format   string `json:"format"`
+	Content  string `json:"content"`
+}
+
+// OutputMessageSync is the result of archiving a chat's messages
+type OutputMessageSync struct {
+	ChatID        string `json:"chat_id"`
+	Fetched       int    `json:"fetched"`
+	NewMessages   int    `json:"new_messages"`
+	TotalArchived int    `json:"total_archived"`
 }
 
 // OutputMessageReaction is the simplified reaction format for CLI output
@@ -1090,6 +1207,7 @@ type OutputMessageReactionItem struct {
 	ReactionID   string `json:"reaction_id"`
 	EmojiType    string `json:"emoji_type"`
 	OperatorID   string `json:"operator_id,omitempty"`
+	OperatorName string `json:"operator_name,omitempty"` // set with --resolve-names
 	OperatorType string `json:"operator_type,omitempty"`
 	ActionTime   string `json:"action_time,omitempty"`
 }
@@ -1101,6 +1219,73 @@ type OutputMessageReactionList struct {
 	Count     int                         `json:"count"`
 }
 
+// --- Message Event Types ---
+
+// EventUserID holds the IDs of a user in event payloads
+type EventUserID struct {
+	OpenID  string `json:"open_id,omitempty"`
+	UnionID string `json:"union_id,omitempty"`
+	UserID  string `json:"user_id,omitempty"`
+}
+
+// MessageReceiveEvent is the payload of im.message.receive_v1
+type MessageReceiveEvent struct {
+	Sender struct {
+		SenderID   EventUserID `json:"sender_id"`
+		SenderType string      `json:"sender_type"`
+		TenantKey  string      `json:"tenant_key"`
+	} `json:"sender"`
+	Message struct {
+		MessageID   string `json:"message_id"`
+		RootID      string `json:"root_id,omitempty"`
+		ParentID    string `json:"parent_id,omitempty"`
+		ThreadID    string `json:"thread_id,omitempty"`
+		CreateTime  string `json:"create_time"` // Unix ms timestamp
+		UpdateTime  string `json:"update_time,omitempty"`
+		ChatID      string `json:"chat_id"`
+		ChatType    string `json:"chat_type"` // p2p, group
+		MessageType string `json:"message_type"`
+		Content     string `json:"content"` // JSON string of message content
+		Mentions    []struct {
+			Key       string      `json:"key"`
+			ID        EventUserID `json:"id"`
+			Name      string      `json:"name"`
+			TenantKey string      `json:"tenant_key"`
+		} `json:"mentions,omitempty"`
+	} `json:"message"`
+}
+
+// MessageReactionEvent is the payload of im.message.reaction.created_v1 and deleted_v1
+type MessageReactionEvent struct {
+	MessageID    string       `json:"message_id"`
+	ReactionType ReactionType `json:"reaction_type"`
+	OperatorType string       `json:"operator_type"` // user, app
+	UserID       EventUserID  `json:"user_id"`
+	AppID        string       `json:"app_id,omitempty"`
+	ActionTime   string       `json:"action_time"` // Unix ms timestamp
+}
+
+// MessageRecalledEvent is the payload of im.message.recalled_v1
+type MessageRecalledEvent struct {
+	MessageID  string `json:"message_id"`
+	ChatID     string `json:"chat_id"`
+	RecallTime string `json:"recall_time"` // Unix ms timestamp
+	RecallType string `json:"recall_type"` // message_owner, group_owner, group_manager, enterprise_manager
+}
+
+// OutputMessageEvent is a real-time message event for CLI output
+type OutputMessageEvent struct {
+	Type       string                     `json:"type"` // message, reaction_added, reaction_removed, recalled
+	EventID    string                     `json:"event_id"`
+	ChatID     string                     `json:"chat_id,omitempty"`
+	ChatType   string                     `json:"chat_type,omitempty"`
+	MessageID  string                     `json:"message_id"`
+	Time       string                     `json:"time"`
+	Message    *OutputMessage             `json:"message,omitempty"`
+	Reaction   *OutputMessageReactionItem `json:"reaction,omitempty"`
+	RecallType string                     `json:"recall_type,omitempty"`
+}
+
 // --- Send Message Types ---
 
 // SendMessageRequest is the request body for POST /im/v1/messages
@@ -1118,6 +1303,67 @@ type ReplyMessageRequest struct {
 	ReplyInThread bool   `json:"reply_in_thread,omitempty"`
 }
 
+// UpdateMessageRequest is the request body for PUT /im/v1/messages/:message_id
+type UpdateMessageRequest struct {
+	MsgType string `json:"msg_type"` // text, post
+	Content string `json:"content"`  // JSON string
+}
+
+// ForwardMessageRequest is the request body for POST /im/v1/messages/:message_id/forward
+type ForwardMessageRequest struct {
+	ReceiveID string `json:"receive_id"`
+}
+
+// MergeForwardRequest is the request body for POST /im/v1/messages/merge_forward
+type MergeForwardRequest struct {
+	ReceiveID     string   `json:"receive_id"`
+	MessageIDList []string `json:"message_id_list"`
+}
+
+// MergeForwardResponse is the response from POST /im/v1/messages/merge_forward
+type MergeForwardResponse struct {
+	BaseResponse
+	Data struct {
+		Message              *Message `json:"message,omitempty"`
+		InvalidMessageIDList []string `json:"invalid_message_id_list,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// PinMessageRequest is the request body for POST /im/v1/pins
+type PinMessageRequest struct {
+	MessageID string `json:"message_id"`
+}
+
+// Pin is a pinned message
+type Pin struct {
+	MessageID      string `json:"message_id,omitempty"`
+	ChatID         string `json:"chat_id,omitempty"`
+	OperatorID     string `json:"operator_id,omitempty"`
+	OperatorIDType string `json:"operator_id_type,omitempty"`
+	CreateTime     string `json:"create_time,omitempty"` // Unix ms timestamp
+}
+
+// PinMessageResponse is the response from POST /im/v1/pins
+type PinMessageResponse struct {
+	BaseResponse
+	Data struct {
+		Pin *Pin `json:"pin,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// UrgentMessageRequest is the request body for PATCH /im/v1/messages/:message_id/urgent_app (and urgent_sms, urgent_phone)
+type UrgentMessageRequest struct {
+	UserIDList []string `json:"user_id_list"`
+}
+
+// UrgentMessageResponse is the response from PATCH /im/v1/messages/:message_id/urgent_app (and urgent_sms, urgent_phone)
+type UrgentMessageResponse struct {
+	BaseResponse
+	Data struct {
+		InvalidUserIDList []string `json:"invalid_user_id_list,omitempty"`
+	} `json:"data,omitempty"`
+}
+
 // UploadImageResponse is the response from POST /im/v1/images
 type UploadImageResponse struct {
 	BaseResponse
@@ -1126,6 +1372,14 @@ type UploadImageResponse struct {
 	} `json:"data,omitempty"`
 }
 
+// UploadFileResponse is the response from POST /im/v1/files
+type UploadFileResponse struct {
+	BaseResponse
+	Data struct {
+		FileKey string `json:"file_key"`
+	} `json:"data,omitempty"`
+}
+
 // SendMessageResponse is the response from POST /im/v1/messages
 type SendMessageResponse struct {
 	BaseResponse
@@ -1147,10 +1401,84 @@ type SendMessageResponse struct {
 
 // OutputSendMessage is the simplified send message response for CLI
 type OutputSendMessage struct {
+	Success    bool                `json:"success"`
+	MessageID  string              `json:"message_id"`
+	ChatID     string              `json:"chat_id,omitempty"`
+	CreateTime string              `json:"create_time"`
+	Messages   []OutputSentMessage `json:"messages,omitempty"` // every message sent, when files were attached
+}
+
+// OutputSentMessage is one of the messages sent by a single msg send
+type OutputSentMessage struct {
+	MessageID  string `json:"message_id"`
+	MsgType    string `json:"msg_type"`
+	FileName   string `json:"file_name,omitempty"`
+	FileKey    string `json:"file_key,omitempty"`
+	CreateTime string `json:"create_time"`
+}
+
+// OutputEditMessage is the msg edit response for CLI
+type OutputEditMessage struct {
 	Success    bool   `json:"success"`
 	MessageID  string `json:"message_id"`
 	ChatID     string `json:"chat_id,omitempty"`
-	CreateTime string `json:"create_time"`
+	UpdateTime string `json:"update_time,omitempty"`
+}
+
+// OutputMergeForward is the msg merge-forward response for CLI
+type OutputMergeForward struct {
+	Success           bool     `json:"success"`
+	MessageID         string   `json:"message_id"`
+	ChatID            string   `json:"chat_id,omitempty"`
+	CreateTime        string   `json:"create_time"`
+	InvalidMessageIDs []string `json:"invalid_message_ids,omitempty"` // messages that couldn't be forwarded
+}
+
+// OutputMessagePin is the msg pin response for CLI
+type OutputMessagePin struct {
+	Success    bool   `json:"success"`
+	MessageID  string `json:"message_id"`
+	ChatID     string `json:"chat_id,omitempty"`
+	OperatorID string `json:"operator_id,omitempty"`
+	CreateTime string `json:"create_time,omitempty"`
+}
+
+// OutputMessageUrgent is the msg urgent response for CLI
+type OutputMessageUrgent struct {
+	Success        bool     `json:"success"`
+	MessageID      string   `json:"message_id"`
+	Type           string   `json:"type"` // app, sms or phone
+	Users          []string `json:"users"`
+	InvalidUserIDs []string `json:"invalid_user_ids,omitempty"` // users who couldn't be buzzed
+}
+
+// OutputOutboxEntry is a scheduled message for CLI output
+type OutputOutboxEntry struct {
+	ID         int64    `json:"id"`
+	Status     string   `json:"status"` // pending, sending, sent, failed, canceled
+	SendAt     string   `json:"send_at"`
+	To         string   `json:"to"`
+	MsgType    string   `json:"msg_type"`
+	Text       string   `json:"text,omitempty"`
+	Card       string   `json:"card,omitempty"`
+	Files      []string `json:"files,omitempty"` // names of images and files to upload
+	ChatID     string   `json:"chat_id,omitempty"`
+	MessageIDs []string `json:"message_ids,omitempty"`
+	SentAt     string   `json:"sent_at,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// OutputOutboxList is the outbox list response for CLI
+type OutputOutboxList struct {
+	Entries []OutputOutboxEntry `json:"entries"`
+	Count   int                 `json:"count"`
+}
+
+// OutputOutboxRun is the result of sending the due messages in the outbox
+type OutputOutboxRun struct {
+	Sent    int                 `json:"sent"`
+	Failed  int                 `json:"failed"`
+	Entries []OutputOutboxEntry `json:"entries"`
 }
 
 // --- Chat Types ---
@@ -1178,6 +1506,12 @@ type SearchChatsResponse struct {
 	} `json:"data,omitempty"`
 }
 
+// GetChatResponse is the response from GET /im/v1/chats/:chat_id
+type GetChatResponse struct {
+	BaseResponse
+	Data *Chat `json:"data,omitempty"`
+}
+
 // --- Chat CLI Output Types ---
 
 // OutputChat is the simplified chat format for CLI output
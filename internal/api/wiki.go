@@ -16,10 +16,6 @@ func (c *Client) GetWikiNode(nodeToken string) (*WikiNode, error) {
 		return nil, err
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data.Node, nil
 }
 
@@ -45,10 +41,6 @@ func (c *Client) SearchWikiNodes(query, spaceID, nodeID string) ([]WikiSearchIte
 		return nil, err
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-	}
-
 	return resp.Data.Items, nil
 }
 
@@ -75,10 +67,6 @@ func (c *Client) GetWikiNodeChildren(spaceID, parentNodeToken string) ([]WikiNod
 			return nil, err
 		}
 
-		if resp.Code != 0 {
-			return nil, fmt.Errorf("API error %d: %s", resp.Code, resp.Msg)
-		}
-
 		allItems = append(allItems, resp.Data.Items...)
 
 		if !resp.Data.HasMore {
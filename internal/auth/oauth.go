@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/rand"
 	"encoding/hex"
@@ -9,8 +10,10 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/yjwong/lark-cli/internal/config"
@@ -18,10 +21,10 @@ import (
 )
 
 const (
-	authorizationURL     = "https://accounts.larksuite.com/open-apis/authen/v1/authorize"
-	tokenURL             = "https://open.larksuite.com/open-apis/authen/v2/oauth/token"
-	tenantTokenURL       = "https://open.larksuite.com/open-apis/auth/v3/tenant_access_token/internal"
-	defaultTimeout       = 5 * time.Minute
+	authorizationPath = "/open-apis/authen/v1/authorize"
+	tokenPath         = "/open-apis/authen/v2/oauth/token"
+	tenantTokenPath   = "/open-apis/auth/v3/tenant_access_token/internal"
+	defaultTimeout    = 5 * time.Minute
 )
 
 // TokenResponse represents the OAuth token response from Lark
@@ -50,6 +53,13 @@ type LoginOptions struct {
 	// ScopeGroups specifies which scope groups to request (e.g., "calendar", "contacts")
 	// If empty, all scopes are requested (default behavior)
 	ScopeGroups []string
+
+	// Headless skips the local callback server and browser. The user opens the
+	// authorization URL on any machine and pastes the redirect URL (or code) back.
+	Headless bool
+
+	// Input is read for the pasted redirect URL in headless mode (default: os.Stdin)
+	Input io.Reader
 }
 
 // Login performs the OAuth login flow with default options (all scopes)
@@ -84,16 +94,57 @@ func LoginWithOptions(opts LoginOptions) error {
 		return fmt.Errorf("failed to generate state: %w", err)
 	}
 
+	var code, redirectURI string
+	if opts.Headless {
+		input := opts.Input
+		if input == nil {
+			input = os.Stdin
+		}
+		code, redirectURI, err = authorizeHeadless(appID, state, scopeString, input)
+	} else {
+		code, redirectURI, err = authorizeWithBrowser(appID, state, scopeString)
+	}
+	if err != nil {
+		return fmt.Errorf("authorization failed: %w", err)
+	}
+
+	fmt.Println("Authorization code received, exchanging for tokens...")
+
+	// Exchange code for tokens
+	tokenResp, err := exchangeCodeForTokens(appID, appSecret, code, redirectURI)
+	if err != nil {
+		return fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	// Store tokens
+	store := GetTokenStore()
+	if err := store.Update(
+		tokenResp.AccessToken,
+		tokenResp.RefreshToken,
+		tokenResp.ExpiresIn,
+		tokenResp.RefreshTokenExpiresIn,
+		tokenResp.Scope,
+	); err != nil {
+		return fmt.Errorf("failed to save tokens: %w", err)
+	}
+
+	fmt.Println("Authentication successful!")
+	return nil
+}
+
+// authorizeWithBrowser opens the authorization URL in a browser and waits for
+// the redirect on the local callback server
+func authorizeWithBrowser(appID, state, scopeString string) (code, redirectURI string, err error) {
 	// Start callback server
 	port := config.GetRedirectPort()
 	server := NewCallbackServer(port)
 	if err := server.Start(state); err != nil {
-		return fmt.Errorf("failed to start callback server: %w", err)
+		return "", "", fmt.Errorf("failed to start callback server: %w", err)
 	}
 	defer server.Stop()
 
 	// Build authorization URL
-	redirectURI := server.GetRedirectURI()
+	redirectURI = server.GetRedirectURI()
 	authURL := buildAuthorizationURL(appID, redirectURI, state, scopeString)
 
 	// Open browser
@@ -107,33 +158,61 @@ func LoginWithOptions(opts LoginOptions) error {
 	fmt.Println("Waiting for authorization...")
 
 	// Wait for callback
-	code, err := server.WaitForCode(defaultTimeout)
+	code, err = server.WaitForCode(defaultTimeout)
 	if err != nil {
-		return fmt.Errorf("authorization failed: %w", err)
+		return "", "", err
 	}
+	return code, redirectURI, nil
+}
 
-	fmt.Println("Authorization code received, exchanging for tokens...")
+// authorizeHeadless prints the authorization URL and reads the redirect URL
+// pasted by the user, for SSH and container sessions
+func authorizeHeadless(appID, state, scopeString string, input io.Reader) (code, redirectURI string, err error) {
+	// The redirect URI must match the one registered for the app, even though
+	// nothing listens on it in headless mode
+	redirectURI = fmt.Sprintf("http://localhost:%d/callback", config.GetRedirectPort())
+	authURL := buildAuthorizationURL(appID, redirectURI, state, scopeString)
 
-	// Exchange code for tokens
-	tokenResp, err := exchangeCodeForTokens(appID, appSecret, code, redirectURI)
+	fmt.Printf("Open this URL in a browser on any machine:\n%s\n\n", authURL)
+	fmt.Println("After you approve, the browser is redirected to a localhost URL that will fail to load.")
+	fmt.Print("Paste that full URL here: ")
+
+	line, err := bufio.NewReader(input).ReadString('\n')
+	if err != nil && (err != io.EOF || strings.TrimSpace(line) == "") {
+		return "", "", fmt.Errorf("failed to read authorization response: %w", err)
+	}
+	fmt.Println()
+
+	code, err = parseAuthorizationResponse(line, state)
 	if err != nil {
-		return fmt.Errorf("failed to exchange code: %w", err)
+		return "", "", err
 	}
+	return code, redirectURI, nil
+}
 
-	// Store tokens
-	store := GetTokenStore()
-	if err := store.Update(
-		tokenResp.AccessToken,
-		tokenResp.RefreshToken,
-		tokenResp.ExpiresIn,
-		tokenResp.RefreshTokenExpiresIn,
-		tokenResp.Scope,
-	); err != nil {
-		return fmt.Errorf("failed to save tokens: %w", err)
+// parseAuthorizationResponse extracts the authorization code from a pasted
+// redirect URL, applying the same checks as the callback server. The full
+// URL is required, since a bare code has no state to verify.
+func parseAuthorizationResponse(input, expectedState string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", fmt.Errorf("no authorization response entered")
+	}
+	i := strings.Index(input, "?")
+	if i < 0 {
+		return "", fmt.Errorf("paste the full redirect URL, including its ?code=...&state=... query")
 	}
 
-	fmt.Println("Authentication successful!")
-	return nil
+	rawQuery := input[i+1:]
+	if i := strings.Index(rawQuery, "#"); i >= 0 {
+		rawQuery = rawQuery[:i]
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect URL: %w", err)
+	}
+
+	return verifyCallback(query, expectedState)
 }
 
 // RefreshAccessToken refreshes the access token using the refresh token
@@ -170,6 +249,10 @@ func RefreshAccessToken() error {
 
 // EnsureValidToken checks and refreshes the token if needed
 func EnsureValidToken() error {
+	if config.IsBotMode() {
+		return fmt.Errorf("this command needs a user access token, but bot mode is enabled (tenant token only); disable bot_mode and run 'lark auth login'")
+	}
+
 	store := GetTokenStore()
 
 	if store.IsValid() {
@@ -232,7 +315,7 @@ func RefreshTenantToken() error {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", tenantTokenURL, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequest("POST", config.GetBaseURL()+tenantTokenPath, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -290,7 +373,7 @@ func buildAuthorizationURL(appID, redirectURI, state, scopeString string) string
 	params.Set("scope", scopeString)
 	params.Set("state", state)
 
-	return authorizationURL + "?" + params.Encode()
+	return config.GetAccountsURL() + authorizationPath + "?" + params.Encode()
 }
 
 // exchangeCodeForTokens exchanges the authorization code for access tokens
@@ -325,7 +408,7 @@ func doTokenRequest(reqBody map[string]string) (*TokenResponse, error) {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", tokenURL, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequest("POST", config.GetBaseURL()+tokenPath, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
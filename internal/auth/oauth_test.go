@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAuthorizationResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"redirect URL", "http://localhost:9999/callback?code=abc&state=s1\n", "abc", nil},
+		{"fragment ignored", "http://localhost:9999/callback?state=s1&code=abc#x", "abc", nil},
+		{"wrong state", "http://localhost:9999/callback?code=abc&state=other", "", errStateMismatch},
+		{"denied", "http://localhost:9999/callback?error=access_denied&state=s1", "", errAuthorizationDenied},
+		{"no code", "http://localhost:9999/callback?state=s1", "", errNoCode},
+		{"bare code", "abc", "", nil},
+		{"query without URL", "code=abc&state=s1", "", nil},
+		{"empty", "  \n", "", nil},
+	}
+	for _, tt := range tests {
+		got, err := parseAuthorizationResponse(tt.input, "s1")
+		if tt.want != "" {
+			if err != nil || got != tt.want {
+				t.Errorf("%s: got %q, %v; want %q", tt.name, got, err, tt.want)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: got %q, want an error", tt.name, got)
+		} else if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
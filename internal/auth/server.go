@@ -2,8 +2,10 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -29,56 +31,10 @@ func (s *CallbackServer) Start(expectedState string) error {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		// Check for error
-		if errParam := r.URL.Query().Get("error"); errParam != "" {
-			s.err <- fmt.Errorf("authorization denied: %s", errParam)
-			w.Header().Set("Content-Type", "text/html")
-			w.WriteHeader(http.StatusForbidden)
-			fmt.Fprintf(w, `<!DOCTYPE html>
-<html>
-<head><title>Authorization Failed</title></head>
-<body style="font-family: sans-serif; text-align: center; padding-top: 50px;">
-<h1>Authorization Failed</h1>
-<p>You denied access to the application.</p>
-<p>You can close this window.</p>
-</body>
-</html>`)
-			return
-		}
-
-		// Verify state
-		state := r.URL.Query().Get("state")
-		if state != expectedState {
-			s.err <- fmt.Errorf("state mismatch: expected %s, got %s", expectedState, state)
-			w.Header().Set("Content-Type", "text/html")
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, `<!DOCTYPE html>
-<html>
-<head><title>Authorization Failed</title></head>
-<body style="font-family: sans-serif; text-align: center; padding-top: 50px;">
-<h1>Authorization Failed</h1>
-<p>Security validation failed. Please try again.</p>
-<p>You can close this window.</p>
-</body>
-</html>`)
-			return
-		}
-
-		// Get authorization code
-		code := r.URL.Query().Get("code")
-		if code == "" {
-			s.err <- fmt.Errorf("no authorization code received")
-			w.Header().Set("Content-Type", "text/html")
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, `<!DOCTYPE html>
-<html>
-<head><title>Authorization Failed</title></head>
-<body style="font-family: sans-serif; text-align: center; padding-top: 50px;">
-<h1>Authorization Failed</h1>
-<p>No authorization code received.</p>
-<p>You can close this window.</p>
-</body>
-</html>`)
+		code, err := verifyCallback(r.URL.Query(), expectedState)
+		if err != nil {
+			s.err <- err
+			writeFailurePage(w, err)
 			return
 		}
 
@@ -111,6 +67,59 @@ func (s *CallbackServer) Start(expectedState string) error {
 	return nil
 }
 
+// Callback verification failures, shared by the callback server and headless login
+var (
+	errAuthorizationDenied = errors.New("authorization denied")
+	errStateMismatch       = errors.New("state mismatch")
+	errNoCode              = errors.New("no authorization code received")
+)
+
+// verifyCallback checks the OAuth redirect parameters and returns the authorization code
+func verifyCallback(query url.Values, expectedState string) (string, error) {
+	// Check for error
+	if errParam := query.Get("error"); errParam != "" {
+		return "", fmt.Errorf("%w: %s", errAuthorizationDenied, errParam)
+	}
+
+	// Verify state
+	if state := query.Get("state"); state != expectedState {
+		return "", fmt.Errorf("%w: expected %s, got %s", errStateMismatch, expectedState, state)
+	}
+
+	// Get authorization code
+	code := query.Get("code")
+	if code == "" {
+		return "", errNoCode
+	}
+
+	return code, nil
+}
+
+// writeFailurePage renders the browser page for a failed callback
+func writeFailurePage(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	message := "No authorization code received."
+	switch {
+	case errors.Is(err, errAuthorizationDenied):
+		status = http.StatusForbidden
+		message = "You denied access to the application."
+	case errors.Is(err, errStateMismatch):
+		message = "Security validation failed. Please try again."
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head><title>Authorization Failed</title></head>
+<body style="font-family: sans-serif; text-align: center; padding-top: 50px;">
+<h1>Authorization Failed</h1>
+<p>%s</p>
+<p>You can close this window.</p>
+</body>
+</html>`, message)
+}
+
 // WaitForCode blocks until an authorization code is received or timeout
 func (s *CallbackServer) WaitForCode(timeout time.Duration) (string, error) {
 	select {
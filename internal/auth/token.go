@@ -3,12 +3,11 @@ package auth
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"sync"
 	"time"
 
-	"github.com/yjwong/lark-cli/internal/config"
 	"github.com/yjwong/lark-cli/internal/scopes"
+	"github.com/yjwong/lark-cli/internal/secrets"
 )
 
 // TokenStore holds the OAuth tokens
@@ -50,14 +49,13 @@ func (t *TokenStore) Load() error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	path := config.TokensFilePath()
-	data, err := os.ReadFile(path)
+	data, err := secrets.Load(secrets.KeyTokens)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // No tokens yet, that's OK
-		}
 		return fmt.Errorf("failed to read tokens: %w", err)
 	}
+	if data == nil {
+		return nil // No tokens yet, that's OK
+	}
 
 	if err := json.Unmarshal(data, t); err != nil {
 		return fmt.Errorf("failed to parse tokens: %w", err)
@@ -76,8 +74,7 @@ func (t *TokenStore) Save() error {
 		return fmt.Errorf("failed to marshal tokens: %w", err)
 	}
 
-	path := config.TokensFilePath()
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	if err := secrets.Save(secrets.KeyTokens, data); err != nil {
 		return fmt.Errorf("failed to write tokens: %w", err)
 	}
 
@@ -96,9 +93,8 @@ func (t *TokenStore) Clear() error {
 	t.Scope = ""
 	t.UserID = ""
 
-	path := config.TokensFilePath()
-	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove tokens file: %w", err)
+	if err := secrets.Remove(secrets.KeyTokens); err != nil {
+		return fmt.Errorf("failed to remove tokens: %w", err)
 	}
 
 	return nil
@@ -235,14 +231,13 @@ func (t *TenantTokenStore) Load() error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	path := config.TenantTokensFilePath()
-	data, err := os.ReadFile(path)
+	data, err := secrets.Load(secrets.KeyTenantTokens)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // No tokens yet, that's OK
-		}
 		return fmt.Errorf("failed to read tenant tokens: %w", err)
 	}
+	if data == nil {
+		return nil // No tokens yet, that's OK
+	}
 
 	if err := json.Unmarshal(data, t); err != nil {
 		return fmt.Errorf("failed to parse tenant tokens: %w", err)
@@ -261,8 +256,7 @@ func (t *TenantTokenStore) Save() error {
 		return fmt.Errorf("failed to marshal tenant tokens: %w", err)
 	}
 
-	path := config.TenantTokensFilePath()
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	if err := secrets.Save(secrets.KeyTenantTokens, data); err != nil {
 		return fmt.Errorf("failed to write tenant tokens: %w", err)
 	}
 
@@ -298,3 +292,10 @@ func (t *TenantTokenStore) GetAccessToken() string {
 	defer t.mu.RUnlock()
 	return t.AccessToken
 }
+
+// GetExpiresAt returns when the tenant access token expires
+func (t *TenantTokenStore) GetExpiresAt() time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.ExpiresAt
+}
@@ -0,0 +1,364 @@
+package card
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ImageResolver uploads a local image and returns its image key
+type ImageResolver func(path string) (string, error)
+
+// Render substitutes vars into the template and returns the card JSON object.
+// Images given as file paths are passed to resolve; image keys (img_...) are
+// used as is. If resolve is nil, paths are left in place of keys.
+func (t *Template) Render(vars Vars, resolve ImageResolver) (map[string]interface{}, error) {
+	if vars == nil {
+		vars = Vars{}
+	}
+	expanded, err := expand(t.root, vars, "")
+	if err != nil {
+		return nil, err
+	}
+	root := expanded.(map[string]interface{})
+
+	// Complete cards only get variables substituted
+	if schema, ok := root["schema"]; ok {
+		if n, ok := schema.(float64); ok && n == 2 {
+			root["schema"] = "2.0" // unquoted 2.0 in YAML
+		}
+		return root, nil
+	}
+	b := &builder{resolve: resolve, images: make(map[string]string)}
+	return b.card(root)
+}
+
+// builder expands the short form into card JSON 2.0
+type builder struct {
+	resolve ImageResolver
+	images  map[string]string // path -> image key, so each file is uploaded once
+}
+
+func (b *builder) card(root map[string]interface{}) (map[string]interface{}, error) {
+	for _, k := range sortedKeys(root) {
+		switch k {
+		case "header", "config", "elements":
+		default:
+			return nil, fmt.Errorf("unknown template key %q (want header, config and elements, or a complete card with schema)", k)
+		}
+	}
+
+	out := map[string]interface{}{"schema": "2.0"}
+	if config, ok := root["config"]; ok {
+		out["config"] = config
+	}
+	if header, ok := root["header"]; ok {
+		h, err := b.header(header)
+		if err != nil {
+			return nil, err
+		}
+		out["header"] = h
+	}
+
+	items, ok := root["elements"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("elements: must be a list")
+	}
+	elements, err := b.elements(items, "elements")
+	if err != nil {
+		return nil, err
+	}
+	out["body"] = map[string]interface{}{"elements": elements}
+	return out, nil
+}
+
+// header accepts a title string or a map with title, subtitle and color
+func (b *builder) header(v interface{}) (map[string]interface{}, error) {
+	switch h := v.(type) {
+	case string:
+		return map[string]interface{}{"title": plainText(h)}, nil
+	case map[string]interface{}:
+		if _, ok := h["title"].(map[string]interface{}); ok {
+			return h, nil
+		}
+		out := map[string]interface{}{}
+		for _, k := range sortedKeys(h) {
+			switch k {
+			case "title", "subtitle":
+				out[k] = plainText(fmt.Sprint(h[k]))
+			case "color", "template":
+				out["template"] = h[k]
+			default:
+				return nil, fmt.Errorf("header: unknown key %q (want title, subtitle, color)", k)
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("header: must be a title or a map")
+}
+
+func (b *builder) elements(items []interface{}, path string) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		e, err := b.element(item, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// element expands one short-form element. Maps with a tag are card
+// components already and pass through.
+func (b *builder) element(v interface{}, path string) (interface{}, error) {
+	switch e := v.(type) {
+	case string:
+		if e == "hr" {
+			return map[string]interface{}{"tag": "hr"}, nil
+		}
+		return map[string]interface{}{"tag": "markdown", "content": e}, nil
+	case map[string]interface{}:
+		if _, ok := e["tag"]; ok {
+			return e, nil
+		}
+		switch {
+		case e["markdown"] != nil:
+			return b.markdown(e, path)
+		case e["image"] != nil:
+			return b.image(e, path)
+		case e["button"] != nil:
+			m, ok := e["button"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s.button: must be a map", path)
+			}
+			return b.button(m, path+".button")
+		case e["buttons"] != nil:
+			return b.buttons(e["buttons"], path+".buttons")
+		case e["columns"] != nil:
+			return b.columns(e["columns"], path+".columns")
+		case e["fields"] != nil:
+			return b.fields(e["fields"], path+".fields")
+		case e["hr"] != nil:
+			return map[string]interface{}{"tag": "hr"}, nil
+		}
+		return nil, fmt.Errorf("%s: unknown element with keys %s (want markdown, image, button, buttons, columns, fields, hr or a tag)",
+			path, strings.Join(sortedKeys(e), ", "))
+	}
+	return nil, fmt.Errorf("%s: element must be a map or a string", path)
+}
+
+func (b *builder) markdown(e map[string]interface{}, path string) (interface{}, error) {
+	out := map[string]interface{}{"tag": "markdown", "content": fmt.Sprint(e["markdown"])}
+	for _, k := range sortedKeys(e) {
+		switch k {
+		case "markdown":
+		case "align":
+			out["text_align"] = e[k]
+		case "size":
+			out["text_size"] = e[k]
+		default:
+			return nil, fmt.Errorf("%s: unknown markdown option %q (want align, size)", path, k)
+		}
+	}
+	return out, nil
+}
+
+func (b *builder) image(e map[string]interface{}, path string) (interface{}, error) {
+	src := fmt.Sprint(e["image"])
+	key := src
+	if !strings.HasPrefix(src, "img_") && b.resolve != nil {
+		if cached, ok := b.images[src]; ok {
+			key = cached
+		} else {
+			var err error
+			if key, err = b.resolve(src); err != nil {
+				return nil, err
+			}
+			b.images[src] = key
+		}
+	}
+
+	alt := ""
+	out := map[string]interface{}{"tag": "img", "img_key": key}
+	for _, k := range sortedKeys(e) {
+		switch k {
+		case "image":
+		case "alt":
+			alt = fmt.Sprint(e[k])
+		case "title":
+			out["title"] = plainText(fmt.Sprint(e[k]))
+		case "scale":
+			out["scale_type"] = e[k]
+		default:
+			return nil, fmt.Errorf("%s: unknown image option %q (want alt, title, scale)", path, k)
+		}
+	}
+	out["alt"] = plainText(alt)
+	return out, nil
+}
+
+// button builds a button that opens url or, with value, sends a callback
+func (b *builder) button(e map[string]interface{}, path string) (interface{}, error) {
+	out := map[string]interface{}{"tag": "button", "type": "default"}
+	var behaviors []interface{}
+	for _, k := range sortedKeys(e) {
+		switch k {
+		case "text":
+			out["text"] = plainText(fmt.Sprint(e[k]))
+		case "type":
+			out["type"] = e[k]
+		case "url":
+			behaviors = append(behaviors, map[string]interface{}{"type": "open_url", "default_url": e[k]})
+		case "value":
+			behaviors = append(behaviors, map[string]interface{}{"type": "callback", "value": e[k]})
+		case "confirm":
+			out["confirm"] = map[string]interface{}{
+				"title": plainText("Confirm"),
+				"text":  plainText(fmt.Sprint(e[k])),
+			}
+		default:
+			return nil, fmt.Errorf("%s: unknown button option %q (want text, type, url, value, confirm)", path, k)
+		}
+	}
+	if behaviors != nil {
+		out["behaviors"] = behaviors
+	}
+	return out, nil
+}
+
+// buttons lays buttons out side by side
+func (b *builder) buttons(v interface{}, path string) (interface{}, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: must be a list", path)
+	}
+	columns := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s[%d]: must be a map", path, i)
+		}
+		button, err := b.button(m, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, map[string]interface{}{
+			"tag":      "column",
+			"width":    "auto",
+			"elements": []interface{}{button},
+		})
+	}
+	return map[string]interface{}{"tag": "column_set", "flex_mode": "flow", "columns": columns}, nil
+}
+
+// columns accepts a list of element lists, or of maps with elements,
+// weight, width and align
+func (b *builder) columns(v interface{}, path string) (interface{}, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: must be a list", path)
+	}
+	columns := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		column := map[string]interface{}{"tag": "column", "width": "weighted", "weight": 1, "vertical_align": "top"}
+		var children []interface{}
+		switch c := item.(type) {
+		case []interface{}:
+			children = c
+		case map[string]interface{}:
+			for _, k := range sortedKeys(c) {
+				switch k {
+				case "elements":
+					if children, ok = c[k].([]interface{}); !ok {
+						return nil, fmt.Errorf("%s.elements: must be a list", itemPath)
+					}
+				case "weight":
+					n, err := toInt(c[k])
+					if err != nil {
+						return nil, fmt.Errorf("%s.weight: %w", itemPath, err)
+					}
+					column["weight"] = n
+				case "width":
+					column["width"] = c[k]
+					if c[k] != "weighted" {
+						delete(column, "weight")
+					}
+				case "align":
+					column["vertical_align"] = c[k]
+				default:
+					return nil, fmt.Errorf("%s: unknown column option %q (want elements, weight, width, align)", itemPath, k)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%s: must be a list of elements or a map", itemPath)
+		}
+		elements, err := b.elements(children, itemPath+".elements")
+		if err != nil {
+			return nil, err
+		}
+		column["elements"] = elements
+		columns = append(columns, column)
+	}
+	return map[string]interface{}{"tag": "column_set", "flex_mode": "none", "columns": columns}, nil
+}
+
+// fields lays label/value pairs out two to a row
+func (b *builder) fields(v interface{}, path string) (interface{}, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: must be a list", path)
+	}
+	columns := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok || m["label"] == nil {
+			return nil, fmt.Errorf("%s[%d]: must be a map with label and value", path, i)
+		}
+		value := ""
+		if m["value"] != nil {
+			value = fmt.Sprint(m["value"])
+		}
+		columns = append(columns, map[string]interface{}{
+			"tag":    "column",
+			"width":  "weighted",
+			"weight": 1,
+			"elements": []interface{}{map[string]interface{}{
+				"tag":     "markdown",
+				"content": fmt.Sprintf("**%v**\n%s", m["label"], value),
+			}},
+		})
+	}
+	return map[string]interface{}{"tag": "column_set", "flex_mode": "bisect", "columns": columns}, nil
+}
+
+func plainText(s string) map[string]interface{} {
+	return map[string]interface{}{"tag": "plain_text", "content": s}
+}
+
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case float64:
+		return int(n), nil
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil {
+			return 0, fmt.Errorf("must be a number, got %q", n)
+		}
+		return i, nil
+	}
+	return 0, fmt.Errorf("must be a number")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,221 @@
+// Package card builds Lark interactive message cards from YAML or JSON templates.
+//
+// A template is either a complete card (it has a "schema" key) or a short
+// form with header, config and elements, where elements are written as
+// {markdown: ...}, {image: ...}, {button: ...}, {buttons: [...]},
+// {columns: [...]}, {fields: [...]} or hr. Elements that have a "tag" key are
+// passed through unchanged, so any card component can be used directly.
+//
+// String values may reference variables with Go template syntax, e.g.
+// "Deployed {{.service}}". List items may carry "each: name" to repeat the
+// item for every entry in a list variable (the entry is available as .item)
+// and "if: ..." to drop it when the expression renders empty, "0" or
+// "false".
+package card
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Vars are the values substituted into a template
+type Vars map[string]interface{}
+
+// Template is a parsed card template
+type Template struct {
+	root map[string]interface{}
+}
+
+// Parse reads a YAML or JSON template
+func Parse(r io.Reader) (*Template, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid card template: %w", err)
+	}
+	if root == nil {
+		return nil, fmt.Errorf("card template is empty")
+	}
+	return &Template{root: root}, nil
+}
+
+// ParseFile reads a template from a file, or from stdin when path is "-"
+func ParseFile(path string) (*Template, error) {
+	if path == "-" {
+		return Parse(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// ReadVars reads variables from a YAML or JSON file
+func ReadVars(path string) (Vars, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var vars Vars
+	if err := yaml.Unmarshal(data, &vars); err != nil {
+		return nil, fmt.Errorf("invalid vars file %s: %w", path, err)
+	}
+	if vars == nil {
+		vars = Vars{}
+	}
+	return vars, nil
+}
+
+// ParseVar parses a key=value assignment into vars
+func (v Vars) ParseVar(assignment string) error {
+	key, value, ok := strings.Cut(assignment, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("invalid variable %q (want key=value)", assignment)
+	}
+	v[key] = value
+	return nil
+}
+
+// with returns a copy of v with one more variable set
+func (v Vars) with(key string, value interface{}) Vars {
+	out := make(Vars, len(v)+1)
+	for k, val := range v {
+		out[k] = val
+	}
+	out[key] = value
+	return out
+}
+
+// expand substitutes variables into every string in a template tree and
+// applies each/if to list items. Map keys are left alone.
+func expand(node interface{}, vars Vars, path string) (interface{}, error) {
+	switch n := node.(type) {
+	case string:
+		return execute(n, vars, path, false)
+	case map[string]interface{}:
+		// Sorted keys so the first missing variable reported is stable
+		out := make(map[string]interface{}, len(n))
+		for _, k := range sortedKeys(n) {
+			v, err := expand(n[k], vars, path+"."+k)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = v
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, 0, len(n))
+		for i, child := range n {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			m, ok := child.(map[string]interface{})
+			if ok && (m["each"] != nil || m["if"] != nil) {
+				items, err := repeat(m, vars, itemPath)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, items...)
+				continue
+			}
+			v, err := expand(child, vars, itemPath)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	default:
+		return node, nil
+	}
+}
+
+// repeat expands a list item that has each and/or if
+func repeat(item map[string]interface{}, vars Vars, path string) ([]interface{}, error) {
+	body := make(map[string]interface{}, len(item))
+	for k, v := range item {
+		if k != "each" && k != "if" {
+			body[k] = v
+		}
+	}
+
+	scopes := []Vars{vars}
+	if each, ok := item["each"]; ok {
+		name, _ := each.(string)
+		name = strings.TrimPrefix(strings.TrimSpace(name), ".")
+		list, ok := vars[name].([]interface{})
+		if !ok {
+			if vars[name] != nil || name == "" {
+				return nil, fmt.Errorf("%s: each: %q is not a list variable", path, each)
+			}
+			list = nil
+		}
+		scopes = scopes[:0]
+		for _, entry := range list {
+			scopes = append(scopes, vars.with("item", entry))
+		}
+	}
+
+	var out []interface{}
+	for _, scope := range scopes {
+		if cond, ok := item["if"]; ok {
+			s, err := execute(fmt.Sprint(cond), scope, path+".if", true)
+			if err != nil {
+				return nil, err
+			}
+			if !truthy(s.(string)) {
+				continue
+			}
+		}
+		v, err := expand(body, scope, path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// execute renders a string as a Go template. Conditions treat missing
+// variables as empty instead of failing.
+func execute(s string, vars Vars, path string, lenient bool) (interface{}, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	name := strings.TrimPrefix(path, ".")
+	t := template.New(name)
+	if !lenient {
+		t = t.Option("missingkey=error")
+	}
+	t, err := t.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, map[string]interface{}(vars)); err != nil {
+		if _, key, ok := strings.Cut(err.Error(), "map has no entry for key "); ok {
+			return nil, fmt.Errorf("%s: variable %s is not set", name, key)
+		}
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+// truthy reports whether a rendered condition should include its item
+func truthy(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "0", "false", "no", "<no value>":
+		return false
+	}
+	return true
+}
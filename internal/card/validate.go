@@ -0,0 +1,276 @@
+package card
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Limits the API enforces on cards
+const (
+	MaxCardBytes    = 30 * 1024
+	MaxCardElements = 200
+)
+
+var (
+	headerColors = set("blue", "wathet", "turquoise", "green", "yellow", "orange", "red",
+		"carmine", "violet", "purple", "indigo", "grey", "default")
+	buttonTypes = set("default", "primary", "danger", "text", "primary_text", "danger_text",
+		"primary_filled", "danger_filled", "laser")
+	behaviorTypes = set("open_url", "callback", "form_action")
+	flexModes     = set("none", "stretch", "flow", "bisect", "trisect")
+
+	// Components allowed in a card JSON 2.0 body
+	componentTags = set("markdown", "div", "hr", "img", "img_combination", "button",
+		"column_set", "collapsible_panel", "form", "interactive_container", "input",
+		"select_static", "multi_select_static", "select_person", "multi_select_person",
+		"select_img", "date_picker", "picker_time", "picker_datetime", "overflow",
+		"checker", "chart", "table", "person", "person_list", "avatar", "audio", "plain_text")
+)
+
+// Problem is one way a card breaks the schema, at a JSON path
+type Problem struct {
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// ValidationError lists every problem found in a card
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.String()
+	}
+	return "invalid card: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks a card against the card JSON 2.0 schema: known component
+// tags, required fields, enum values and the size and element limits.
+// It returns a *ValidationError listing every problem.
+func Validate(c map[string]interface{}) error {
+	v := &validator{}
+	v.card(c)
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+type validator struct {
+	problems []Problem
+	count    int // components seen
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) card(c map[string]interface{}) {
+	if schema := fmt.Sprint(c["schema"]); schema != "2.0" {
+		v.fail("schema", "must be \"2.0\", got %q", schema)
+	}
+	if config, ok := c["config"]; ok {
+		if _, ok := config.(map[string]interface{}); !ok {
+			v.fail("config", "must be an object")
+		}
+	}
+	if header, ok := c["header"]; ok {
+		v.header(header)
+	}
+
+	body, ok := c["body"].(map[string]interface{})
+	if !ok {
+		v.fail("body", "is required")
+	} else {
+		elements, ok := body["elements"].([]interface{})
+		if !ok || len(elements) == 0 {
+			v.fail("body.elements", "must be a non-empty list")
+		}
+		v.elements(elements, "body.elements")
+	}
+
+	if v.count > MaxCardElements {
+		v.fail("body", "has %d components, more than the limit of %d", v.count, MaxCardElements)
+	}
+	if data, err := json.Marshal(c); err != nil {
+		v.fail("card", "%v", err)
+	} else if len(data) > MaxCardBytes {
+		v.fail("card", "is %d bytes, more than the limit of %d", len(data), MaxCardBytes)
+	}
+}
+
+func (v *validator) header(h interface{}) {
+	header, ok := h.(map[string]interface{})
+	if !ok {
+		v.fail("header", "must be an object")
+		return
+	}
+	v.text(header["title"], "header.title", true)
+	if subtitle, ok := header["subtitle"]; ok {
+		v.text(subtitle, "header.subtitle", false)
+	}
+	if t, ok := header["template"]; ok && !headerColors[fmt.Sprint(t)] {
+		v.fail("header.template", "unknown color %q (want one of %s)", t, keys(headerColors))
+	}
+}
+
+// text checks a plain_text or lark_md text object
+func (v *validator) text(t interface{}, path string, required bool) {
+	obj, ok := t.(map[string]interface{})
+	if !ok {
+		if t != nil || required {
+			v.fail(path, "must be a text object with tag and content")
+		}
+		return
+	}
+	if tag := fmt.Sprint(obj["tag"]); tag != "plain_text" && tag != "lark_md" {
+		v.fail(path+".tag", "must be plain_text or lark_md, got %q", tag)
+	}
+	content, ok := obj["content"].(string)
+	if !ok || (required && content == "") {
+		v.fail(path+".content", "must be a non-empty string")
+	}
+}
+
+func (v *validator) elements(items []interface{}, path string) {
+	for i, item := range items {
+		v.element(item, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+func (v *validator) element(item interface{}, path string) {
+	v.count++
+	e, ok := item.(map[string]interface{})
+	if !ok {
+		v.fail(path, "must be an object")
+		return
+	}
+	tag, _ := e["tag"].(string)
+	switch {
+	case tag == "":
+		v.fail(path+".tag", "is required")
+		return
+	case tag == "action" || tag == "note":
+		v.fail(path+".tag", "%q is not supported in schema 2.0 (use column_set or markdown)", tag)
+		return
+	case !componentTags[tag]:
+		v.fail(path+".tag", "unknown component %q", tag)
+		return
+	}
+
+	switch tag {
+	case "markdown":
+		if s, ok := e["content"].(string); !ok || s == "" {
+			v.fail(path+".content", "must be a non-empty string")
+		}
+	case "div":
+		if e["text"] == nil && e["fields"] == nil {
+			v.fail(path, "div needs text or fields")
+		}
+		if e["text"] != nil {
+			v.text(e["text"], path+".text", false)
+		}
+	case "img":
+		if s, ok := e["img_key"].(string); !ok || s == "" {
+			v.fail(path+".img_key", "must be a non-empty string")
+		}
+		if alt, ok := e["alt"]; ok {
+			v.text(alt, path+".alt", false)
+		}
+	case "button":
+		v.button(e, path)
+	case "column_set":
+		v.columnSet(e, path)
+	case "collapsible_panel", "form", "interactive_container":
+		if children, ok := e["elements"].([]interface{}); ok {
+			v.elements(children, path+".elements")
+		} else {
+			v.fail(path+".elements", "must be a list")
+		}
+	}
+}
+
+func (v *validator) button(e map[string]interface{}, path string) {
+	v.text(e["text"], path+".text", true)
+	if t, ok := e["type"]; ok && !buttonTypes[fmt.Sprint(t)] {
+		v.fail(path+".type", "unknown button type %q (want one of %s)", t, keys(buttonTypes))
+	}
+	behaviors, _ := e["behaviors"].([]interface{})
+	if e["behaviors"] != nil && behaviors == nil {
+		v.fail(path+".behaviors", "must be a list")
+	}
+	for i, b := range behaviors {
+		bpath := fmt.Sprintf("%s.behaviors[%d]", path, i)
+		behavior, ok := b.(map[string]interface{})
+		if !ok {
+			v.fail(bpath, "must be an object")
+			continue
+		}
+		t := fmt.Sprint(behavior["type"])
+		if !behaviorTypes[t] {
+			v.fail(bpath+".type", "unknown behavior %q (want one of %s)", t, keys(behaviorTypes))
+			continue
+		}
+		if t == "open_url" {
+			url, _ := behavior["default_url"].(string)
+			if !strings.Contains(url, "://") {
+				v.fail(bpath+".default_url", "must be an absolute URL, got %q", url)
+			}
+		}
+	}
+}
+
+func (v *validator) columnSet(e map[string]interface{}, path string) {
+	if mode, ok := e["flex_mode"]; ok && !flexModes[fmt.Sprint(mode)] {
+		v.fail(path+".flex_mode", "unknown flex mode %q (want one of %s)", mode, keys(flexModes))
+	}
+	columns, ok := e["columns"].([]interface{})
+	if !ok || len(columns) == 0 {
+		v.fail(path+".columns", "must be a non-empty list")
+		return
+	}
+	for i, item := range columns {
+		cpath := fmt.Sprintf("%s.columns[%d]", path, i)
+		column, ok := item.(map[string]interface{})
+		if !ok || column["tag"] != "column" {
+			v.fail(cpath, "must be an object with tag column")
+			continue
+		}
+		if column["width"] == "weighted" {
+			if w, err := toInt(column["weight"]); err != nil || w < 1 || w > 5 {
+				v.fail(cpath+".weight", "must be between 1 and 5 for weighted columns")
+			}
+		}
+		children, ok := column["elements"].([]interface{})
+		if !ok {
+			v.fail(cpath+".elements", "must be a list")
+			continue
+		}
+		v.elements(children, cpath+".elements")
+	}
+}
+
+func set(values ...string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+func keys(s map[string]bool) string {
+	out := make([]string, 0, len(s))
+	for k := range s {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return strings.Join(out, ", ")
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/names"
 	"github.com/yjwong/lark-cli/internal/output"
 )
 
@@ -202,13 +203,20 @@ Examples:
 
 // --- List Attendees ---
 
+var listAttendeeResolve bool
+
 var attendeeListCmd = &cobra.Command{
 	Use:   "list <event-id>",
 	Short: "List attendees of an event",
 	Long: `List all attendees of a calendar event.
 
+The API doesn't always return a display name, for example for users outside
+your organization's visible range. --resolve-names looks up the names of
+user and chat attendees that have none, caching them for a day.
+
 Examples:
-  lark cal attendee list <event-id>`,
+  lark cal attendee list <event-id>
+  lark cal attendee list <event-id> --resolve-names`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		eventID := args[0]
@@ -227,12 +235,34 @@ Examples:
 			output.Fatal("API_ERROR", err)
 		}
 
+		var resolver *names.Resolver
+		if listAttendeeResolve {
+			var users, chats []string
+			for _, att := range attendees {
+				if att.DisplayName == "" {
+					users = append(users, att.UserID)
+					chats = append(chats, att.ChatID)
+				}
+			}
+			resolver = names.NewResolver(client)
+			resolveNames(resolver, users, chats)
+		}
+
 		// Convert to output format
 		var outAttendees []map[string]interface{}
 		for _, att := range attendees {
+			name := att.DisplayName
+			if name == "" {
+				switch att.Type {
+				case "user":
+					name = resolver.User(att.UserID)
+				case "chat":
+					name = resolver.Chat(att.ChatID)
+				}
+			}
 			outAtt := map[string]interface{}{
 				"id":          att.AttendeeID,
-				"name":        att.DisplayName,
+				"name":        name,
 				"type":        att.Type,
 				"rsvp_status": att.RsvpStatus,
 			}
@@ -271,6 +301,9 @@ func init() {
 	attendeeRemoveCmd.Flags().BoolVar(&removeAttendeeSelf, "self", false, "Remove yourself from the event")
 	attendeeRemoveCmd.Flags().BoolVar(&removeAttendeeNoNotify, "no-notify", false, "Don't send notifications")
 
+	// List command flags
+	attendeeListCmd.Flags().BoolVar(&listAttendeeResolve, "resolve-names", false, "Look up names of attendees without a display name")
+
 	// Register subcommands
 	attendeeCmd.AddCommand(attendeeAddCmd)
 	attendeeCmd.AddCommand(attendeeRemoveCmd)
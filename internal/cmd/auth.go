@@ -7,13 +7,19 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/yjwong/lark-cli/internal/api"
 	"github.com/yjwong/lark-cli/internal/auth"
+	"github.com/yjwong/lark-cli/internal/config"
 	"github.com/yjwong/lark-cli/internal/output"
 	"github.com/yjwong/lark-cli/internal/scopes"
+	"github.com/yjwong/lark-cli/internal/secrets"
 )
 
 var (
-	loginScopes string
-	loginAdd    bool
+	loginScopes   string
+	loginAdd      bool
+	loginHeadless bool
+
+	migrateFrom string
+	migrateTo   string
 )
 
 var authCmd = &cobra.Command{
@@ -36,9 +42,28 @@ Examples:
   lark auth login                           # All permissions (default)
   lark auth login --scopes calendar         # Only calendar permissions
   lark auth login --scopes calendar,contacts # Calendar and contacts
-  lark auth login --add --scopes messages   # Add messaging to existing permissions`,
+  lark auth login --add --scopes messages   # Add messaging to existing permissions
+  lark auth login --profile personal        # Login to a separate profile
+  lark auth login --headless                # SSH/containers: paste the redirect URL
+
+Headless mode prints the authorization URL instead of opening a browser.
+Open it anywhere, approve, then paste the URL the browser is redirected to
+(it will fail to load) or just its code parameter.
+
+In bot mode (bot_mode: true or LARK_BOT_MODE=true) no user login is used;
+this command only verifies that a tenant access token can be obtained.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		opts := auth.LoginOptions{}
+		if config.IsBotMode() {
+			if err := auth.RefreshTenantToken(); err != nil {
+				output.Fatal("AUTH_ERROR", err)
+			}
+			output.Success("Bot mode: tenant access token obtained")
+			return
+		}
+
+		opts := auth.LoginOptions{
+			Headless: loginHeadless,
+		}
 
 		if loginScopes != "" {
 			// Parse and validate scope groups
@@ -80,9 +105,21 @@ var statusCmd = &cobra.Command{
 	Short: "Show authentication status",
 	Long:  "Display current authentication status, token expiry, and granted permissions",
 	Run: func(cmd *cobra.Command, args []string) {
+		if config.IsBotMode() {
+			output.JSON(api.OutputAuthStatus{
+				Profile:       config.GetProfile(),
+				Mode:          "bot",
+				Authenticated: auth.EnsureValidTenantToken() == nil,
+				ExpiresAt:     auth.GetTenantTokenStore().GetExpiresAt(),
+			})
+			return
+		}
+
 		store := auth.GetTokenStore()
 
 		status := api.OutputAuthStatus{
+			Profile:       config.GetProfile(),
+			Mode:          "user",
 			Authenticated: store.IsValid(),
 			ExpiresAt:     store.GetExpiresAt(),
 		}
@@ -135,12 +172,72 @@ var scopesCmd = &cobra.Command{
 	},
 }
 
+var migrateSecretsCmd = &cobra.Command{
+	Use:   "migrate-secrets",
+	Short: "Move stored secrets to another backend",
+	Long: `Move OAuth tokens, tenant tokens and mail credentials for the current
+profile from one secret backend to another.
+
+Backends:
+  file       Plaintext JSON files in the profile directory (default)
+  keyring    OS keyring (Secret Service over D-Bus on Linux, Keychain on macOS)
+  encrypted  age-encrypted files, using secrets.identity_file or LARK_SECRETS_PASSPHRASE
+
+By default secrets move from plaintext files to the backend set in
+secrets.backend. Secrets are removed from the source once written.
+
+Examples:
+  lark auth migrate-secrets                          # file -> configured backend
+  lark auth migrate-secrets --to keyring
+  lark auth migrate-secrets --from keyring --to file # move back to plaintext`,
+	Run: func(cmd *cobra.Command, args []string) {
+		to := migrateTo
+		if to == "" {
+			to = config.GetSecretsBackend()
+		}
+		if to == migrateFrom {
+			output.Fatalf("VALIDATION_ERROR", "source and destination backends are both %q; set secrets.backend or use --to", to)
+		}
+
+		from, err := secrets.OpenBackend(migrateFrom)
+		if err != nil {
+			output.Fatal("VALIDATION_ERROR", err)
+		}
+		dest, err := secrets.OpenBackend(to)
+		if err != nil {
+			output.Fatal("VALIDATION_ERROR", err)
+		}
+
+		migrated, err := secrets.Migrate(from, dest)
+		if err != nil {
+			output.Fatal("SECRETS_ERROR", err)
+		}
+
+		result := map[string]interface{}{
+			"success":  true,
+			"profile":  config.GetProfile(),
+			"from":     from.Name(),
+			"to":       dest.Name(),
+			"migrated": migrated,
+		}
+		if dest.Name() != config.GetSecretsBackend() {
+			result["note"] = fmt.Sprintf("set secrets.backend to %q in config.yaml so the CLI reads the migrated secrets", dest.Name())
+		}
+		output.JSON(result)
+	},
+}
+
 func init() {
 	loginCmd.Flags().StringVar(&loginScopes, "scopes", "", "Comma-separated scope groups (calendar,contacts,documents,messages,mail,minutes)")
 	loginCmd.Flags().BoolVar(&loginAdd, "add", false, "Add to existing permissions (incremental authorization)")
+	loginCmd.Flags().BoolVar(&loginHeadless, "headless", false, "Don't open a browser; paste the redirect URL or code on stdin")
+
+	migrateSecretsCmd.Flags().StringVar(&migrateFrom, "from", secrets.BackendFile, "Backend to move secrets from (file, keyring, encrypted)")
+	migrateSecretsCmd.Flags().StringVar(&migrateTo, "to", "", "Backend to move secrets to (default: secrets.backend from config)")
 
 	authCmd.AddCommand(loginCmd)
 	authCmd.AddCommand(logoutCmd)
 	authCmd.AddCommand(statusCmd)
 	authCmd.AddCommand(scopesCmd)
+	authCmd.AddCommand(migrateSecretsCmd)
 }
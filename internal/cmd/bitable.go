@@ -231,6 +231,30 @@ func bitableFieldTypeToString(fieldType int) string {
 }
 
 func init() {
+	output.RegisterTable(api.OutputBitableTableList{}, output.Table{
+		Items: "tables",
+		Columns: []output.Column{
+			{Header: "TABLE_ID", Path: "table_id"},
+			{Header: "NAME", Path: "name"},
+		},
+	})
+	output.RegisterTable(api.OutputBitableFieldList{}, output.Table{
+		Items: "fields",
+		Columns: []output.Column{
+			{Header: "FIELD_ID", Path: "field_id"},
+			{Header: "NAME", Path: "field_name"},
+			{Header: "TYPE", Path: "type"},
+			{Header: "PRIMARY", Path: "is_primary"},
+		},
+	})
+	output.RegisterTable(api.OutputBitableRecordList{}, output.Table{
+		Items: "records",
+		Columns: []output.Column{
+			{Header: "RECORD_ID", Path: "record_id"},
+			{Header: "FIELDS", Path: "fields"},
+		},
+	})
+
 	// bitable records flags
 	bitableRecordsCmd.Flags().IntVar(&bitableRecordsLimit, "limit", 0,
 		"Maximum number of records to retrieve (0 = no limit)")
@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/yjwong/lark-cli/internal/auth"
+	"github.com/yjwong/lark-cli/internal/config"
 	"github.com/yjwong/lark-cli/internal/output"
 	"github.com/yjwong/lark-cli/internal/scopes"
 )
@@ -21,6 +22,12 @@ var calCmd = &cobra.Command{
 // validateScopeGroup checks if the required scope group is granted
 // and exits with a helpful error message if not
 func validateScopeGroup(groupName string) {
+	// In bot mode there is no user token to check; the app's own permissions
+	// apply and commands that need a user token fail when they request one
+	if config.IsBotMode() {
+		return
+	}
+
 	store := auth.GetTokenStore()
 
 	// First check if authenticated at all
@@ -51,5 +58,8 @@ func init() {
 	calCmd.AddCommand(rsvpCmd)
 	calCmd.AddCommand(lookupUserCmd)
 	calCmd.AddCommand(commonFreetimeCmd)
+	calCmd.AddCommand(findSlotCmd)
+	calCmd.AddCommand(exportCmd)
+	calCmd.AddCommand(importCmd)
 	calCmd.AddCommand(attendeeCmd)
 }
@@ -108,6 +108,16 @@ Examples:
 }
 
 func init() {
+	output.RegisterTable(api.OutputChatList{}, output.Table{
+		Items: "chats",
+		Columns: []output.Column{
+			{Header: "CHAT_ID", Path: "chat_id"},
+			{Header: "NAME", Path: "name"},
+			{Header: "DESCRIPTION", Path: "description"},
+			{Header: "EXTERNAL", Path: "external"},
+		},
+	})
+
 	chatSearchCmd.Flags().IntVar(&chatSearchLimit, "limit", 0,
 		"Maximum number of chats to retrieve (0 = no limit)")
 
@@ -139,6 +139,15 @@ Examples:
 }
 
 func init() {
+	output.RegisterTable(api.OutputCommonFreeTime{}, output.Table{
+		Items: "free_slots",
+		Columns: []output.Column{
+			{Header: "START", Path: "start"},
+			{Header: "END", Path: "end"},
+			{Header: "MINUTES", Path: "length_minutes"},
+		},
+	})
+
 	commonFreetimeCmd.Flags().StringVar(&commonFreetimeFrom, "from", "", "Start time (required, ISO 8601 or date)")
 	commonFreetimeCmd.Flags().StringVar(&commonFreetimeTo, "to", "", "End time (required, ISO 8601 or date)")
 	commonFreetimeCmd.Flags().StringVar(&commonFreetimeUsers, "users", "", "Comma-separated user open_ids (required, max 10)")
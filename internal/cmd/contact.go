@@ -248,6 +248,25 @@ Examples:
 }
 
 func init() {
+	output.RegisterTable(api.OutputContactList{}, output.Table{
+		Items: "contacts",
+		Columns: []output.Column{
+			{Header: "USER_ID", Path: "user_id"},
+			{Header: "NAME", Path: "name"},
+			{Header: "EMAIL", Path: "email"},
+			{Header: "JOB_TITLE", Path: "job_title"},
+			{Header: "DEPARTMENT", Path: "department"},
+		},
+	})
+	output.RegisterTable(api.OutputDepartmentList{}, output.Table{
+		Items: "departments",
+		Columns: []output.Column{
+			{Header: "DEPARTMENT_ID", Path: "department_id"},
+			{Header: "NAME", Path: "name"},
+			{Header: "MEMBERS", Path: "member_count"},
+		},
+	})
+
 	// contact get flags
 	contactGetCmd.Flags().StringVar(&contactGetIDType, "id-type", "open_id", "Type of user ID (open_id, union_id, user_id)")
 
@@ -27,6 +27,7 @@ var (
 	createVisibility      string
 	createAttendeeAbility string
 	createExcludeSelf     bool
+	createRecurrence      recurrenceFlags
 )
 
 var createCmd = &cobra.Command{
@@ -37,7 +38,10 @@ var createCmd = &cobra.Command{
 Examples:
   lark cal create --summary "Team standup" --start 2026-01-03T09:00:00+08:00 --duration 30m
   lark cal create --summary "1:1 with John" --start 2026-01-03T14:00:00+08:00 --duration 30m --attendee john@example.com
-  lark cal create --summary "Focus Time" --start 2026-01-03T14:00:00+08:00 --duration 2h --color "#9CA2A9"`,
+  lark cal create --summary "Focus Time" --start 2026-01-03T14:00:00+08:00 --duration 2h --color "#9CA2A9"
+  lark cal create --summary "Standup" --start 2026-01-05T09:00:00+08:00 --duration 15m --repeat weekly --on MO,WE,FR
+  lark cal create --summary "Retro" --start 2026-01-09T16:00:00+08:00 --duration 1h --repeat weekly --interval 2 --until 2026-06-30
+  lark cal create --summary "Review" --start 2026-01-05T10:00:00+08:00 --duration 1h --rrule "FREQ=MONTHLY;BYDAY=1MO;COUNT=6"`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if createSummary == "" {
 			output.Fatalf("VALIDATION_ERROR", "--summary is required")
@@ -99,6 +103,12 @@ Examples:
 			},
 		}
 
+		recurrence, _, err := createRecurrence.build(loc)
+		if err != nil {
+			output.Fatalf("VALIDATION_ERROR", "Invalid recurrence: %v", err)
+		}
+		req.Recurrence = recurrence
+
 		if createLocation != "" {
 			req.Location = &api.Location{Name: createLocation}
 		}
@@ -211,6 +221,7 @@ func init() {
 	createCmd.Flags().StringVar(&createVisibility, "visibility", "", "Event visibility (default, public, private)")
 	createCmd.Flags().StringVar(&createAttendeeAbility, "attendee-ability", "", "Guest permissions (none, can_see_others, can_invite_others, can_modify_event)")
 	createCmd.Flags().BoolVar(&createExcludeSelf, "exclude-self", false, "Don't add yourself as an attendee")
+	addRecurrenceFlags(createCmd, &createRecurrence, false)
 
 	createCmd.MarkFlagRequired("summary")
 	createCmd.MarkFlagRequired("start")
@@ -8,6 +8,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/names"
 	"github.com/yjwong/lark-cli/internal/output"
 )
 
@@ -283,8 +284,13 @@ The document_id is the token from the document URL.
 For example, if the URL is https://xxx.larksuite.com/docx/ABC123xyz
 then the document_id is ABC123xyz.
 
+--resolve-names adds the names of commenters and replaces @mentions of
+users with their names. Names are looked up through the contact API and
+cached for a day.
+
 Examples:
-  lark doc comments ABC123xyz`,
+  lark doc comments ABC123xyz
+  lark doc comments ABC123xyz --resolve-names`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		documentID := args[0]
@@ -296,22 +302,47 @@ Examples:
 			output.Fatal("API_ERROR", err)
 		}
 
-		result := convertCommentsToOutput(documentID, comments)
+		var resolver *names.Resolver
+		if resolve, _ := cmd.Flags().GetBool("resolve-names"); resolve {
+			resolver = names.NewResolver(client)
+			resolveNames(resolver, commentUserIDs(comments), nil)
+		}
+
+		result := convertCommentsToOutput(documentID, comments, resolver)
 		output.JSON(result)
 	},
 }
 
-// convertCommentsToOutput converts API comments to CLI output format
-func convertCommentsToOutput(fileToken string, comments []api.DocumentComment) api.OutputDocumentComments {
+// commentUserIDs returns the IDs of the users who wrote or are mentioned in
+// comments
+func commentUserIDs(comments []api.DocumentComment) []string {
+	var ids []string
+	for _, c := range comments {
+		ids = append(ids, c.UserID)
+		for _, r := range c.ReplyList.Replies {
+			ids = append(ids, r.UserID)
+			for _, elem := range r.Content.Elements {
+				if elem.Type == "person" && elem.Person != nil {
+					ids = append(ids, elem.Person.UserID)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// convertCommentsToOutput converts API comments to CLI output format, naming
+// users r knows
+func convertCommentsToOutput(fileToken string, comments []api.DocumentComment, r *names.Resolver) api.OutputDocumentComments {
 	outputComments := make([]api.OutputDocumentComment, len(comments))
 
 	for i, c := range comments {
 		// Convert replies
 		replies := make([]api.OutputCommentReply, len(c.ReplyList.Replies))
-		for j, r := range c.ReplyList.Replies {
+		for j, reply := range c.ReplyList.Replies {
 			// Extract text from reply elements
 			var text string
-			for _, elem := range r.Content.Elements {
+			for _, elem := range reply.Content.Elements {
 				switch elem.Type {
 				case "text_run":
 					if elem.TextRun != nil {
@@ -323,15 +354,20 @@ func convertCommentsToOutput(fileToken string, comments []api.DocumentComment) a
 					}
 				case "person":
 					if elem.Person != nil {
-						text += "@" + elem.Person.UserID
+						if name := r.User(elem.Person.UserID); name != "" {
+							text += "@" + name
+						} else {
+							text += "@" + elem.Person.UserID
+						}
 					}
 				}
 			}
 
 			replies[j] = api.OutputCommentReply{
-				ReplyID:    r.ReplyID,
-				UserID:     r.UserID,
-				CreateTime: formatUnixTimestamp(r.CreateTime),
+				ReplyID:    reply.ReplyID,
+				UserID:     reply.UserID,
+				UserName:   r.User(reply.UserID),
+				CreateTime: formatUnixTimestamp(reply.CreateTime),
 				Text:       text,
 			}
 		}
@@ -339,6 +375,7 @@ func convertCommentsToOutput(fileToken string, comments []api.DocumentComment) a
 		outputComments[i] = api.OutputDocumentComment{
 			CommentID:  c.CommentID,
 			UserID:     c.UserID,
+			UserName:   r.User(c.UserID),
 			CreateTime: formatUnixTimestamp(c.CreateTime),
 			IsSolved:   c.IsSolved,
 			IsWhole:    c.IsWhole,
@@ -508,15 +545,15 @@ The --doc flag is required to specify which document the image belongs to.
 This is needed for authentication with the Lark API.
 
 By default, outputs the binary image data to stdout.
-Use -o/--output to save to a file instead.
+Use --out to save to a file instead.
 
 Examples:
   lark doc image K1TQbpmDuokIq3xq1WVl9J7ygkc --doc ABC123xyz > image.png
-  lark doc image K1TQbpmDuokIq3xq1WVl9J7ygkc --doc ABC123xyz -o image.png`,
+  lark doc image K1TQbpmDuokIq3xq1WVl9J7ygkc --doc ABC123xyz --out image.png`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		imageToken := args[0]
-		outputFile, _ := cmd.Flags().GetString("output")
+		outputFile := outPath(cmd)
 		documentID, _ := cmd.Flags().GetString("doc")
 
 		if documentID == "" {
@@ -568,18 +605,18 @@ var docDownloadCmd = &cobra.Command{
 The file_token is obtained from 'doc list' or 'doc search' output.
 Only files with type "file" can be downloaded (not docs, sheets, etc).
 
-You must specify an output filename with -o/--output.
+You must specify an output filename with --out.
 
 Examples:
-  lark doc download FG3obxWuaoftXIx0CvxlQAabcef -o report.pdf
-  lark doc download FG3obxWuaoftXIx0CvxlQAabcef -o ~/Downloads/report.pdf`,
+  lark doc download FG3obxWuaoftXIx0CvxlQAabcef --out report.pdf
+  lark doc download FG3obxWuaoftXIx0CvxlQAabcef --out ~/Downloads/report.pdf`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		fileToken := args[0]
-		outputPath, _ := cmd.Flags().GetString("output")
+		outputPath := outPath(cmd)
 
 		if outputPath == "" {
-			output.Fatal("MISSING_ARG", fmt.Errorf("--output/-o flag is required"))
+			output.Fatal("MISSING_ARG", fmt.Errorf("--out flag is required"))
 		}
 
 		client := api.NewClient()
@@ -621,6 +658,53 @@ Examples:
 }
 
 func init() {
+	output.RegisterTable(api.OutputFolderItemsList{}, output.Table{
+		Items: "items",
+		Columns: []output.Column{
+			{Header: "TOKEN", Path: "token"},
+			{Header: "TYPE", Path: "type"},
+			{Header: "NAME", Path: "name"},
+			{Header: "URL", Path: "url"},
+		},
+	})
+	output.RegisterTable(api.OutputWikiChildren{}, output.Table{
+		Items: "children",
+		Columns: []output.Column{
+			{Header: "NODE_TOKEN", Path: "node_token"},
+			{Header: "TYPE", Path: "obj_type"},
+			{Header: "TITLE", Path: "title"},
+			{Header: "HAS_CHILD", Path: "has_child"},
+		},
+	})
+	output.RegisterTable(api.OutputDocumentComments{}, output.Table{
+		Items: "comments",
+		Columns: []output.Column{
+			{Header: "COMMENT_ID", Path: "comment_id"},
+			{Header: "USER_ID", Path: "user_id"},
+			{Header: "CREATED", Path: "create_time"},
+			{Header: "SOLVED", Path: "is_solved"},
+			{Header: "QUOTE", Path: "quote"},
+		},
+	})
+	output.RegisterTable(api.OutputWikiSearchResult{}, output.Table{
+		Items: "results",
+		Columns: []output.Column{
+			{Header: "NODE_ID", Path: "node_id"},
+			{Header: "TYPE", Path: "obj_type"},
+			{Header: "TITLE", Path: "title"},
+			{Header: "URL", Path: "url"},
+		},
+	})
+	output.RegisterTable(api.OutputDocSearchResult{}, output.Table{
+		Items: "results",
+		Columns: []output.Column{
+			{Header: "TOKEN", Path: "token"},
+			{Header: "TYPE", Path: "type"},
+			{Header: "TITLE", Path: "title"},
+			{Header: "OWNER_ID", Path: "owner_id"},
+		},
+	})
+
 	// Register subcommands
 	docCmd.AddCommand(docGetCmd)
 	docCmd.AddCommand(docBlocksCmd)
@@ -633,6 +717,9 @@ func init() {
 	docCmd.AddCommand(docWikiSearchCmd)
 	docCmd.AddCommand(docDownloadCmd)
 
+	// Flags for doc comments
+	docCommentsCmd.Flags().Bool("resolve-names", false, "Add commenter names and name @mentioned users")
+
 	// Flags for doc wiki-search
 	docWikiSearchCmd.Flags().String("space-id", "", "Filter to specific wiki space ID")
 	docWikiSearchCmd.Flags().String("node-id", "", "Search within a node and its children (requires --space-id)")
@@ -643,9 +730,9 @@ func init() {
 	docSearchCmd.Flags().StringSlice("type", nil, "Filter by doc type: doc, sheet, slide, bitable, mindnote, file (can be repeated)")
 
 	// Flags for doc image
-	docImageCmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+	addOutPathFlag(docImageCmd, "", "Output file path (default: stdout)")
 	docImageCmd.Flags().StringP("doc", "d", "", "Document ID (required for authentication)")
 
 	// Flags for doc download
-	docDownloadCmd.Flags().StringP("output", "o", "", "Output file path (default: original filename)")
+	addOutPathFlag(docDownloadCmd, "", "Output file path (required)")
 }
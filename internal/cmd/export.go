@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/config"
+	"github.com/yjwong/lark-cli/internal/ics"
+	"github.com/yjwong/lark-cli/internal/output"
+	timex "github.com/yjwong/lark-cli/internal/time"
+)
+
+var (
+	exportFrom string
+	exportTo   string
+)
+
+// icsUIDMarker tags the description of imported events with the source UID
+// so re-imports can be skipped and exports keep the original UID
+const icsUIDMarker = "[ics-uid: "
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export events as iCalendar (.ics)",
+	Long: `Export events from your primary calendar as iCalendar (RFC 5545) to stdout.
+
+Recurring events are exported once with their RRULE; modified instances are
+exported as overrides (RECURRENCE-ID). Attendees, reminders (VALARM) and
+meeting links (URL) are included.
+
+Examples:
+  lark cal export --from 2026-10-01 --to 2026-10-31 > events.ics
+  lark cal export --from 2026-10-20T00:00:00+08:00 --to 2026-10-21T00:00:00+08:00`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if exportFrom == "" || exportTo == "" {
+			output.Fatalf("VALIDATION_ERROR", "--from and --to are required")
+		}
+
+		// Parse timezone
+		tz := config.GetTimezone()
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			loc = time.Local
+			tz = loc.String()
+		}
+
+		startTime, err := timex.Parse(exportFrom, loc)
+		if err != nil {
+			output.Fatalf("PARSE_ERROR", "Failed to parse --from: %v", err)
+		}
+		endTime, err := timex.Parse(exportTo, loc)
+		if err != nil {
+			output.Fatalf("PARSE_ERROR", "Failed to parse --to: %v", err)
+		}
+		if !containsTimeSpec(exportFrom) {
+			startTime = timex.StartOfDay(startTime)
+		}
+		if !containsTimeSpec(exportTo) {
+			endTime = timex.EndOfDay(endTime)
+		}
+		if endTime.Before(startTime) {
+			output.Fatalf("VALIDATION_ERROR", "--to must be after --from")
+		}
+
+		client := api.NewClient()
+
+		cal, err := client.GetPrimaryCalendar()
+		if err != nil {
+			output.Fatal("CALENDAR_ERROR", err)
+		}
+
+		events, err := client.ListEvents(api.ListEventsOptions{
+			CalendarID: cal.CalendarID,
+			StartTime:  startTime,
+			EndTime:    endTime,
+		})
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+
+		root := ics.NewComponent("VCALENDAR")
+		root.Add("VERSION", "2.0", nil)
+		root.Add("PRODID", "-//lark-cli//Lark Calendar//EN", nil)
+		root.Add("CALSCALE", "GREGORIAN", nil)
+		root.Add("METHOD", "PUBLISH", nil)
+		root.AddText("X-WR-TIMEZONE", tz)
+
+		e := &icsExporter{
+			client:     client,
+			calendarID: cal.CalendarID,
+			loc:        loc,
+			emails:     make(map[string]string),
+			masters:    make(map[string]*api.Event),
+		}
+
+		for _, item := range events {
+			masterID := item.RecurringEventID
+			if masterID != "" {
+				master, err := e.master(masterID)
+				if err != nil {
+					output.Fatalf("API_ERROR", "Failed to fetch recurring event %s: %v", masterID, err)
+				}
+				if master != nil {
+					root.Components = append(root.Components, e.vevent(master, "", time.Time{}))
+				}
+				if !item.IsException {
+					continue
+				}
+			}
+
+			event, err := client.GetEvent(cal.CalendarID, item.EventID)
+			if err != nil {
+				output.Fatalf("API_ERROR", "Failed to fetch event %s: %v", item.EventID, err)
+			}
+
+			// Overrides share the series UID; Lark instance IDs end in the
+			// original start time (<series>_<unix seconds>)
+			uid := ""
+			var recurrenceID time.Time
+			if master := e.masters[masterID]; master != nil {
+				if ts, err := strconv.ParseInt(event.EventID[strings.LastIndex(event.EventID, "_")+1:], 10, 64); err == nil && ts > 0 {
+					uid = e.uid(master)
+					recurrenceID = time.Unix(ts, 0)
+				}
+			}
+			root.Components = append(root.Components, e.vevent(event, uid, recurrenceID))
+		}
+
+		if err := ics.Write(os.Stdout, root); err != nil {
+			output.Fatal("IO_ERROR", err)
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFrom, "from", "", "Start date/time (required)")
+	exportCmd.Flags().StringVar(&exportTo, "to", "", "End date/time (required)")
+}
+
+// icsExporter converts Lark events to VEVENTs, caching lookups
+type icsExporter struct {
+	client     *api.Client
+	calendarID string
+	loc        *time.Location
+	emails     map[string]string     // open_id -> email
+	masters    map[string]*api.Event // series ID -> master event
+}
+
+// master returns the series master the first time it is requested and
+// nil afterwards, so each series is exported once
+func (e *icsExporter) master(id string) (*api.Event, error) {
+	if _, ok := e.masters[id]; ok {
+		return nil, nil
+	}
+	master, err := e.client.GetEvent(e.calendarID, id)
+	if err != nil {
+		return nil, err
+	}
+	e.masters[id] = master
+	return master, nil
+}
+
+// uid returns the event's iCalendar UID: the UID it was imported with, or its event ID
+func (e *icsExporter) uid(event *api.Event) string {
+	if uid, _ := splitICSUID(event.Description); uid != "" {
+		return uid
+	}
+	return event.EventID
+}
+
+// email returns a user's email address, or "" if it can't be looked up
+func (e *icsExporter) email(openID string) string {
+	if email, ok := e.emails[openID]; ok {
+		return email
+	}
+	email := ""
+	if user, err := e.client.GetUser(openID, "open_id"); err == nil && user != nil {
+		email = user.Email
+	}
+	e.emails[openID] = email
+	return email
+}
+
+// vevent converts an event. uid and recurrenceID are set for overrides of a series.
+func (e *icsExporter) vevent(event *api.Event, uid string, recurrenceID time.Time) *ics.Component {
+	v := ics.NewComponent("VEVENT")
+
+	if uid == "" {
+		uid = e.uid(event)
+	}
+	v.AddText("UID", uid)
+	v.Add("DTSTAMP", ics.FormatUTC(time.Now()), nil)
+
+	allDay := event.StartTime != nil && event.StartTime.Date != ""
+	addICSTime(v, "DTSTART", event.StartTime)
+	addICSTime(v, "DTEND", event.EndTime)
+	if !recurrenceID.IsZero() {
+		if allDay {
+			v.Add("RECURRENCE-ID", ics.FormatDate(recurrenceID.In(e.loc)), map[string]string{"VALUE": "DATE"})
+		} else {
+			v.Add("RECURRENCE-ID", ics.FormatUTC(recurrenceID), nil)
+		}
+	}
+
+	v.AddText("SUMMARY", event.Summary)
+	if _, description := splitICSUID(event.Description); description != "" {
+		v.AddText("DESCRIPTION", description)
+	}
+	if event.Location != nil && event.Location.Name != "" {
+		location := event.Location.Name
+		if event.Location.Address != "" && event.Location.Address != location {
+			location += ", " + event.Location.Address
+		}
+		v.AddText("LOCATION", location)
+	}
+	if event.Vchat != nil && event.Vchat.MeetingURL != "" {
+		v.Add("URL", event.Vchat.MeetingURL, nil)
+	}
+	if event.Recurrence != "" && event.RecurringEventID == "" && recurrenceID.IsZero() {
+		v.Add("RRULE", strings.TrimPrefix(event.Recurrence, "RRULE:"), nil)
+	}
+
+	switch event.Visibility {
+	case "public":
+		v.Add("CLASS", "PUBLIC", nil)
+	case "private":
+		v.Add("CLASS", "PRIVATE", nil)
+	}
+	switch event.Status {
+	case "tentative":
+		v.Add("STATUS", "TENTATIVE", nil)
+	case "cancelled":
+		v.Add("STATUS", "CANCELLED", nil)
+	default:
+		v.Add("STATUS", "CONFIRMED", nil)
+	}
+
+	for _, a := range event.Attendees {
+		email := a.ThirdPartyEmail
+		if a.Type == "user" && a.UserID != "" {
+			email = e.email(a.UserID)
+		}
+		if email == "" {
+			// Chats, rooms and users without a visible email have no calendar address
+			continue
+		}
+
+		params := map[string]string{}
+		if a.DisplayName != "" {
+			params["CN"] = a.DisplayName
+		}
+		if a.IsOrganizer {
+			v.Add("ORGANIZER", "mailto:"+email, params)
+		}
+
+		params = map[string]string{
+			"CUTYPE":   "INDIVIDUAL",
+			"ROLE":     "REQ-PARTICIPANT",
+			"PARTSTAT": icsPartStat(a.RsvpStatus),
+		}
+		if a.DisplayName != "" {
+			params["CN"] = a.DisplayName
+		}
+		if a.IsOptional {
+			params["ROLE"] = "OPT-PARTICIPANT"
+		}
+		v.Add("ATTENDEE", "mailto:"+email, params)
+	}
+
+	for _, r := range event.Reminders {
+		alarm := ics.NewComponent("VALARM")
+		alarm.Add("ACTION", "DISPLAY", nil)
+		alarm.AddText("DESCRIPTION", event.Summary)
+		alarm.Add("TRIGGER", ics.FormatDuration(-time.Duration(r.Minutes)*time.Minute), nil)
+		v.Components = append(v.Components, alarm)
+	}
+
+	return v
+}
+
+// addICSTime adds a DATE or UTC DATE-TIME property
+func addICSTime(v *ics.Component, name string, info *api.TimeInfo) {
+	if info == nil {
+		return
+	}
+	if info.Date != "" {
+		v.Add(name, strings.ReplaceAll(info.Date, "-", ""), map[string]string{"VALUE": "DATE"})
+		return
+	}
+	if t, ok := eventTime(info); ok {
+		v.Add(name, ics.FormatUTC(t), nil)
+	}
+}
+
+// icsPartStat maps a Lark RSVP status to an iCalendar PARTSTAT
+func icsPartStat(rsvp string) string {
+	switch rsvp {
+	case "accept":
+		return "ACCEPTED"
+	case "decline":
+		return "DECLINED"
+	case "tentative":
+		return "TENTATIVE"
+	}
+	return "NEEDS-ACTION"
+}
+
+// splitICSUID extracts the import marker from a description and returns
+// the UID and the description without the marker
+func splitICSUID(description string) (string, string) {
+	i := strings.LastIndex(description, icsUIDMarker)
+	if i < 0 {
+		return "", description
+	}
+	rest := description[i+len(icsUIDMarker):]
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return "", description
+	}
+	return rest[:end], strings.TrimRight(description[:i]+rest[end+1:], "\n ")
+}
+
+// withICSUID appends the import marker to a description
+func withICSUID(description, uid string) string {
+	marker := fmt.Sprintf("%s%s]", icsUIDMarker, uid)
+	if description == "" {
+		return marker
+	}
+	return description + "\n\n" + marker
+}
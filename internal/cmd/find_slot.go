@@ -0,0 +1,516 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/config"
+	"github.com/yjwong/lark-cli/internal/output"
+	"github.com/yjwong/lark-cli/internal/slots"
+	timex "github.com/yjwong/lark-cli/internal/time"
+)
+
+var (
+	findSlotWith            string
+	findSlotOptional        string
+	findSlotDuration        string
+	findSlotWithin          string
+	findSlotWorkHours       string
+	findSlotTZAware         bool
+	findSlotBuffer          int
+	findSlotStep            string
+	findSlotIncludeWeekends bool
+	findSlotMaxConflicts    int
+	findSlotExcludeSelf     bool
+	findSlotLimit           int
+	findSlotBook            bool
+	findSlotSummary         string
+	findSlotDescription     string
+	findSlotLocation        string
+	findSlotNoNotify        bool
+)
+
+// commonFreeTimeMaxUsers is the most users the common free time API accepts per request
+const commonFreeTimeMaxUsers = 10
+
+// lookupMaxEmails is the most emails the user lookup API accepts per request
+const lookupMaxEmails = 50
+
+var findSlotCmd = &cobra.Command{
+	Use:   "find-slot",
+	Short: "Propose meeting times for a group",
+	Long: `Find and rank meeting slots when the given people are free.
+
+Each participant's free/busy is merged and candidate slots inside the working
+hours are ranked by fewest busy required attendees, then by most optional
+attendees free, then earliest. You are included as a required attendee unless
+--exclude-self is set.
+
+With --tz-aware, slots are also limited to every required attendee's own
+working hours (from their Lark settings, so each in their own timezone), using
+the common free time API in batches of 10 users. --work-hours then defaults to
+no extra limit.
+
+Participants are emails or open_ids (ou_xxx).
+
+Examples:
+  lark cal find-slot --with a@x.com,b@x.com --duration 45m --within 2026-10-20..2026-10-24
+  lark cal find-slot --with a@x.com --optional c@x.com --duration 30m --within 2026-10-20..2026-10-21 --work-hours 10:00-17:00 --buffer 10
+  lark cal find-slot --with a@x.com,b@x.com --duration 1h --within 2026-10-20..2026-10-24 --tz-aware
+  lark cal find-slot --with a@x.com --duration 30m --within 2026-10-20..2026-10-24 --book --summary "Sync"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if findSlotWith == "" && findSlotExcludeSelf {
+			output.Fatalf("VALIDATION_ERROR", "--with is required with --exclude-self")
+		}
+		if findSlotDuration == "" {
+			output.Fatalf("VALIDATION_ERROR", "--duration is required")
+		}
+		if findSlotWithin == "" {
+			output.Fatalf("VALIDATION_ERROR", "--within is required")
+		}
+		if findSlotBook && findSlotSummary == "" {
+			output.Fatalf("VALIDATION_ERROR", "--summary is required with --book")
+		}
+		if findSlotBuffer < 0 || findSlotMaxConflicts < 0 {
+			output.Fatalf("VALIDATION_ERROR", "--buffer and --max-conflicts must not be negative")
+		}
+
+		duration, err := timex.ParseDuration(findSlotDuration)
+		if err != nil {
+			output.Fatalf("PARSE_ERROR", "Failed to parse --duration: %v", err)
+		}
+		step, err := timex.ParseDuration(findSlotStep)
+		if err != nil || step <= 0 {
+			output.Fatalf("PARSE_ERROR", "Failed to parse --step: %s", findSlotStep)
+		}
+
+		// Parse timezone
+		tz := config.GetTimezone()
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			loc = time.Local
+			tz = loc.String()
+		}
+
+		startTime, endTime, err := parseWithin(findSlotWithin, loc)
+		if err != nil {
+			output.Fatalf("PARSE_ERROR", "Failed to parse --within: %v", err)
+		}
+		if now := time.Now().In(loc); startTime.Before(now) {
+			startTime = now
+		}
+		if !endTime.After(startTime) {
+			output.Fatalf("VALIDATION_ERROR", "--within must end in the future")
+		}
+		if endTime.Sub(startTime) > 90*24*time.Hour {
+			output.Fatalf("VALIDATION_ERROR", "--within cannot exceed 90 days")
+		}
+		if findSlotTZAware && endTime.Sub(startTime) > 14*24*time.Hour {
+			output.Fatalf("VALIDATION_ERROR", "--within cannot exceed 14 days with --tz-aware")
+		}
+
+		// In tz-aware mode each user's own working hours apply instead
+		workHours := findSlotWorkHours
+		if findSlotTZAware && !cmd.Flags().Changed("work-hours") {
+			workHours = ""
+		}
+		workStart, workEnd, err := parseWorkHours(workHours)
+		if err != nil {
+			output.Fatalf("PARSE_ERROR", "Failed to parse --work-hours: %v", err)
+		}
+
+		client := api.NewClient()
+
+		required := splitList(findSlotWith)
+		optional := splitList(findSlotOptional)
+
+		ids, err := resolveParticipants(client, append(append([]string{}, required...), optional...))
+		if err != nil {
+			output.Fatal("USER_ERROR", err)
+		}
+
+		var selfID string
+		if !findSlotExcludeSelf {
+			currentUser, err := client.GetCurrentUser()
+			if err != nil {
+				output.Fatalf("USER_ERROR", "Failed to get current user: %v", err)
+			}
+			selfID = currentUser.OpenID
+			label := currentUser.Email
+			if label == "" {
+				label = selfID
+			}
+			ids[label] = selfID
+			required = append([]string{label}, required...)
+		}
+
+		// Merge each participant's busy periods
+		attendees := make([]slots.Attendee, 0, len(required)+len(optional))
+		for i, label := range append(append([]string{}, required...), optional...) {
+			periods, err := client.GetFreebusy(api.FreebusyOptions{
+				StartTime: startTime,
+				EndTime:   endTime,
+				UserID:    ids[label],
+			})
+			if err != nil {
+				output.Fatalf("API_ERROR", "Failed to get free/busy for %s: %v", label, err)
+			}
+			busy, err := parseBusyPeriods(periods)
+			if err != nil {
+				output.Fatal("API_ERROR", err)
+			}
+			attendees = append(attendees, slots.Attendee{
+				ID:       label,
+				Optional: i >= len(required),
+				Busy:     busy,
+			})
+		}
+
+		opts := slots.Options{
+			From:            startTime,
+			To:              endTime,
+			Duration:        duration,
+			Step:            step,
+			WorkStart:       workStart,
+			WorkEnd:         workEnd,
+			Location:        loc,
+			IncludeWeekends: findSlotIncludeWeekends,
+			BufferMinutes:   findSlotBuffer,
+			MaxConflicts:    findSlotMaxConflicts,
+		}
+
+		if findSlotTZAware {
+			requiredIDs := make([]string, len(required))
+			for i, label := range required {
+				requiredIDs[i] = ids[label]
+			}
+			allowed, err := commonWorkingFreeTime(client, requiredIDs, startTime, endTime, duration, tz, loc)
+			if err != nil {
+				output.Fatal("API_ERROR", err)
+			}
+			opts.Allowed = allowed
+		}
+
+		found := slots.Find(attendees, opts)
+		if findSlotLimit > 0 && len(found) > findSlotLimit {
+			found = found[:findSlotLimit]
+		}
+
+		result := api.OutputFindSlot{
+			Query: api.OutputFindSlotQuery{
+				Required:        required,
+				Optional:        optional,
+				From:            startTime.Format(time.RFC3339),
+				To:              endTime.Format(time.RFC3339),
+				DurationMinutes: int(duration.Minutes()),
+				WorkHours:       workHours,
+				BufferMinutes:   findSlotBuffer,
+				Timezone:        tz,
+				TZAware:         findSlotTZAware,
+			},
+			Slots: make([]api.OutputSlot, len(found)),
+		}
+		for i, s := range found {
+			result.Slots[i] = api.OutputSlot{
+				Rank:                i + 1,
+				Start:               s.Start.Format(time.RFC3339),
+				End:                 s.End.Format(time.RFC3339),
+				Conflicts:           s.Conflicts,
+				OptionalAvailable:   s.Available,
+				OptionalUnavailable: s.Unavailable,
+			}
+		}
+
+		if findSlotBook {
+			if len(found) == 0 {
+				output.Fatalf("NO_SLOT", "No slot found to book")
+			}
+			event, err := bookSlot(client, found[0], tz, selfID, required, optional, ids)
+			if err != nil {
+				output.Fatal("API_ERROR", err)
+			}
+			booked := api.ConvertToOutputEvent(*event)
+			result.Booked = &booked
+		}
+
+		output.JSON(result)
+	},
+}
+
+func init() {
+	output.RegisterTable(api.OutputFindSlot{}, output.Table{
+		Items: "slots",
+		Columns: []output.Column{
+			{Header: "RANK", Path: "rank"},
+			{Header: "START", Path: "start"},
+			{Header: "END", Path: "end"},
+			{Header: "CONFLICTS", Path: "conflicts"},
+			{Header: "OPTIONAL_FREE", Path: "optional_available"},
+		},
+	})
+
+	findSlotCmd.Flags().StringVar(&findSlotWith, "with", "", "Comma-separated required attendees (emails or open_ids)")
+	findSlotCmd.Flags().StringVar(&findSlotOptional, "optional", "", "Comma-separated optional attendees (emails or open_ids)")
+	findSlotCmd.Flags().StringVar(&findSlotDuration, "duration", "", "Meeting length (required, e.g., 30m, 1h)")
+	findSlotCmd.Flags().StringVar(&findSlotWithin, "within", "", "Date range to search, FROM..TO (required, e.g., 2026-10-20..2026-10-24)")
+	findSlotCmd.Flags().StringVar(&findSlotWorkHours, "work-hours", "09:00-18:00", "Daily window for slots, HH:MM-HH:MM (empty for any time)")
+	findSlotCmd.Flags().BoolVar(&findSlotTZAware, "tz-aware", false, "Respect each required attendee's own working hours and timezone")
+	findSlotCmd.Flags().IntVar(&findSlotBuffer, "buffer", 0, "Minutes of free time required before and after other events")
+	findSlotCmd.Flags().StringVar(&findSlotStep, "step", "30m", "Spacing between candidate start times")
+	findSlotCmd.Flags().BoolVar(&findSlotIncludeWeekends, "include-weekends", false, "Include Saturdays and Sundays")
+	findSlotCmd.Flags().IntVar(&findSlotMaxConflicts, "max-conflicts", 0, "Maximum busy required attendees in a proposed slot")
+	findSlotCmd.Flags().BoolVar(&findSlotExcludeSelf, "exclude-self", false, "Don't include yourself as a required attendee")
+	findSlotCmd.Flags().IntVar(&findSlotLimit, "limit", 5, "Maximum slots to return (0 for all)")
+	findSlotCmd.Flags().BoolVar(&findSlotBook, "book", false, "Create an event in the top-ranked slot")
+	findSlotCmd.Flags().StringVar(&findSlotSummary, "summary", "", "Event title (required with --book)")
+	findSlotCmd.Flags().StringVar(&findSlotDescription, "description", "", "Event description (with --book)")
+	findSlotCmd.Flags().StringVar(&findSlotLocation, "location", "", "Event location (with --book)")
+	findSlotCmd.Flags().BoolVar(&findSlotNoNotify, "no-notify", false, "Don't send notifications (with --book)")
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseWithin parses a FROM..TO range. Bare dates cover whole days.
+func parseWithin(s string, loc *time.Location) (time.Time, time.Time, error) {
+	fromStr, toStr, ok := strings.Cut(s, "..")
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("expected FROM..TO, got %q", s)
+	}
+	from, err := timex.Parse(strings.TrimSpace(fromStr), loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := timex.Parse(strings.TrimSpace(toStr), loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !containsTimeSpec(fromStr) {
+		from = timex.StartOfDay(from)
+	}
+	if !containsTimeSpec(toStr) {
+		to = timex.EndOfDay(to)
+	}
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("TO must be after FROM")
+	}
+	return from, to, nil
+}
+
+// parseWorkHours parses an HH:MM-HH:MM window into offsets from midnight.
+// An empty value returns a zero window, meaning any time of day.
+func parseWorkHours(s string) (time.Duration, time.Duration, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	startStr, endStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected HH:MM-HH:MM, got %q", s)
+	}
+	parse := func(v string) (time.Duration, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %q (use HH:MM)", v)
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+	}
+	start, err := parse(startStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parse(endStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end <= start {
+		return 0, 0, fmt.Errorf("end of work hours must be after the start")
+	}
+	return start, end, nil
+}
+
+// resolveParticipants maps each participant (email or open_id) to an open_id
+func resolveParticipants(client *api.Client, participants []string) (map[string]string, error) {
+	ids := make(map[string]string)
+	var emails []string
+	for _, p := range participants {
+		switch {
+		case strings.Contains(p, "@"):
+			emails = append(emails, p)
+		case strings.HasPrefix(p, "ou_"):
+			ids[p] = p
+		default:
+			return nil, fmt.Errorf("unknown participant format: %s (use an email or open_id)", p)
+		}
+	}
+
+	for len(emails) > 0 {
+		batch := emails
+		if len(batch) > lookupMaxEmails {
+			batch = batch[:lookupMaxEmails]
+		}
+		emails = emails[len(batch):]
+
+		users, err := client.LookupUsers(api.UserLookupOptions{Emails: batch})
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up users: %w", err)
+		}
+		found := make(map[string]string)
+		for _, u := range users {
+			if u.UserID != "" {
+				found[strings.ToLower(u.Email)] = u.UserID
+			}
+		}
+		for _, email := range batch {
+			id := found[strings.ToLower(email)]
+			if id == "" {
+				return nil, fmt.Errorf("no Lark user found for %s", email)
+			}
+			ids[email] = id
+		}
+	}
+
+	return ids, nil
+}
+
+// parseBusyPeriods converts freebusy API periods to intervals
+func parseBusyPeriods(periods []api.FreebusyPeriod) ([]slots.Interval, error) {
+	busy := make([]slots.Interval, 0, len(periods))
+	for _, p := range periods {
+		start, err := time.Parse(time.RFC3339, p.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid busy period start %q: %w", p.StartTime, err)
+		}
+		end, err := time.Parse(time.RFC3339, p.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid busy period end %q: %w", p.EndTime, err)
+		}
+		busy = append(busy, slots.Interval{Start: start, End: end})
+	}
+	return busy, nil
+}
+
+// commonWorkingFreeTime returns the periods when all users are free within
+// their own working hours. The API takes at most 10 users, so larger groups
+// are queried in batches and the results intersected.
+func commonWorkingFreeTime(client *api.Client, userIDs []string, start, end time.Time, minLength time.Duration, tz string, loc *time.Location) ([]slots.Interval, error) {
+	const apiTimeFormat = "2006-01-02 15:04:05"
+
+	var allowed []slots.Interval
+	for i := 0; i < len(userIDs); i += commonFreeTimeMaxUsers {
+		batch := userIDs[i:min(i+commonFreeTimeMaxUsers, len(userIDs))]
+
+		free, err := client.GetCommonFreeTime(api.CommonFreeTimeOptions{
+			UserIDs:        batch,
+			StartTime:      start,
+			EndTime:        end,
+			Timezone:       tz,
+			OnlyBusy:       true,
+			EnableWorkHour: true,
+			MinTimeLength:  int(minLength.Seconds()),
+			Limit:          100,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		periods := []slots.Interval{}
+		for _, f := range free {
+			s, err := time.ParseInLocation(apiTimeFormat, f.StartTime, loc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid free time start %q: %w", f.StartTime, err)
+			}
+			e, err := time.ParseInLocation(apiTimeFormat, f.EndTime, loc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid free time end %q: %w", f.EndTime, err)
+			}
+			periods = append(periods, slots.Interval{Start: s, End: e})
+		}
+
+		if allowed == nil {
+			allowed = periods
+		} else {
+			allowed = slots.Intersect(allowed, periods)
+		}
+	}
+
+	return allowed, nil
+}
+
+// bookSlot creates an event in the slot and invites the participants
+func bookSlot(client *api.Client, slot slots.Slot, tz, selfID string, required, optional []string, ids map[string]string) (*api.Event, error) {
+	cal, err := client.GetPrimaryCalendar()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &api.CreateEventRequest{
+		Summary:     findSlotSummary,
+		Description: findSlotDescription,
+		StartTime: &api.TimeInfo{
+			Timestamp: strconv.FormatInt(slot.Start.Unix(), 10),
+			Timezone:  tz,
+		},
+		EndTime: &api.TimeInfo{
+			Timestamp: strconv.FormatInt(slot.End.Unix(), 10),
+			Timezone:  tz,
+		},
+		AttendeeAbility: "can_invite_others",
+	}
+	if findSlotLocation != "" {
+		req.Location = &api.Location{Name: findSlotLocation}
+	}
+	if defaultReminder := config.Get().Defaults.ReminderMinutes; defaultReminder > 0 {
+		req.Reminders = []api.Reminder{{Minutes: defaultReminder}}
+	}
+	if findSlotNoNotify {
+		noNotify := false
+		req.NeedNotify = &noNotify
+	}
+
+	event, err := client.CreateEvent(cal.CalendarID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var attendees []api.Attendee
+	seen := make(map[string]bool)
+	add := func(label string, isOptional bool) {
+		id := ids[label]
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		attendees = append(attendees, api.Attendee{Type: "user", UserID: id, IsOptional: isOptional})
+	}
+	for _, label := range required {
+		add(label, false)
+	}
+	for _, label := range optional {
+		add(label, true)
+	}
+	if selfID != "" && !seen[selfID] {
+		attendees = append(attendees, api.Attendee{Type: "user", UserID: selfID})
+	}
+
+	if len(attendees) > 0 {
+		added, err := client.CreateEventAttendees(cal.CalendarID, event.EventID, attendees, !findSlotNoNotify)
+		if err != nil {
+			return nil, fmt.Errorf("event %s created but failed to add attendees: %w", event.EventID, err)
+		}
+		event.Attendees = added
+	}
+
+	return event, nil
+}
@@ -119,6 +119,14 @@ Examples:
 }
 
 func init() {
+	output.RegisterTable(api.OutputFreebusy{}, output.Table{
+		Items: "busy_periods",
+		Columns: []output.Column{
+			{Header: "START", Path: "start"},
+			{Header: "END", Path: "end"},
+		},
+	})
+
 	freebusyCmd.Flags().StringVar(&freebusyFrom, "from", "", "Start time (required, ISO 8601)")
 	freebusyCmd.Flags().StringVar(&freebusyTo, "to", "", "End time (required, ISO 8601)")
 	freebusyCmd.Flags().StringVar(&freebusyUser, "user", "", "User open_id to check (default: self)")
@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/config"
+	"github.com/yjwong/lark-cli/internal/ics"
+	"github.com/yjwong/lark-cli/internal/output"
+	timex "github.com/yjwong/lark-cli/internal/time"
+)
+
+var (
+	importDryRun    bool
+	importAttendees bool
+	importNoNotify  bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file.ics>",
+	Short: "Import events from an iCalendar (.ics) file",
+	Long: `Create events in your primary calendar from an iCalendar (RFC 5545) file.
+Use - to read from stdin.
+
+Each event's UID is recorded at the end of its description, so importing the
+same file again skips events that already exist. Recurring events keep their
+RRULE; modified instances (RECURRENCE-ID) and cancelled events are skipped.
+
+Attendees are not invited unless --attendees is set, in which case they are
+added as external guests by email.
+
+Examples:
+  lark cal import invite.ics
+  lark cal import events.ics --dry-run
+  lark cal import invite.ics --attendees --no-notify
+  cat invite.ics | lark cal import -`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				output.Fatal("FILE_ERROR", err)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		root, err := ics.Parse(r)
+		if err != nil {
+			output.Fatalf("PARSE_ERROR", "Failed to parse iCalendar file: %v", err)
+		}
+
+		// Parse timezone
+		tz := config.GetTimezone()
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			loc = time.Local
+			tz = loc.String()
+		}
+
+		result := api.OutputICSImport{
+			Imported: []api.OutputICSImportItem{},
+			Skipped:  []api.OutputICSImportItem{},
+			DryRun:   importDryRun,
+		}
+
+		// Convert every VEVENT first so the dedupe lookup covers the whole file
+		type pending struct {
+			item      api.OutputICSImportItem
+			req       *api.CreateEventRequest
+			attendees []api.Attendee
+		}
+		var events []pending
+		var rangeStart, rangeEnd time.Time
+		for _, v := range root.Children("VEVENT") {
+			item := api.OutputICSImportItem{
+				UID:     v.Text("UID"),
+				Summary: v.Text("SUMMARY"),
+			}
+
+			if v.Get("RECURRENCE-ID") != nil {
+				item.Reason = "modified instance of a recurring event (not supported)"
+				result.Skipped = append(result.Skipped, item)
+				continue
+			}
+			if strings.EqualFold(v.Text("STATUS"), "CANCELLED") {
+				item.Reason = "cancelled"
+				result.Skipped = append(result.Skipped, item)
+				continue
+			}
+
+			req, start, err := icsToEventRequest(v, tz, loc)
+			if err != nil {
+				item.Reason = err.Error()
+				result.Skipped = append(result.Skipped, item)
+				continue
+			}
+			item.Start = start.In(loc).Format(time.RFC3339)
+			if req.StartTime.Date != "" {
+				item.Start = req.StartTime.Date
+			}
+
+			var attendees []api.Attendee
+			if importAttendees {
+				attendees = icsAttendees(v)
+			}
+
+			if rangeStart.IsZero() || start.Before(rangeStart) {
+				rangeStart = start
+			}
+			if start.After(rangeEnd) {
+				rangeEnd = start
+			}
+			events = append(events, pending{item: item, req: req, attendees: attendees})
+		}
+
+		if len(events) == 0 {
+			output.JSON(result)
+			return
+		}
+
+		client := api.NewClient()
+
+		cal, err := client.GetPrimaryCalendar()
+		if err != nil {
+			output.Fatal("CALENDAR_ERROR", err)
+		}
+
+		// Find UIDs imported before
+		existing, err := client.ListEvents(api.ListEventsOptions{
+			CalendarID: cal.CalendarID,
+			StartTime:  rangeStart.Add(-24 * time.Hour),
+			EndTime:    rangeEnd.Add(48 * time.Hour),
+		})
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+		imported := make(map[string]string)
+		for _, e := range existing {
+			if uid, _ := splitICSUID(e.Description); uid != "" {
+				imported[uid] = e.EventID
+			}
+		}
+
+		for _, p := range events {
+			if p.item.UID != "" {
+				if eventID, ok := imported[p.item.UID]; ok {
+					p.item.EventID = eventID
+					p.item.Reason = "already imported"
+					result.Skipped = append(result.Skipped, p.item)
+					continue
+				}
+				p.req.Description = withICSUID(p.req.Description, p.item.UID)
+			}
+
+			if importDryRun {
+				result.Imported = append(result.Imported, p.item)
+				continue
+			}
+
+			event, err := client.CreateEvent(cal.CalendarID, p.req)
+			if err != nil {
+				output.Fatalf("API_ERROR", "Failed to create %q: %v", p.item.Summary, err)
+			}
+			p.item.EventID = event.EventID
+
+			if len(p.attendees) > 0 {
+				if _, err := client.CreateEventAttendees(cal.CalendarID, event.EventID, p.attendees, !importNoNotify); err != nil {
+					output.Fatalf("ATTENDEE_ERROR", "Event %s created but failed to add attendees: %v", event.EventID, err)
+				}
+			}
+
+			if p.item.UID != "" {
+				imported[p.item.UID] = event.EventID
+			}
+			result.Imported = append(result.Imported, p.item)
+		}
+
+		output.JSON(result)
+	},
+}
+
+func init() {
+	output.RegisterTable(api.OutputICSImport{}, output.Table{
+		Items: "imported",
+		Columns: []output.Column{
+			{Header: "EVENT_ID", Path: "event_id"},
+			{Header: "START", Path: "start"},
+			{Header: "SUMMARY", Path: "summary"},
+			{Header: "UID", Path: "uid"},
+		},
+	})
+
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would be imported without creating events")
+	importCmd.Flags().BoolVar(&importAttendees, "attendees", false, "Invite the file's attendees as external guests")
+	importCmd.Flags().BoolVar(&importNoNotify, "no-notify", false, "Don't send notifications")
+}
+
+// icsToEventRequest converts a VEVENT to a create request and returns its start time
+func icsToEventRequest(v *ics.Component, tz string, loc *time.Location) (*api.CreateEventRequest, time.Time, error) {
+	dtstart := v.Get("DTSTART")
+	if dtstart == nil {
+		return nil, time.Time{}, fmt.Errorf("missing DTSTART")
+	}
+	start, allDay, err := dtstart.Time(loc)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid DTSTART: %v", err)
+	}
+
+	var end time.Time
+	if dtend := v.Get("DTEND"); dtend != nil {
+		end, _, err = dtend.Time(loc)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("invalid DTEND: %v", err)
+		}
+	} else if duration := v.Get("DURATION"); duration != nil {
+		d, err := ics.ParseDuration(duration.Value)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		end = start.Add(d)
+	} else if allDay {
+		end = start.AddDate(0, 0, 1)
+	} else {
+		end = start
+	}
+
+	req := &api.CreateEventRequest{
+		Summary:     v.Text("SUMMARY"),
+		Description: v.Text("DESCRIPTION"),
+	}
+	if importNoNotify {
+		noNotify := false
+		req.NeedNotify = &noNotify
+	}
+
+	if allDay {
+		req.StartTime = &api.TimeInfo{Date: start.Format("2006-01-02")}
+		req.EndTime = &api.TimeInfo{Date: end.Format("2006-01-02")}
+	} else {
+		eventTZ := tz
+		if tzid := dtstart.Param("TZID"); tzid != "" {
+			eventTZ = start.Location().String()
+		}
+		req.StartTime = &api.TimeInfo{Timestamp: strconv.FormatInt(start.Unix(), 10), Timezone: eventTZ}
+		req.EndTime = &api.TimeInfo{Timestamp: strconv.FormatInt(end.Unix(), 10), Timezone: eventTZ}
+	}
+
+	if location := v.Text("LOCATION"); location != "" {
+		req.Location = &api.Location{Name: location}
+	}
+	if url := v.Get("URL"); url != nil && url.Value != "" {
+		req.Vchat = &api.Vchat{VcType: "third_party", MeetingURL: url.Value}
+	}
+
+	if rrule := v.Get("RRULE"); rrule != nil {
+		r, err := timex.ParseRRule(rrule.Value, start.Location())
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("unsupported RRULE: %v", err)
+		}
+		req.Recurrence = r.String()
+	}
+
+	switch strings.ToUpper(v.Text("CLASS")) {
+	case "PUBLIC":
+		req.Visibility = "public"
+	case "PRIVATE", "CONFIDENTIAL":
+		req.Visibility = "private"
+	}
+
+	for _, alarm := range v.Children("VALARM") {
+		trigger := alarm.Get("TRIGGER")
+		if trigger == nil || trigger.Param("VALUE") == "DATE-TIME" || strings.EqualFold(trigger.Param("RELATED"), "END") {
+			continue
+		}
+		d, err := ics.ParseDuration(trigger.Value)
+		if err != nil {
+			continue
+		}
+		req.Reminders = append(req.Reminders, api.Reminder{Minutes: int(-d / time.Minute)})
+	}
+
+	return req, start, nil
+}
+
+// icsAttendees converts ATTENDEE properties with an email to external guests
+func icsAttendees(v *ics.Component) []api.Attendee {
+	var attendees []api.Attendee
+	for _, p := range v.GetAll("ATTENDEE") {
+		email := p.Value
+		if len(email) >= 7 && strings.EqualFold(email[:7], "mailto:") {
+			email = email[7:]
+		}
+		if !strings.Contains(email, "@") {
+			continue
+		}
+		cutype := strings.ToUpper(p.Param("CUTYPE"))
+		if cutype == "ROOM" || cutype == "RESOURCE" {
+			continue
+		}
+		attendees = append(attendees, api.Attendee{
+			Type:            "third_party",
+			ThirdPartyEmail: email,
+			IsOptional:      strings.EqualFold(p.Param("ROLE"), "OPT-PARTICIPANT"),
+		})
+	}
+	return attendees
+}
@@ -195,6 +195,18 @@ Examples:
 }
 
 func init() {
+	output.RegisterTable(api.OutputEventList{}, output.Table{
+		Items: "events",
+		Columns: []output.Column{
+			{Header: "ID", Path: "id"},
+			{Header: "START", Path: "start"},
+			{Header: "END", Path: "end"},
+			{Header: "SUMMARY", Path: "summary"},
+			{Header: "LOCATION", Path: "location"},
+			{Header: "RSVP", Path: "rsvp_status"},
+		},
+	})
+
 	listCmd.Flags().StringVar(&listFrom, "from", "", "Start date (ISO 8601)")
 	listCmd.Flags().StringVar(&listTo, "to", "", "End date (ISO 8601)")
 	listCmd.Flags().BoolVar(&listToday, "today", false, "List today's events (default)")
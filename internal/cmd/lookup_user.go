@@ -65,6 +65,15 @@ Examples:
 }
 
 func init() {
+	output.RegisterTable(api.OutputUserLookup{}, output.Table{
+		Items: "users",
+		Columns: []output.Column{
+			{Header: "USER_ID", Path: "user_id"},
+			{Header: "EMAIL", Path: "email"},
+			{Header: "MOBILE", Path: "mobile"},
+		},
+	})
+
 	lookupUserCmd.Flags().StringArrayVar(&lookupUserEmails, "email", nil, "Email address to look up (can be repeated)")
 	lookupUserCmd.Flags().StringArrayVar(&lookupUserMobiles, "mobile", nil, "Mobile number to look up (can be repeated)")
 }
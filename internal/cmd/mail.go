@@ -16,8 +16,8 @@ import (
 
 var mailCmd = &cobra.Command{
 	Use:   "mail",
-	Short: "Email commands (IMAP)",
-	Long:  "Read and search emails via IMAP with local caching",
+	Short: "Email commands (IMAP/SMTP)",
+	Long:  "Read and search emails via IMAP with local caching, and send them via SMTP",
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		validateScopeGroup("mail")
 	},
@@ -27,8 +27,8 @@ var mailCmd = &cobra.Command{
 
 var mailSetupCmd = &cobra.Command{
 	Use:   "setup",
-	Short: "Configure IMAP credentials",
-	Long: `Configure IMAP credentials for accessing Lark Mail.
+	Short: "Configure IMAP and SMTP credentials",
+	Long: `Configure IMAP and SMTP credentials for accessing Lark Mail.
 
 See: https://www.larksuite.com/hc/en-US/articles/378111206512-log-in-to-lark-mail-through-a-third-party-email-client
 
@@ -41,6 +41,7 @@ To get your IMAP credentials:
 This command will prompt for:
 - IMAP server host (e.g., imap.larksuite.com)
 - Port (usually 993 for SSL)
+- SMTP server host, port and security (ssl on 465, or starttls on 587)
 - Username (your Lark email address)
 - Password (dedicated password from step 4)`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -91,6 +92,42 @@ This command will prompt for:
 		sslStr = strings.TrimSpace(strings.ToLower(sslStr))
 		creds.UseSSL = sslStr != "n" && sslStr != "no"
 
+		// SMTP
+		defaultSMTPHost, _, _ := creds.SMTPServer()
+		fmt.Printf("SMTP Host [%s]: ", defaultSMTPHost)
+		smtpHost, _ := reader.ReadString('\n')
+		smtpHost = strings.TrimSpace(smtpHost)
+		if smtpHost == "" {
+			smtpHost = defaultSMTPHost
+		}
+		creds.SMTPHost = smtpHost
+
+		fmt.Print("SMTP Security (ssl, starttls or none) [ssl]: ")
+		security, _ := reader.ReadString('\n')
+		security = strings.TrimSpace(strings.ToLower(security))
+		switch security {
+		case "":
+			security = mail.SMTPSecuritySSL
+		case mail.SMTPSecuritySSL, mail.SMTPSecuritySTARTTLS, mail.SMTPSecurityNone:
+		default:
+			output.Fatalf("VALIDATION_ERROR", "invalid SMTP security: %s (must be ssl, starttls or none)", security)
+		}
+		creds.SMTPSecurity = security
+
+		_, defaultSMTPPort, _ := creds.SMTPServer()
+		fmt.Printf("SMTP Port [%d]: ", defaultSMTPPort)
+		smtpPortStr, _ := reader.ReadString('\n')
+		smtpPortStr = strings.TrimSpace(smtpPortStr)
+		if smtpPortStr == "" {
+			creds.SMTPPort = defaultSMTPPort
+		} else {
+			port, err := strconv.Atoi(smtpPortStr)
+			if err != nil {
+				output.Fatalf("VALIDATION_ERROR", "invalid port: %s", smtpPortStr)
+			}
+			creds.SMTPPort = port
+		}
+
 		// Username
 		fmt.Print("Username (email address): ")
 		username, _ := reader.ReadString('\n')
@@ -130,7 +167,7 @@ This command will prompt for:
 
 		output.JSON(map[string]interface{}{
 			"success": true,
-			"message": "IMAP credentials configured successfully",
+			"message": "IMAP and SMTP credentials configured successfully",
 		})
 	},
 }
@@ -152,6 +189,13 @@ var mailStatusCmd = &cobra.Command{
 				result["port"] = creds.Port
 				result["username"] = creds.Username
 				result["use_ssl"] = creds.UseSSL
+
+				smtpHost, smtpPort, smtpSecurity := creds.SMTPServer()
+				result["smtp"] = map[string]interface{}{
+					"host":     smtpHost,
+					"port":     smtpPort,
+					"security": smtpSecurity,
+				}
 			}
 
 			// Test connection
@@ -194,6 +238,13 @@ var mailStatusCmd = &cobra.Command{
 var mailListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List mailboxes/folders",
+	Long: `List mailboxes with their message and unread counts.
+
+Folders that only hold other folders are marked "noselect" and have no
+counts.
+
+Examples:
+  lark mail list`,
 	Run: func(cmd *cobra.Command, args []string) {
 		client, err := mail.Connect()
 		if err != nil {
@@ -201,7 +252,7 @@ var mailListCmd = &cobra.Command{
 		}
 		defer client.Close()
 
-		mailboxes, err := client.ListMailboxes()
+		mailboxes, err := client.MailboxStatuses()
 		if err != nil {
 			output.Fatal("IMAP_ERROR", err)
 		}
@@ -216,8 +267,13 @@ var mailListCmd = &cobra.Command{
 // --- mail sync ---
 
 var (
-	mailSyncMailbox string
-	mailSyncWorkers int
+	mailSyncMailbox     string
+	mailSyncWorkers     int
+	mailSyncBodies      bool
+	mailSyncMaxBodySize int
+	mailSyncAll         bool
+	mailSyncInclude     []string
+	mailSyncExclude     []string
 )
 
 var mailSyncCmd = &cobra.Command{
@@ -226,16 +282,54 @@ var mailSyncCmd = &cobra.Command{
 	Long: `Fetch new emails from the IMAP server and store metadata in the local cache.
 
 On first sync, fetches all email headers using parallel connections for speed.
-On subsequent syncs, only fetches new messages.
+On subsequent syncs, fetches new messages, drops messages that were deleted
+or moved away, and updates read/flagged state. Servers with CONDSTORE only
+send flags that changed since the last sync, and a mailbox with no changes
+at all is skipped. QRESYNC isn't used: when anything changed, deletions are
+found by listing every UID in the mailbox, which is slower on large mailboxes.
 The cache is used for fast local searching with 'lark mail search'.
 
+With --all, every mailbox is synced. --include and --exclude narrow this
+down with case-insensitive glob patterns (* matches anything, including the
+folder separator). A mailbox that fails is reported and the rest continue.
+
+With --bodies, the text of each email is also downloaded and indexed so
+'lark mail search --text' can match it. Only emails not indexed yet are
+downloaded, and emails larger than --max-body-size are skipped.
+
 Examples:
   lark mail sync
-  lark mail sync --workers 20`,
+  lark mail sync --workers 20
+  lark mail sync --bodies
+  lark mail sync --bodies --max-body-size 5120
+  lark mail sync --all
+  lark mail sync --all --exclude Trash --exclude "Junk*"
+  lark mail sync --all --include "Projects/*" --bodies`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if mailSyncMaxBodySize < 0 {
+			output.Fatalf("VALIDATION_ERROR", "--max-body-size must not be negative")
+		}
+		if !mailSyncAll && (len(mailSyncInclude) > 0 || len(mailSyncExclude) > 0) {
+			output.Fatalf("VALIDATION_ERROR", "--include and --exclude require --all")
+		}
+		if mailSyncAll && cmd.Flags().Changed("mailbox") {
+			output.Fatalf("VALIDATION_ERROR", "--mailbox and --all can't be used together")
+		}
+
 		opts := &mail.SyncOptions{
-			Workers:  mailSyncWorkers,
-			Progress: os.Stderr,
+			Workers:     mailSyncWorkers,
+			Progress:    os.Stderr,
+			Bodies:      mailSyncBodies,
+			MaxBodySize: int64(mailSyncMaxBodySize) * 1024,
+		}
+
+		if mailSyncAll {
+			result, err := mail.SyncAll(mailSyncInclude, mailSyncExclude, opts)
+			if err != nil {
+				output.Fatal("SYNC_ERROR", err)
+			}
+			output.JSON(result)
+			return
 		}
 
 		result, err := mail.Sync(mailSyncMailbox, opts)
@@ -250,12 +344,15 @@ Examples:
 // --- mail search ---
 
 var (
-	mailSearchMailbox string
-	mailSearchFrom    string
-	mailSearchSubject string
-	mailSearchSince   string
-	mailSearchBefore  string
-	mailSearchLimit   int
+	mailSearchMailbox       string
+	mailSearchText          string
+	mailSearchFrom          string
+	mailSearchTo            string
+	mailSearchSubject       string
+	mailSearchSince         string
+	mailSearchBefore        string
+	mailSearchHasAttachment bool
+	mailSearchLimit         int
 )
 
 var mailSearchCmd = &cobra.Command{
@@ -266,10 +363,17 @@ var mailSearchCmd = &cobra.Command{
 The search uses the local cache which is updated by 'lark mail sync'.
 Results include cache freshness information so you know if data is stale.
 
+--text searches the subject, sender, recipients, attachment names and
+indexed body text; every word must match, and a trailing * matches a
+prefix. Results are ranked by relevance and include a snippet with the
+matches in [brackets]. Bodies are only indexed by 'lark mail sync --bodies'.
+
 Examples:
   lark mail search
   lark mail search --from alice@example.com
   lark mail search --subject "Q4 Report" --since 2025-01-01
+  lark mail search --text "budget forecast" --has-attachment
+  lark mail search --to bob@example.com --text invoice*
   lark mail search --mailbox INBOX --limit 20`,
 	Run: func(cmd *cobra.Command, args []string) {
 		opts, err := mail.ParseSearchOptions(
@@ -280,6 +384,9 @@ Examples:
 		if err != nil {
 			output.Fatal("VALIDATION_ERROR", err)
 		}
+		opts.Text = mailSearchText
+		opts.To = mailSearchTo
+		opts.HasAttachment = mailSearchHasAttachment
 
 		result, err := mail.Search(mailSearchMailbox, opts)
 		if err != nil {
@@ -300,7 +407,12 @@ var (
 var mailShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show email content",
-	Long: `Fetch and display the full content of an email by UID.
+	Long: `Fetch and display an email by UID, decoded.
+
+The body is the message's plain text part, or its HTML part converted to
+text when there is no plain text part (body_type says which). Attachments
+are listed with their index, filename, content type and size; save one with
+'lark mail attachment'.
 
 The UID can be obtained from search results.
 
@@ -328,9 +440,30 @@ Examples:
 			output.Fatal("IMAP_ERROR", err)
 		}
 
+		msg, err := mail.ParseMessage(body)
+		if err != nil {
+			output.Fatal("PARSE_ERROR", err)
+		}
+
 		result := map[string]interface{}{
-			"uid":  mailShowUID,
-			"body": string(body),
+			"uid":         mailShowUID,
+			"to":          nonNilAddresses(msg.To),
+			"cc":          nonNilAddresses(msg.Cc),
+			"body":        msg.Body,
+			"body_type":   msg.BodyType,
+			"attachments": msg.Attachments,
+		}
+		if len(msg.ReplyTo) > 0 {
+			result["reply_to"] = msg.ReplyTo
+		}
+		if len(msg.InReplyTo) > 0 {
+			result["in_reply_to"] = msg.InReplyTo
+		}
+		if len(msg.References) > 0 {
+			result["references"] = msg.References
+		}
+		if msg.Attachments == nil {
+			result["attachments"] = []mail.Attachment{}
 		}
 
 		if envelope != nil {
@@ -347,12 +480,98 @@ Examples:
 	},
 }
 
+// nonNilAddresses returns addrs, or an empty list instead of nil so the
+// JSON field is always a list
+func nonNilAddresses(addrs []mail.Address) []mail.Address {
+	if addrs == nil {
+		return []mail.Address{}
+	}
+	return addrs
+}
+
+// --- mail attachment ---
+
+var (
+	mailAttachmentMailbox string
+	mailAttachmentUID     uint32
+	mailAttachmentIndex   int
+	mailAttachmentOutput  string
+)
+
+var mailAttachmentCmd = &cobra.Command{
+	Use:   "attachment",
+	Short: "Save an email attachment",
+	Long: `Save one attachment of an email, decoded, to a file.
+
+--index is the attachment's index as listed by 'lark mail show'. --out is
+the file to write, or a directory to write it to under its own filename.
+
+Examples:
+  lark mail attachment --uid 12345 --index 1
+  lark mail attachment --uid 12345 --index 2 --out ~/Downloads/
+  lark mail attachment --uid 12345 --index 2 --out report.pdf`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if mailAttachmentUID == 0 {
+			output.Fatalf("VALIDATION_ERROR", "--uid is required")
+		}
+		if mailAttachmentIndex < 1 {
+			output.Fatalf("VALIDATION_ERROR", "--index is required")
+		}
+
+		client, err := mail.Connect()
+		if err != nil {
+			output.Fatal("CONNECTION_ERROR", err)
+		}
+		defer client.Close()
+
+		_, err = client.SelectMailbox(mailAttachmentMailbox)
+		if err != nil {
+			output.Fatal("IMAP_ERROR", err)
+		}
+
+		body, _, err := client.FetchMessage(mail.UID(mailAttachmentUID))
+		if err != nil {
+			output.Fatal("IMAP_ERROR", err)
+		}
+
+		attachment, data, err := mail.ExtractAttachment(body, mailAttachmentIndex)
+		if err != nil {
+			output.Fatal("PARSE_ERROR", err)
+		}
+
+		filename := sanitizeFilename(filepath.Base(attachment.Filename))
+		if filename == "" || filename == "." {
+			filename = fmt.Sprintf("attachment-%d", attachment.Index)
+		}
+		outpath := mailAttachmentOutput
+		if info, err := os.Stat(outpath); (err == nil && info.IsDir()) || strings.HasSuffix(outpath, string(os.PathSeparator)) {
+			if err := os.MkdirAll(outpath, 0755); err != nil {
+				output.Fatal("IO_ERROR", err)
+			}
+			outpath = filepath.Join(outpath, filename)
+		}
+
+		if err := os.WriteFile(outpath, data, 0644); err != nil {
+			output.Fatal("IO_ERROR", err)
+		}
+
+		output.JSON(map[string]interface{}{
+			"success":      true,
+			"uid":          mailAttachmentUID,
+			"index":        attachment.Index,
+			"filename":     attachment.Filename,
+			"content_type": attachment.ContentType,
+			"path":         outpath,
+			"size":         len(data),
+		})
+	},
+}
+
 // --- mail fetch ---
 
 var (
 	mailFetchMailbox string
 	mailFetchUID     uint32
-	mailFetchOutput  string
 )
 
 var mailFetchCmd = &cobra.Command{
@@ -362,7 +581,7 @@ var mailFetchCmd = &cobra.Command{
 
 Examples:
   lark mail fetch --uid 12345
-  lark mail fetch --uid 12345 -o /path/to/output/`,
+  lark mail fetch --uid 12345 --out /path/to/output/`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if mailFetchUID == 0 {
 			output.Fatalf("VALIDATION_ERROR", "--uid is required")
@@ -400,9 +619,10 @@ Examples:
 		}
 
 		filename := fmt.Sprintf("%s %s.eml", date, subject)
-		outpath := filepath.Join(mailFetchOutput, filename)
+		outdir := outPath(cmd)
+		outpath := filepath.Join(outdir, filename)
 
-		if err := os.MkdirAll(mailFetchOutput, 0755); err != nil {
+		if err := os.MkdirAll(outdir, 0755); err != nil {
 			output.Fatal("IO_ERROR", err)
 		}
 
@@ -472,23 +692,37 @@ func init() {
 	// mail sync flags
 	mailSyncCmd.Flags().StringVarP(&mailSyncMailbox, "mailbox", "m", "INBOX", "Mailbox to sync")
 	mailSyncCmd.Flags().IntVarP(&mailSyncWorkers, "workers", "w", 10, "Number of parallel connections for initial sync")
+	mailSyncCmd.Flags().BoolVar(&mailSyncBodies, "bodies", false, "Also download and index email body text for --text search")
+	mailSyncCmd.Flags().IntVar(&mailSyncMaxBodySize, "max-body-size", 1024, "With --bodies, skip emails larger than this many KB (0 = no limit)")
+	mailSyncCmd.Flags().BoolVar(&mailSyncAll, "all", false, "Sync all mailboxes")
+	mailSyncCmd.Flags().StringArrayVar(&mailSyncInclude, "include", nil, "With --all, only sync mailboxes matching this glob (repeatable)")
+	mailSyncCmd.Flags().StringArrayVar(&mailSyncExclude, "exclude", nil, "With --all, skip mailboxes matching this glob (repeatable)")
 
 	// mail search flags
 	mailSearchCmd.Flags().StringVarP(&mailSearchMailbox, "mailbox", "m", "INBOX", "Mailbox to search")
+	mailSearchCmd.Flags().StringVar(&mailSearchText, "text", "", "Full-text search of subject, addresses, attachment names and bodies")
 	mailSearchCmd.Flags().StringVar(&mailSearchFrom, "from", "", "Filter by sender address")
+	mailSearchCmd.Flags().StringVar(&mailSearchTo, "to", "", "Filter by recipient (To or Cc) address or name")
 	mailSearchCmd.Flags().StringVar(&mailSearchSubject, "subject", "", "Filter by subject")
 	mailSearchCmd.Flags().StringVar(&mailSearchSince, "since", "", "Emails since date (YYYY-MM-DD)")
 	mailSearchCmd.Flags().StringVar(&mailSearchBefore, "before", "", "Emails before date (YYYY-MM-DD)")
+	mailSearchCmd.Flags().BoolVar(&mailSearchHasAttachment, "has-attachment", false, "Only emails with attachments")
 	mailSearchCmd.Flags().IntVar(&mailSearchLimit, "limit", 50, "Maximum results")
 
 	// mail show flags
 	mailShowCmd.Flags().StringVarP(&mailShowMailbox, "mailbox", "m", "INBOX", "Mailbox")
 	mailShowCmd.Flags().Uint32Var(&mailShowUID, "uid", 0, "Email UID (required)")
 
+	// mail attachment flags
+	mailAttachmentCmd.Flags().StringVarP(&mailAttachmentMailbox, "mailbox", "m", "INBOX", "Mailbox")
+	mailAttachmentCmd.Flags().Uint32Var(&mailAttachmentUID, "uid", 0, "Email UID (required)")
+	mailAttachmentCmd.Flags().IntVar(&mailAttachmentIndex, "index", 0, "Attachment index from 'lark mail show' (required)")
+	mailAttachmentCmd.Flags().StringVar(&mailAttachmentOutput, "out", ".", "Output file or directory")
+
 	// mail fetch flags
 	mailFetchCmd.Flags().StringVarP(&mailFetchMailbox, "mailbox", "m", "INBOX", "Mailbox")
 	mailFetchCmd.Flags().Uint32Var(&mailFetchUID, "uid", 0, "Email UID (required)")
-	mailFetchCmd.Flags().StringVarP(&mailFetchOutput, "output", "o", ".", "Output directory")
+	addOutPathFlag(mailFetchCmd, ".", "Output directory")
 
 	// Register subcommands
 	mailCmd.AddCommand(mailSetupCmd)
@@ -498,4 +732,5 @@ func init() {
 	mailCmd.AddCommand(mailSearchCmd)
 	mailCmd.AddCommand(mailShowCmd)
 	mailCmd.AddCommand(mailFetchCmd)
+	mailCmd.AddCommand(mailAttachmentCmd)
 }
@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/mail"
+	"github.com/yjwong/lark-cli/internal/output"
+)
+
+// Flags shared by mail mark, move and delete to pick the emails to act on
+var (
+	mailSelectMailbox       string
+	mailSelectUIDs          []uint
+	mailSelectText          string
+	mailSelectFrom          string
+	mailSelectRecipient     string
+	mailSelectSubject       string
+	mailSelectSince         string
+	mailSelectBefore        string
+	mailSelectHasAttachment bool
+	mailSelectLimit         int
+	mailSelectDryRun        bool
+)
+
+// addSelectFlags adds --uid and the mail search filters, which select
+// emails from the local cache
+func addSelectFlags(c *cobra.Command) {
+	c.Flags().StringVarP(&mailSelectMailbox, "mailbox", "m", "INBOX", "Mailbox of the emails")
+	c.Flags().UintSliceVar(&mailSelectUIDs, "uid", nil, "UIDs of the emails (comma-separated or repeatable)")
+	c.Flags().StringVar(&mailSelectText, "text", "", "Select cached emails matching this full-text search")
+	c.Flags().StringVar(&mailSelectFrom, "from", "", "Select cached emails by sender address")
+	c.Flags().StringVar(&mailSelectRecipient, "recipient", "", "Select cached emails by recipient (To or Cc)")
+	c.Flags().StringVar(&mailSelectSubject, "subject", "", "Select cached emails by subject")
+	c.Flags().StringVar(&mailSelectSince, "since", "", "Select cached emails since date (YYYY-MM-DD)")
+	c.Flags().StringVar(&mailSelectBefore, "before", "", "Select cached emails before date (YYYY-MM-DD)")
+	c.Flags().BoolVar(&mailSelectHasAttachment, "has-attachment", false, "Select cached emails with attachments")
+	c.Flags().IntVar(&mailSelectLimit, "limit", 50, "Maximum emails to select by search; the output has truncated set if more match")
+	c.Flags().BoolVar(&mailSelectDryRun, "dry-run", false, "Show the selected emails without changing anything")
+}
+
+// selectEmails returns the UIDs given with --uid, or those of the cached
+// emails matching the search filters, which are the ones 'lark mail search'
+// lists for the same filters. The matching emails are returned too, and
+// whether more than --limit matched.
+func selectEmails(cmd *cobra.Command) (mail.Selection, []mail.SearchHit, bool) {
+	searching := false
+	for _, name := range []string{"text", "from", "recipient", "subject", "since", "before", "has-attachment"} {
+		if cmd.Flags().Changed(name) {
+			searching = true
+		}
+	}
+	if len(mailSelectUIDs) > 0 && searching {
+		output.Fatalf("VALIDATION_ERROR", "--uid can't be combined with search filters")
+	}
+	if len(mailSelectUIDs) == 0 && !searching {
+		output.Fatalf("VALIDATION_ERROR", "--uid or a search filter (--text, --from, --recipient, --subject, --since, --before, --has-attachment) is required")
+	}
+	// A blank --text matches everything; don't let a typo act on a whole mailbox
+	if cmd.Flags().Changed("text") && strings.TrimSpace(mailSelectText) == "" {
+		output.Fatalf("VALIDATION_ERROR", "--text is blank")
+	}
+
+	var sel mail.Selection
+	var hits []mail.SearchHit
+	truncated := false
+	if searching {
+		if mailSelectLimit < 1 {
+			output.Fatalf("VALIDATION_ERROR", "--limit must be at least 1")
+		}
+		// One more than the limit tells whether the selection is cut short
+		opts, err := mail.ParseSearchOptions(
+			mailSelectFrom, mailSelectSubject,
+			mailSelectSince, mailSelectBefore,
+			mailSelectLimit+1,
+		)
+		if err != nil {
+			output.Fatal("VALIDATION_ERROR", err)
+		}
+		opts.Text = mailSelectText
+		opts.To = mailSelectRecipient
+		opts.HasAttachment = mailSelectHasAttachment
+
+		result, err := mail.Search(mailSelectMailbox, opts)
+		if err != nil {
+			output.Fatal("SEARCH_ERROR", err)
+		}
+		hits = result.Results
+		if len(hits) > mailSelectLimit {
+			hits, truncated = hits[:mailSelectLimit], true
+		}
+		sel.FromCache = true
+		for _, hit := range hits {
+			sel.UIDs = append(sel.UIDs, hit.UID)
+		}
+	} else {
+		for _, uid := range mailSelectUIDs {
+			if uid == 0 || uid > 1<<32-1 {
+				output.Fatalf("VALIDATION_ERROR", "invalid UID: %d", uid)
+			}
+			sel.UIDs = append(sel.UIDs, uint32(uid))
+		}
+	}
+
+	if len(sel.UIDs) == 0 && !mailSelectDryRun {
+		output.Fatalf("VALIDATION_ERROR", "no cached emails in %s match the search", mailSelectMailbox)
+	}
+	return sel, hits, truncated
+}
+
+// dryRunResult describes the emails an action would change
+func dryRunResult(sel mail.Selection, hits []mail.SearchHit, truncated bool) map[string]interface{} {
+	result := map[string]interface{}{
+		"dry_run": true,
+		"mailbox": mailSelectMailbox,
+		"uids":    nonNilUIDs(sel.UIDs),
+		"count":   len(sel.UIDs),
+	}
+	if hits != nil {
+		result["emails"] = hits
+	}
+	if truncated {
+		result["truncated"] = true
+	}
+	return result
+}
+
+func nonNilUIDs(uids []uint32) []uint32 {
+	if uids == nil {
+		return []uint32{}
+	}
+	return uids
+}
+
+// --- mail mark ---
+
+var (
+	mailMarkRead   bool
+	mailMarkUnread bool
+	mailMarkFlag   bool
+	mailMarkUnflag bool
+)
+
+var mailMarkCmd = &cobra.Command{
+	Use:   "mark",
+	Short: "Mark emails read, unread, flagged or unflagged",
+	Long: `Mark emails as read or unread, and flag or unflag them.
+
+Emails are picked with --uid, or with the same filters as 'lark mail search',
+which select from the local cache. Use --dry-run to see what a search
+selects first. The cache is updated with the new flags.
+
+Examples:
+  lark mail mark --uid 12345 --read
+  lark mail mark --uid 12345,12346 --unread --flag
+  lark mail mark --from newsletter@example.com --read
+  lark mail mark --recipient finance@example.com --flag --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if mailMarkRead && mailMarkUnread {
+			output.Fatalf("VALIDATION_ERROR", "--read and --unread can't be used together")
+		}
+		if mailMarkFlag && mailMarkUnflag {
+			output.Fatalf("VALIDATION_ERROR", "--flag and --unflag can't be used together")
+		}
+
+		var add, remove []string
+		if mailMarkRead {
+			add = append(add, mail.FlagSeen)
+		}
+		if mailMarkUnread {
+			remove = append(remove, mail.FlagSeen)
+		}
+		if mailMarkFlag {
+			add = append(add, mail.FlagFlagged)
+		}
+		if mailMarkUnflag {
+			remove = append(remove, mail.FlagFlagged)
+		}
+		if len(add) == 0 && len(remove) == 0 {
+			output.Fatalf("VALIDATION_ERROR", "one of --read, --unread, --flag or --unflag is required")
+		}
+
+		sel, hits, truncated := selectEmails(cmd)
+		if mailSelectDryRun {
+			output.JSON(dryRunResult(sel, hits, truncated))
+			return
+		}
+		result, err := mail.Mark(mailSelectMailbox, sel, add, remove)
+		if err != nil {
+			output.Fatal("IMAP_ERROR", err)
+		}
+		result.Truncated = truncated
+
+		output.JSON(result)
+	},
+}
+
+// --- mail move ---
+
+var mailMoveTo string
+
+var mailMoveCmd = &cobra.Command{
+	Use:   "move",
+	Short: "Move emails to another mailbox",
+	Long: `Move emails to another mailbox.
+
+Emails are picked with --uid, or with the same filters as 'lark mail search',
+which select from the local cache. Cached emails move to the destination in
+the cache too, under their new UIDs, if the server reports them.
+
+Examples:
+  lark mail move --uid 12345 --to Archive
+  lark mail move --uid 12345,12346 --mailbox Archive --to INBOX
+  lark mail move --from alerts@example.com --before 2026-01-01 --to "Archive/Alerts"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if mailMoveTo == "" {
+			output.Fatalf("VALIDATION_ERROR", "--to is required")
+		}
+
+		sel, hits, truncated := selectEmails(cmd)
+		if mailSelectDryRun {
+			output.JSON(dryRunResult(sel, hits, truncated))
+			return
+		}
+		result, err := mail.Move(mailSelectMailbox, sel, mailMoveTo)
+		if err != nil {
+			output.Fatal("IMAP_ERROR", err)
+		}
+		result.Truncated = truncated
+
+		output.JSON(result)
+	},
+}
+
+// --- mail delete ---
+
+var mailDeleteExpunge bool
+
+var mailDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete emails",
+	Long: `Delete emails by moving them to the Trash mailbox.
+
+With --expunge, or for emails already in the Trash, they are deleted
+permanently instead, which can't be undone. Emails are picked with --uid, or
+with the same filters as 'lark mail search', which select from the local
+cache.
+
+Examples:
+  lark mail delete --uid 12345
+  lark mail delete --uid 12345,12346 --expunge
+  lark mail delete --from spam@example.com --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sel, hits, truncated := selectEmails(cmd)
+		if mailSelectDryRun {
+			output.JSON(dryRunResult(sel, hits, truncated))
+			return
+		}
+		result, err := mail.Delete(mailSelectMailbox, sel, mailDeleteExpunge)
+		if err != nil {
+			output.Fatal("IMAP_ERROR", err)
+		}
+		result.Truncated = truncated
+
+		output.JSON(result)
+	},
+}
+
+func init() {
+	addSelectFlags(mailMarkCmd)
+	mailMarkCmd.Flags().BoolVar(&mailMarkRead, "read", false, "Mark as read")
+	mailMarkCmd.Flags().BoolVar(&mailMarkUnread, "unread", false, "Mark as unread")
+	mailMarkCmd.Flags().BoolVar(&mailMarkFlag, "flag", false, "Flag (star)")
+	mailMarkCmd.Flags().BoolVar(&mailMarkUnflag, "unflag", false, "Remove the flag")
+
+	addSelectFlags(mailMoveCmd)
+	mailMoveCmd.Flags().StringVar(&mailMoveTo, "to", "", "Mailbox to move the emails to (required)")
+
+	addSelectFlags(mailDeleteCmd)
+	mailDeleteCmd.Flags().BoolVar(&mailDeleteExpunge, "expunge", false, "Delete permanently instead of moving to Trash")
+
+	mailCmd.AddCommand(mailMarkCmd)
+	mailCmd.AddCommand(mailMoveCmd)
+	mailCmd.AddCommand(mailDeleteCmd)
+}
@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/mail"
+	"github.com/yjwong/lark-cli/internal/output"
+)
+
+// Flags shared by mail send, reply and forward
+var (
+	mailComposeTo       []string
+	mailComposeCc       []string
+	mailComposeBcc      []string
+	mailComposeSubject  string
+	mailComposeBody     string
+	mailComposeBodyFile string
+	mailComposeAttach   []string
+)
+
+// addComposeFlags adds the flags that describe an outgoing email
+func addComposeFlags(c *cobra.Command) {
+	c.Flags().StringSliceVar(&mailComposeTo, "to", nil, "Recipient address (repeatable or comma-separated)")
+	c.Flags().StringSliceVar(&mailComposeCc, "cc", nil, "Cc address (repeatable or comma-separated)")
+	c.Flags().StringSliceVar(&mailComposeBcc, "bcc", nil, "Bcc address (repeatable or comma-separated)")
+	c.Flags().StringVar(&mailComposeSubject, "subject", "", "Subject")
+	c.Flags().StringVar(&mailComposeBody, "body", "", "Plain text body")
+	c.Flags().StringVar(&mailComposeBodyFile, "body-file", "", "Read the plain text body from a file (- for stdin)")
+	c.Flags().StringArrayVar(&mailComposeAttach, "attach", nil, "File to attach (repeatable)")
+}
+
+// --- mail send ---
+
+var mailSendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Send an email",
+	Long: `Send a plain text email over SMTP and save a copy to the Sent mailbox.
+
+SMTP uses the server configured by 'lark mail setup' and logs in with the
+IMAP username and password. The body comes from --body or --body-file.
+
+Examples:
+  lark mail send --to alice@example.com --subject "Q4 report" --body "Attached."
+  lark mail send --to "Alice Tan <alice@example.com>" --cc bob@example.com \
+    --subject "Q4 report" --body-file notes.txt --attach report.pdf
+  echo "Done" | lark mail send --to alice@example.com --subject Status --body-file -`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(mailComposeTo) == 0 {
+			output.Fatalf("VALIDATION_ERROR", "--to is required")
+		}
+		if mailComposeSubject == "" {
+			output.Fatalf("VALIDATION_ERROR", "--subject is required")
+		}
+
+		creds, err := mail.LoadCredentials()
+		if err != nil {
+			output.Fatal("CONNECTION_ERROR", err)
+		}
+
+		draft := &mail.Draft{
+			From:    mail.Address{Email: creds.Username},
+			Subject: mailComposeSubject,
+		}
+		applyComposeFlags(draft)
+
+		client, err := mail.ConnectWithCredentials(creds)
+		if err != nil {
+			output.Fatal("CONNECTION_ERROR", err)
+		}
+		defer client.Close()
+
+		output.JSON(sendDraft(creds, client, draft))
+	},
+}
+
+// --- mail reply ---
+
+var (
+	mailReplyMailbox string
+	mailReplyUID     uint32
+	mailReplyAll     bool
+)
+
+var mailReplyCmd = &cobra.Command{
+	Use:   "reply",
+	Short: "Reply to an email",
+	Long: `Reply to an email by UID, quoting it below your message.
+
+The reply goes to the original's Reply-To address, or its sender. With
+--all, the original's other To and Cc recipients are copied in. In-Reply-To
+and References are set from the original so the reply stays in its thread,
+and "Re: " is added to the subject unless --subject is given.
+
+Examples:
+  lark mail reply --uid 12345 --body "Thanks, looks good."
+  lark mail reply --uid 12345 --all --body-file reply.txt --attach notes.pdf`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if mailReplyUID == 0 {
+			output.Fatalf("VALIDATION_ERROR", "--uid is required")
+		}
+
+		creds, client, orig, _ := fetchOriginal(mailReplyMailbox, mailReplyUID)
+		defer client.Close()
+
+		draft := mail.NewReply(orig, mail.Address{Email: creds.Username}, mailReplyAll, readComposeBody())
+		if len(draft.To) == 0 && len(mailComposeTo) == 0 {
+			output.Fatalf("VALIDATION_ERROR", "the original has no sender to reply to; use --to")
+		}
+		applyComposeFlags(draft)
+
+		output.JSON(sendDraft(creds, client, draft))
+	},
+}
+
+// --- mail forward ---
+
+var (
+	mailForwardMailbox string
+	mailForwardUID     uint32
+)
+
+var mailForwardCmd = &cobra.Command{
+	Use:   "forward",
+	Short: "Forward an email",
+	Long: `Forward an email by UID, with its attachments.
+
+Your message goes above the forwarded one. "Fwd: " is added to the subject
+unless --subject is given. References is set from the original, but not
+In-Reply-To, since a forward isn't a reply.
+
+Examples:
+  lark mail forward --uid 12345 --to bob@example.com
+  lark mail forward --uid 12345 --to bob@example.com --body "FYI, see below."`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if mailForwardUID == 0 {
+			output.Fatalf("VALIDATION_ERROR", "--uid is required")
+		}
+		if len(mailComposeTo) == 0 {
+			output.Fatalf("VALIDATION_ERROR", "--to is required")
+		}
+
+		creds, client, orig, raw := fetchOriginal(mailForwardMailbox, mailForwardUID)
+		defer client.Close()
+
+		draft, err := mail.NewForward(orig, raw, mail.Address{Email: creds.Username}, readComposeBody())
+		if err != nil {
+			output.Fatal("PARSE_ERROR", err)
+		}
+		applyComposeFlags(draft)
+
+		output.JSON(sendDraft(creds, client, draft))
+	},
+}
+
+// fetchOriginal connects and fetches the message being replied to or
+// forwarded
+func fetchOriginal(mailbox string, uid uint32) (*mail.Credentials, *mail.Client, *mail.Message, []byte) {
+	creds, err := mail.LoadCredentials()
+	if err != nil {
+		output.Fatal("CONNECTION_ERROR", err)
+	}
+
+	client, err := mail.ConnectWithCredentials(creds)
+	if err != nil {
+		output.Fatal("CONNECTION_ERROR", err)
+	}
+
+	if _, err := client.SelectMailbox(mailbox); err != nil {
+		client.Close()
+		output.Fatal("IMAP_ERROR", err)
+	}
+	raw, _, err := client.FetchMessage(mail.UID(uid))
+	if err != nil {
+		client.Close()
+		output.Fatal("IMAP_ERROR", err)
+	}
+	orig, err := mail.ParseMessage(raw)
+	if err != nil {
+		client.Close()
+		output.Fatal("PARSE_ERROR", err)
+	}
+	return creds, client, orig, raw
+}
+
+// readComposeBody returns the body from --body or --body-file
+func readComposeBody() string {
+	if mailComposeBodyFile == "" {
+		return mailComposeBody
+	}
+	if mailComposeBody != "" {
+		output.Fatalf("VALIDATION_ERROR", "--body and --body-file can't be used together")
+	}
+
+	var data []byte
+	var err error
+	if mailComposeBodyFile == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(mailComposeBodyFile)
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			output.Fatalf("FILE_ERROR", "body file not found: %s", mailComposeBodyFile)
+		}
+		output.Fatal("FILE_ERROR", err)
+	}
+	return string(data)
+}
+
+// applyComposeFlags adds the recipients and attachments from the flags to a
+// draft, and the subject and body if set. A reply or forward has its body
+// already, so it is only set here for mail send.
+func applyComposeFlags(d *mail.Draft) {
+	for _, f := range []struct {
+		list *[]mail.Address
+		flag []string
+	}{{&d.To, mailComposeTo}, {&d.Cc, mailComposeCc}, {&d.Bcc, mailComposeBcc}} {
+		addrs, err := mail.ParseAddresses(f.flag)
+		if err != nil {
+			output.Fatal("VALIDATION_ERROR", err)
+		}
+		*f.list = append(*f.list, addrs...)
+	}
+
+	if mailComposeSubject != "" {
+		d.Subject = mailComposeSubject
+	}
+	if d.Body == "" {
+		d.Body = readComposeBody()
+	}
+
+	for _, path := range mailComposeAttach {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				output.Fatalf("FILE_ERROR", "attachment not found: %s", path)
+			}
+			output.Fatal("FILE_ERROR", err)
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		d.Attachments = append(d.Attachments, mail.DraftAttachment{
+			Filename:    filepath.Base(path),
+			ContentType: contentType,
+			Data:        data,
+		})
+	}
+}
+
+// sendDraft sends a draft over SMTP and saves it to the Sent mailbox. The
+// message has been sent once SMTP accepts it, so failing to save it is
+// reported in the result rather than as an error.
+func sendDraft(creds *mail.Credentials, client *mail.Client, d *mail.Draft) map[string]interface{} {
+	messageID, raw, err := d.Build()
+	if err != nil {
+		output.Fatal("PARSE_ERROR", err)
+	}
+
+	if err := mail.SendMail(creds, d.From.Email, d.Recipients(), raw); err != nil {
+		output.Fatal("SMTP_ERROR", err)
+	}
+
+	attachments := make([]string, len(d.Attachments))
+	for i, a := range d.Attachments {
+		attachments[i] = a.Filename
+	}
+	result := map[string]interface{}{
+		"success":     true,
+		"message_id":  messageID,
+		"from":        d.From.Email,
+		"to":          nonNilAddresses(d.To),
+		"cc":          nonNilAddresses(d.Cc),
+		"subject":     d.Subject,
+		"attachments": attachments,
+	}
+	if len(d.Bcc) > 0 {
+		result["bcc"] = d.Bcc
+	}
+	if d.InReplyTo != "" {
+		result["in_reply_to"] = d.InReplyTo
+	}
+
+	if mailbox, err := client.SaveSent(raw); err != nil {
+		result["append_error"] = fmt.Sprintf("sent, but not saved to the Sent mailbox: %v", err)
+	} else {
+		result["sent_mailbox"] = mailbox
+	}
+	return result
+}
+
+func init() {
+	addComposeFlags(mailSendCmd)
+
+	addComposeFlags(mailReplyCmd)
+	mailReplyCmd.Flags().StringVarP(&mailReplyMailbox, "mailbox", "m", "INBOX", "Mailbox of the email")
+	mailReplyCmd.Flags().Uint32Var(&mailReplyUID, "uid", 0, "UID of the email to reply to (required)")
+	mailReplyCmd.Flags().BoolVar(&mailReplyAll, "all", false, "Reply to all recipients")
+
+	addComposeFlags(mailForwardCmd)
+	mailForwardCmd.Flags().StringVarP(&mailForwardMailbox, "mailbox", "m", "INBOX", "Mailbox of the email")
+	mailForwardCmd.Flags().Uint32Var(&mailForwardUID, "uid", 0, "UID of the email to forward (required)")
+
+	mailCmd.AddCommand(mailSendCmd)
+	mailCmd.AddCommand(mailReplyCmd)
+	mailCmd.AddCommand(mailForwardCmd)
+}
@@ -85,7 +85,6 @@ var (
 	transcriptFormat    string
 	transcriptSpeaker   bool
 	transcriptTimestamp bool
-	transcriptOutput    string
 )
 
 var minutesTranscriptCmd = &cobra.Command{
@@ -99,7 +98,7 @@ Examples:
   lark minutes transcript obcnq3b9jl72l83w4f14xxxx
   lark minutes transcript obcnq3b9jl72l83w4f14xxxx --format srt
   lark minutes transcript obcnq3b9jl72l83w4f14xxxx --speaker --timestamp
-  lark minutes transcript obcnq3b9jl72l83w4f14xxxx --output transcript.txt`,
+  lark minutes transcript obcnq3b9jl72l83w4f14xxxx --out transcript.txt`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		minuteToken := args[0]
@@ -118,7 +117,7 @@ Examples:
 		}
 
 		// Write to file if output path specified
-		if transcriptOutput != "" {
+		if transcriptOutput := outPath(cmd); transcriptOutput != "" {
 			if err := os.WriteFile(transcriptOutput, content, 0644); err != nil {
 				output.Fatal("FILE_ERROR", err)
 			}
@@ -197,7 +196,7 @@ func init() {
 	minutesTranscriptCmd.Flags().StringVar(&transcriptFormat, "format", "txt", "Output format (txt or srt)")
 	minutesTranscriptCmd.Flags().BoolVar(&transcriptSpeaker, "speaker", false, "Include speaker names")
 	minutesTranscriptCmd.Flags().BoolVar(&transcriptTimestamp, "timestamp", false, "Include timestamps")
-	minutesTranscriptCmd.Flags().StringVar(&transcriptOutput, "output", "", "Write transcript to file instead of stdout")
+	addOutPathFlag(minutesTranscriptCmd, "", "Write transcript to file instead of stdout")
 
 	// Register subcommands
 	minutesCmd.AddCommand(minutesGetCmd)
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -13,6 +15,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/yjwong/lark-cli/internal/api"
 	"github.com/yjwong/lark-cli/internal/config"
+	"github.com/yjwong/lark-cli/internal/markdown"
+	"github.com/yjwong/lark-cli/internal/names"
 	"github.com/yjwong/lark-cli/internal/output"
 )
 
@@ -28,12 +32,16 @@ var msgCmd = &cobra.Command{
 // --- msg history ---
 
 var (
-	msgHistoryChatID    string
-	msgHistoryType      string
-	msgHistoryStartTime string
-	msgHistoryEndTime   string
-	msgHistorySort      string
-	msgHistoryLimit     int
+	msgHistoryChatID     string
+	msgHistoryType       string
+	msgHistoryStartTime  string
+	msgHistoryEndTime    string
+	msgHistorySort       string
+	msgHistoryLimit      int
+	msgHistoryFormat     string
+	msgHistoryThreaded   bool
+	msgHistoryTranscript bool
+	msgHistoryResolve    bool
 )
 
 var msgHistoryCmd = &cobra.Command{
@@ -49,11 +57,26 @@ Examples:
   lark msg history --chat-id oc_xxxxx --limit 50
   lark msg history --chat-id oc_xxxxx --start 1704067200 --end 1704153600
   lark msg history --chat-id oc_xxxxx --sort desc
-  lark msg history --chat-id thread_xxxxx --type thread`,
+  lark msg history --chat-id thread_xxxxx --type thread
+  lark msg history --chat-id oc_xxxxx --format markdown
+  lark msg history --chat-id oc_xxxxx --threaded --start 2026-01-02
+
+Content is the raw content JSON by default. --format text renders each
+message as plain text and --format markdown as markdown, with @mentions
+replaced by names. Images and files are listed under attachments either way;
+download them with 'lark msg resource'.
+
+--threaded nests replies under their root message, fetching the rest of each
+thread, and --transcript exports the conversation as a markdown transcript
+(see 'lark msg thread' for a single thread).
+
+--resolve-names adds sender and chat names, looked up through the contact
+and chat APIs and cached for a day.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if msgHistoryChatID == "" {
 			output.Fatalf("VALIDATION_ERROR", "chat-id is required")
 		}
+		validateContentFormat(msgHistoryFormat)
 
 		client := api.NewClient()
 
@@ -76,60 +99,107 @@ Examples:
 			}
 		}
 
-		// Fetch messages with pagination
-		var allMessages []api.Message
-		var pageToken string
-		hasMore := true
-		remaining := msgHistoryLimit
+		allMessages, err := listAllMessages(client, msgHistoryType, msgHistoryChatID, opts, msgHistoryLimit)
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
 
-		for hasMore {
-			// Calculate page size
-			pageSize := 50
-			if remaining > 0 && remaining < pageSize {
-				pageSize = remaining
-			}
-			opts.PageSize = pageSize
-			opts.PageToken = pageToken
+		var resolver *names.Resolver
+		if msgHistoryResolve {
+			resolver = names.NewResolver(client)
+		}
 
-			messages, more, nextToken, err := client.ListMessages(msgHistoryType, msgHistoryChatID, opts)
+		if msgHistoryThreaded || msgHistoryTranscript {
+			threads, err := groupThreads(client, allMessages)
 			if err != nil {
 				output.Fatal("API_ERROR", err)
 			}
-
-			allMessages = append(allMessages, messages...)
-			hasMore = more
-			pageToken = nextToken
-
-			// Check limit
-			if msgHistoryLimit > 0 {
-				remaining = msgHistoryLimit - len(allMessages)
-				if remaining <= 0 {
-					break
-				}
+			var threaded []api.Message
+			for _, t := range threads {
+				threaded = append(threaded, t.root)
+				threaded = append(threaded, t.replies...)
+			}
+			resolveMessageNames(resolver, threaded, msgHistoryType, msgHistoryChatID)
+
+			if msgHistoryTranscript {
+				output.JSON(api.OutputMessageTranscript{
+					ChatID:  msgHistoryChatID,
+					Format:  contentFormatMarkdown,
+					Content: renderTranscript(threads, resolver),
+				})
+				return
 			}
+			result := api.OutputMessageList{ChatID: msgHistoryChatID, ChatName: resolver.Chat(msgHistoryChatID)}
+			for _, t := range threads {
+				result.Messages = append(result.Messages, t.output(msgHistoryFormat))
+			}
+			nameSenders(result.Messages, resolver)
+			result.Count = len(result.Messages)
+			output.JSON(result)
+			return
 		}
 
-		// Trim to limit if needed
-		if msgHistoryLimit > 0 && len(allMessages) > msgHistoryLimit {
-			allMessages = allMessages[:msgHistoryLimit]
-		}
+		resolveMessageNames(resolver, allMessages, msgHistoryType, msgHistoryChatID)
 
 		// Convert to output format
 		outputMessages := make([]api.OutputMessage, len(allMessages))
 		for i, m := range allMessages {
-			outputMessages[i] = convertMessage(m)
+			outputMessages[i] = convertMessage(m, msgHistoryFormat)
 		}
+		nameSenders(outputMessages, resolver)
 
 		result := api.OutputMessageList{
 			Messages: outputMessages,
 			Count:    len(outputMessages),
 			ChatID:   msgHistoryChatID,
+			ChatName: resolver.Chat(msgHistoryChatID),
 		}
 
 		output.JSON(result)
 	},
 }
 
+// listAllMessages lists a chat or thread's messages page by page, stopping
+// after limit messages (0 = no limit)
+func listAllMessages(client *api.Client, containerType, containerID string, opts *api.ListMessagesOptions, limit int) ([]api.Message, error) {
+	var allMessages []api.Message
+	remaining := limit
+	opts.PageToken = ""
+
+	for {
+		// Calculate page size
+		pageSize := 50
+		if remaining > 0 && remaining < pageSize {
+			pageSize = remaining
+		}
+		opts.PageSize = pageSize
+
+		messages, more, nextToken, err := client.ListMessages(containerType, containerID, opts)
+		if err != nil {
+			return nil, err
+		}
+		allMessages = append(allMessages, messages...)
+		if !more {
+			break
+		}
+		opts.PageToken = nextToken
+
+		// Check limit
+		if limit > 0 {
+			remaining = limit - len(allMessages)
+			if remaining <= 0 {
+				break
+			}
+		}
+	}
+
+	// Trim to limit if needed
+	if limit > 0 && len(allMessages) > limit {
+		allMessages = allMessages[:limit]
+	}
+	return allMessages, nil
+}
+
 // parseTimeArg parses a time argument as either Unix timestamp or ISO 8601
 func parseTimeArg(s string) string {
 	// First try as Unix timestamp
@@ -154,19 +224,18 @@ func parseTimeArg(s string) string {
 	return strconv.FormatInt(t.Unix(), 10)
 }
 
-// convertMessage converts an API message to CLI output format
-func convertMessage(m api.Message) api.OutputMessage {
+// convertMessage converts an API message to CLI output format, rendering
+// its content in the given format (raw, text or markdown)
+func convertMessage(m api.Message, format string) api.OutputMessage {
 	out := api.OutputMessage{
-		MessageID:  m.MessageID,
-		MsgType:    m.MsgType,
-		CreateTime: formatMessageTime(m.CreateTime),
-		IsReply:    m.RootID != "" || m.ParentID != "",
-		ThreadID:   m.ThreadID,
-		Deleted:    m.Deleted,
-	}
-
-	if m.Body != nil {
-		out.Content = m.Body.Content
+		MessageID:   m.MessageID,
+		MsgType:     m.MsgType,
+		Content:     renderMessageContent(m, format),
+		Attachments: messageAttachments(m),
+		CreateTime:  formatMessageTime(m.CreateTime),
+		IsReply:     m.RootID != "" || m.ParentID != "",
+		ThreadID:    m.ThreadID,
+		Deleted:     m.Deleted,
 	}
 
 	if m.Sender != nil {
@@ -190,6 +259,42 @@ func convertMessage(m api.Message) api.OutputMessage {
 	return out
 }
 
+// resolveMessageNames looks up the names of the messages' senders, and of
+// the chat when the container is one. A nil resolver does nothing.
+func resolveMessageNames(r *names.Resolver, messages []api.Message, containerType, containerID string) {
+	if r == nil {
+		return
+	}
+	var users, chats []string
+	for _, m := range messages {
+		if m.Sender != nil && m.Sender.SenderType == "user" {
+			users = append(users, m.Sender.ID)
+		}
+	}
+	if containerType == "chat" {
+		chats = append(chats, containerID)
+	}
+	resolveNames(r, users, chats)
+}
+
+// resolveNames looks up user and chat names. Names are a convenience, so
+// failures are reported on stderr and the IDs are left unnamed.
+func resolveNames(r *names.Resolver, users, chats []string) {
+	if err := r.Resolve(users, chats); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", strings.ReplaceAll(err.Error(), "\n", "\nWarning: "))
+	}
+}
+
+// nameSenders sets the resolved sender names of messages and their replies
+func nameSenders(messages []api.OutputMessage, r *names.Resolver) {
+	for i := range messages {
+		if s := messages[i].Sender; s != nil && s.Type == "user" {
+			s.Name = r.User(s.ID)
+		}
+		nameSenders(messages[i].Replies, r)
+	}
+}
+
 // formatMessageTime converts Unix milliseconds to ISO 8601
 func formatMessageTime(ms string) string {
 	if ms == "" {
@@ -211,7 +316,6 @@ var (
 	msgResourceMessageID string
 	msgResourceFileKey   string
 	msgResourceType      string
-	msgResourceOutput    string
 )
 
 var msgResourceCmd = &cobra.Command{
@@ -223,8 +327,8 @@ The file_key can be found in the message content JSON returned by 'lark msg hist
 For image messages, use --type image. For file, audio, and video messages, use --type file.
 
 Examples:
-  lark msg resource --message-id om_xxx --file-key img_v2_xxx --type image --output ./image.png
-  lark msg resource --message-id om_xxx --file-key file_v2_xxx --type file --output ./video.mp4`,
+  lark msg resource --message-id om_xxx --file-key img_v2_xxx --type image --out ./image.png
+  lark msg resource --message-id om_xxx --file-key file_v2_xxx --type file --out ./video.mp4`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if msgResourceMessageID == "" {
 			output.Fatalf("VALIDATION_ERROR", "message-id is required")
@@ -238,8 +342,9 @@ Examples:
 		if msgResourceType != "image" && msgResourceType != "file" {
 			output.Fatalf("VALIDATION_ERROR", "type must be 'image' or 'file'")
 		}
-		if msgResourceOutput == "" {
-			output.Fatalf("VALIDATION_ERROR", "output is required")
+		outputPath := outPath(cmd)
+		if outputPath == "" {
+			output.Fatalf("VALIDATION_ERROR", "out is required")
 		}
 
 		client := api.NewClient()
@@ -252,7 +357,7 @@ Examples:
 		defer body.Close()
 
 		// Create output file
-		outFile, err := os.Create(msgResourceOutput)
+		outFile, err := os.Create(outputPath)
 		if err != nil {
 			output.Fatalf("FILE_ERROR", "failed to create output file: %v", err)
 		}
@@ -269,7 +374,7 @@ Examples:
 			"success":       true,
 			"message_id":    msgResourceMessageID,
 			"file_key":      msgResourceFileKey,
-			"output_path":   msgResourceOutput,
+			"output_path":   outputPath,
 			"content_type":  contentType,
 			"bytes_written": bytesWritten,
 		}
@@ -287,6 +392,12 @@ var (
 	msgSendRootID   string
 	msgSendParentID string
 	msgSendMsgType  string
+	msgSendCard     string
+	msgSendVars     []string
+	msgSendVarsFile string
+	msgSendFiles    []string
+	msgSendTemplate string
+	msgSendQuiet    bool
 )
 
 var msgSendCmd = &cobra.Command{
@@ -295,9 +406,23 @@ var msgSendCmd = &cobra.Command{
 	Long: `Send a message to a user or chat as the bot.
 
 Message format:
-- Markdown-lite (default): Use --text with **bold**, *italic*, [text](url), and @{ou_xxx} mentions
+- Markdown (default): --text is CommonMark with ~~strike~~, <u>underline</u>,
+  @{ou_xxx} and @all mentions and :SMILE: emoji. Code blocks, dividers and
+  styled text become native post elements; lists and quotes are sent as
+  markdown blocks. A leading "# Title" becomes the post title
 - Images: Use --image and place {{image}} in --text to position them
-- Message type: post (default) or text (plain)
+- Files: Use --file to attach files (up to 30 MB each). Each is sent as its
+  own file, video (.mp4) or audio (.opus) message after the text. Videos sent
+  with --text or --image are embedded in the post instead, at {{video}}
+  placeholders or at the end
+- Message type: post (default), text (plain) or interactive (card)
+- Cards: Use --card with a YAML/JSON template and --var/--vars for its
+  variables (see 'lark msg card preview --help' for the template format)
+- Templates: Use --template with a markdown file in Go template syntax
+  ({{.version}}) instead of --text; --var/--vars fill its variables and
+  {{image}} and {{video}} are placeholders as in --text
+
+To send later, use 'lark msg schedule' with the same flags.
 
 Examples:
 	# Send text to user
@@ -324,87 +449,332 @@ Examples:
 	# Image only
 	lark msg send --to oc_xxx --image ./screenshot.png
 
+	# Share build artifacts
+	lark msg send --to oc_xxx --text "Build 1234 artifacts" --file ./report.pdf --file ./app.zip
+
+	# Video in a post
+	lark msg send --to oc_xxx --text "Demo:\n{{video}}" --file ./demo.mp4
+
 	# Reply in thread
 	lark msg send --to oc_xxx --parent-id om_xxx --text "Replying here"
 
 	# Reply inside an existing thread
-	lark msg send --to oc_xxx --root-id om_root --parent-id om_parent --text "Follow-up"`,
+	lark msg send --to oc_xxx --root-id om_root --parent-id om_parent --text "Follow-up"
+
+	# Interactive card from a template
+	lark msg send --to oc_xxx --card deploy.yaml --var service=api --var version=v1.4.2
+
+	# Markdown message from a template
+	lark msg send --to oc_xxx --template release.md --var version=1.2.3 --image ./chart.png`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if msgSendTo == "" {
-			output.Fatalf("VALIDATION_ERROR", "--to is required")
+		m := outgoingMessageFromFlags(cmd)
+		for _, imagePath := range m.Images {
+			checkUploadSize("image", imagePath, api.MaxMessageImageSize)
 		}
-		if msgSendText == "" && len(msgSendImages) == 0 {
-			output.Fatalf("VALIDATION_ERROR", "--text or --image is required")
+		for _, filePath := range m.Files {
+			checkUploadSize("file", filePath, api.MaxMessageFileSize)
 		}
-		if msgSendMsgType != "post" && msgSendMsgType != "text" {
-			output.Fatalf("VALIDATION_ERROR", "--msg-type must be 'post' or 'text'")
+
+		var progress io.Writer
+		if !msgSendQuiet {
+			progress = os.Stderr
 		}
-		if msgSendMsgType == "text" && len(msgSendImages) > 0 {
-			output.Fatalf("VALIDATION_ERROR", "--image is only supported with --msg-type post")
+		result, err := deliverMessage(api.NewClient(), m, progress)
+		if err != nil {
+			fatalSendError(err)
 		}
-		if msgSendMsgType == "text" && msgSendText == "" {
-			output.Fatalf("VALIDATION_ERROR", "--text is required with --msg-type text")
+		output.JSON(result)
+	},
+}
+
+// outgoingMessage is a message as msg send sends it. msg schedule stores
+// one in the outbox for msg outbox run to send later.
+type outgoingMessage struct {
+	To       string   `json:"to"`
+	ToType   string   `json:"to_type"`
+	MsgType  string   `json:"msg_type"`
+	Text     string   `json:"text,omitempty"` // with escapes processed and any template rendered
+	Images   []string `json:"images,omitempty"`
+	Files    []string `json:"files,omitempty"`
+	Card     string   `json:"card,omitempty"`
+	Vars     []string `json:"vars,omitempty"`
+	VarsFile string   `json:"vars_file,omitempty"`
+	RootID   string   `json:"root_id,omitempty"`
+	ParentID string   `json:"parent_id,omitempty"`
+}
+
+// outgoingMessageFromFlags validates the msg send flags, which msg schedule
+// shares, and returns the message they describe. It exits on invalid flags.
+func outgoingMessageFromFlags(cmd *cobra.Command) *outgoingMessage {
+	if msgSendTo == "" {
+		output.Fatalf("VALIDATION_ERROR", "--to is required")
+	}
+	if msgSendCard != "" && !cmd.Flags().Changed("msg-type") {
+		msgSendMsgType = "interactive"
+	}
+	if msgSendMsgType != "post" && msgSendMsgType != "text" && msgSendMsgType != "interactive" {
+		output.Fatalf("VALIDATION_ERROR", "--msg-type must be 'post', 'text' or 'interactive'")
+	}
+
+	text := unescapeString(msgSendText)
+	if msgSendTemplate != "" {
+		if msgSendText != "" || msgSendCard != "" {
+			output.Fatalf("VALIDATION_ERROR", "--template can't be used with --text or --card")
 		}
-		if msgSendRootID != "" && msgSendParentID == "" {
-			output.Fatalf("VALIDATION_ERROR", "--parent-id is required when --root-id is set")
+		var err error
+		if text, err = renderMessageTemplate(msgSendTemplate, msgSendVars, msgSendVarsFile); err != nil {
+			fatalSendError(err)
 		}
+	}
 
-		// Auto-detect receive_id_type if not specified
-		receiveIDType := msgSendToType
-		if receiveIDType == "" {
-			receiveIDType = detectIDType(msgSendTo)
+	if msgSendMsgType == "interactive" {
+		if msgSendCard == "" {
+			output.Fatalf("VALIDATION_ERROR", "--card is required with --msg-type interactive")
+		}
+		if text != "" || len(msgSendImages) > 0 || len(msgSendFiles) > 0 {
+			output.Fatalf("VALIDATION_ERROR", "--text, --image and --file can't be used with --card; put text and images in the template")
 		}
+	} else if msgSendCard != "" {
+		output.Fatalf("VALIDATION_ERROR", "--card is only supported with --msg-type interactive")
+	} else if text == "" && len(msgSendImages) == 0 && len(msgSendFiles) == 0 {
+		output.Fatalf("VALIDATION_ERROR", "--text, --template, --image or --file is required")
+	}
+	if msgSendMsgType == "text" && len(msgSendImages) > 0 {
+		output.Fatalf("VALIDATION_ERROR", "--image is only supported with --msg-type post")
+	}
+	if msgSendMsgType == "text" && text == "" {
+		output.Fatalf("VALIDATION_ERROR", "--text is required with --msg-type text")
+	}
+	if msgSendRootID != "" && msgSendParentID == "" {
+		output.Fatalf("VALIDATION_ERROR", "--parent-id is required when --root-id is set")
+	}
+	if msgSendMsgType == "post" {
+		// Caught here rather than after uploading, and before msg schedule
+		// queues a message that can't be sent
+		videos := 0
+		for _, f := range msgSendFiles {
+			if api.MessageFileType(f) == "mp4" {
+				videos++
+			}
+		}
+		if strings.Count(text, imagePlaceholder) > len(msgSendImages) {
+			output.Fatalf("VALIDATION_ERROR", "not enough images for %s placeholders", imagePlaceholder)
+		}
+		if strings.Count(text, videoPlaceholder) > videos {
+			output.Fatalf("VALIDATION_ERROR", "not enough videos for %s placeholders", videoPlaceholder)
+		}
+	}
 
-		client := api.NewClient()
-		imageKeys := make([]string, 0, len(msgSendImages))
-		for _, imagePath := range msgSendImages {
-			imageKey, err := client.UploadMessageImage(imagePath)
-			if err != nil {
-				if errors.Is(err, os.ErrNotExist) {
-					output.Fatalf("FILE_ERROR", "image not found: %s", imagePath)
-				}
-				output.Fatal("API_ERROR", err)
+	// Auto-detect receive_id_type if not specified
+	toType := msgSendToType
+	if toType == "" {
+		toType = detectIDType(msgSendTo)
+	}
+
+	m := &outgoingMessage{
+		To:       msgSendTo,
+		ToType:   toType,
+		MsgType:  msgSendMsgType,
+		Text:     text,
+		Images:   msgSendImages,
+		Files:    msgSendFiles,
+		Card:     msgSendCard,
+		RootID:   msgSendRootID,
+		ParentID: msgSendParentID,
+	}
+	if m.Card != "" {
+		m.Vars = msgSendVars
+		m.VarsFile = msgSendVarsFile
+	}
+	return m
+}
+
+// sendError is an error from sending a message, with the code to report it
+// under
+type sendError struct {
+	code string
+	err  error
+}
+
+func (e *sendError) Error() string { return e.err.Error() }
+func (e *sendError) Unwrap() error { return e.err }
+
+// fatalSendError reports an error from building or sending a message and
+// exits
+func fatalSendError(err error) {
+	var se *sendError
+	if errors.As(err, &se) {
+		output.Fatal(se.code, se.err)
+	}
+	output.Fatal("API_ERROR", err)
+}
+
+// deliverMessage uploads a message's images and files and sends it. Files
+// follow the message as messages of their own, so on error the result
+// holds whatever was sent before it. Upload progress goes to progress if
+// it isn't nil.
+func deliverMessage(client *api.Client, m *outgoingMessage, progress io.Writer) (*api.OutputSendMessage, error) {
+	result := &api.OutputSendMessage{}
+
+	imageKeys := make([]string, 0, len(m.Images))
+	for _, imagePath := range m.Images {
+		imageKey, err := client.UploadMessageImage(imagePath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return result, &sendError{"FILE_ERROR", fmt.Errorf("image not found: %s", imagePath)}
 			}
-			imageKeys = append(imageKeys, imageKey)
+			return result, err
 		}
+		imageKeys = append(imageKeys, imageKey)
+	}
 
+	// Upload files. Videos are embedded in a post; everything else
+	// follows as its own message.
+	msgType := m.MsgType
+	hasMessage := msgType == "interactive" || m.Text != "" || len(imageKeys) > 0
+	var videoKeys []string
+	var attachments []msgAttachment
+	for _, filePath := range m.Files {
+		fileType := api.MessageFileType(filePath)
+		fileKey, err := client.UploadMessageFile(filePath, api.UploadFileOptions{
+			FileType: fileType,
+			Progress: progress,
+		})
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return result, &sendError{"FILE_ERROR", fmt.Errorf("file not found: %s", filePath)}
+			}
+			return result, err
+		}
+		if fileType == "mp4" && msgType == "post" && hasMessage {
+			videoKeys = append(videoKeys, fileKey)
+			continue
+		}
+		attachments = append(attachments, msgAttachment{
+			name:    filepath.Base(filePath),
+			fileKey: fileKey,
+			msgType: fileMessageType(fileType),
+		})
+	}
+
+	send := func(sent api.OutputSentMessage, content string) error {
+		var resp *api.SendMessageResponse
+		var err error
+		if m.ParentID != "" {
+			resp, err = client.ReplyMessage(m.ParentID, sent.MsgType, content, m.RootID, true)
+		} else {
+			resp, err = client.SendMessage(m.ToType, m.To, sent.MsgType, content)
+		}
+		if err != nil {
+			return err
+		}
+		if result.ChatID == "" {
+			result.ChatID = resp.Data.ChatID
+		}
+		sent.MessageID = resp.Data.MessageID
+		sent.CreateTime = formatMessageTime(resp.Data.CreateTime)
+		result.Messages = append(result.Messages, sent)
+		return nil
+	}
+
+	if hasMessage {
 		// Build message content
-		msgType := msgSendMsgType
 		var content string
 		var err error
-		if msgType == "text" {
-			content, err = buildTextContent(msgSendText)
-			if err != nil {
-				output.Fatal("VALIDATION_ERROR", err)
+		if msgType == "interactive" {
+			var c map[string]interface{}
+			if c, err = loadCard(m.Card, m.Vars, m.VarsFile, client.UploadMessageImage); err != nil {
+				return result, err
 			}
+			var data []byte
+			data, err = json.Marshal(c)
+			content = string(data)
+		} else if msgType == "text" {
+			content, err = buildTextContent(m.Text)
 		} else {
-			content, err = buildMarkdownPostContentWithImages(msgSendText, imageKeys)
-			if err != nil {
-				output.Fatal("VALIDATION_ERROR", err)
-			}
+			content, err = buildMarkdownPostContentWithMedia(m.Text, imageKeys, videoKeys)
+		}
+		if err != nil {
+			return result, &sendError{"VALIDATION_ERROR", err}
 		}
 
-		// Send message
-		var resp *api.SendMessageResponse
-		if msgSendParentID != "" {
-			resp, err = client.ReplyMessage(msgSendParentID, msgType, content, msgSendRootID, true)
-		} else {
-			resp, err = client.SendMessage(receiveIDType, msgSendTo, msgType, content)
+		if err := send(api.OutputSentMessage{MsgType: msgType}, content); err != nil {
+			return result, err
 		}
+	}
+	for _, a := range attachments {
+		content, err := buildFileContent(a.fileKey)
 		if err != nil {
-			output.Fatal("API_ERROR", err)
+			return result, &sendError{"VALIDATION_ERROR", err}
 		}
-
-		// Format output
-		result := api.OutputSendMessage{
-			Success:    true,
-			MessageID:  resp.Data.MessageID,
-			ChatID:     resp.Data.ChatID,
-			CreateTime: formatMessageTime(resp.Data.CreateTime),
+		err = send(api.OutputSentMessage{MsgType: a.msgType, FileName: a.name, FileKey: a.fileKey}, content)
+		if err != nil {
+			return result, err
 		}
+	}
 
-		output.JSON(result)
-	},
+	result.Success = true
+	result.MessageID = result.Messages[0].MessageID
+	result.CreateTime = result.Messages[0].CreateTime
+	if len(m.Files) == 0 {
+		result.Messages = nil
+	}
+	return result, nil
+}
+
+// addSendFlags adds the flags that describe a message, shared by msg send
+// and msg schedule
+func addSendFlags(c *cobra.Command) {
+	c.Flags().StringVar(&msgSendTo, "to", "", "Recipient ID (user ID, open_id, email, or chat_id) (required)")
+	c.Flags().StringVar(&msgSendToType, "to-type", "", "Recipient ID type: open_id, user_id, email, chat_id (auto-detected if not specified)")
+	c.Flags().StringVar(&msgSendText, "text", "", "Message text (markdown). Use {{image}} to place images")
+	c.Flags().StringVar(&msgSendTemplate, "template", "", "Markdown message template file (- for stdin), used instead of --text")
+	c.Flags().StringSliceVar(&msgSendImages, "image", nil, "Image file path (repeatable)")
+	c.Flags().StringArrayVar(&msgSendFiles, "file", nil, "File to attach: pdf, doc, xls, ppt, mp4, opus or any other file (repeatable)")
+	c.Flags().StringVar(&msgSendMsgType, "msg-type", "post", "Message type: post (default), text or interactive")
+	c.Flags().StringVar(&msgSendCard, "card", "", "Card template file (YAML or JSON, - for stdin); implies --msg-type interactive")
+	c.Flags().StringArrayVar(&msgSendVars, "var", nil, "Template variable as key=value (repeatable)")
+	c.Flags().StringVar(&msgSendVarsFile, "vars", "", "YAML or JSON file of template variables")
+	c.Flags().StringVar(&msgSendParentID, "parent-id", "", "Parent message ID to reply to (optional)")
+	c.Flags().StringVar(&msgSendRootID, "root-id", "", "Root message ID for thread replies (optional)")
+}
+
+// msgAttachment is a file sent as its own message
+type msgAttachment struct {
+	name    string
+	fileKey string
+	msgType string
+}
+
+// fileMessageType returns the message type for an uploaded file_type
+func fileMessageType(fileType string) string {
+	switch fileType {
+	case "mp4":
+		return "media"
+	case "opus":
+		return "audio"
+	}
+	return "file"
+}
+
+// checkUploadSize exits unless path is a non-empty file within limit
+func checkUploadSize(kind, path string, limit int64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			output.Fatalf("FILE_ERROR", "%s not found: %s", kind, path)
+		}
+		output.Fatal("FILE_ERROR", err)
+	}
+	switch {
+	case info.IsDir():
+		output.Fatalf("VALIDATION_ERROR", "%s is a directory: %s", kind, path)
+	case info.Size() == 0:
+		output.Fatalf("VALIDATION_ERROR", "%s is empty: %s", kind, path)
+	case info.Size() > limit:
+		output.Fatalf("VALIDATION_ERROR", "%s %s is %s, more than the %s limit",
+			kind, path, api.FormatSize(info.Size()), api.FormatSize(limit))
+	}
 }
 
 // --- msg react ---
@@ -416,6 +786,7 @@ var (
 	msgReactListMessageID    string
 	msgReactListReactionID   string
 	msgReactListLimit        int
+	msgReactListResolve      bool
 	msgReactRemoveMessageID  string
 	msgReactRemoveReactionID string
 )
@@ -476,7 +847,11 @@ var msgReactListCmd = &cobra.Command{
 Examples:
   lark msg react list --message-id om_xxx
   lark msg react list --message-id om_xxx --reaction SMILE
-  lark msg react list --message-id om_xxx --limit 50`,
+  lark msg react list --message-id om_xxx --limit 50
+  lark msg react list --message-id om_xxx --resolve-names
+
+--resolve-names adds the name of each user who reacted, looked up through
+the contact API and cached for a day.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if msgReactListMessageID == "" {
 			output.Fatalf("VALIDATION_ERROR", "message-id is required")
@@ -527,6 +902,20 @@ Examples:
 			outputReactions[i] = convertMessageReaction(r)
 		}
 
+		if msgReactListResolve {
+			resolver := names.NewResolver(client)
+			var users []string
+			for _, r := range outputReactions {
+				users = append(users, r.OperatorID)
+			}
+			resolveNames(resolver, users, nil)
+			for i := range outputReactions {
+				if outputReactions[i].OperatorType == "user" {
+					outputReactions[i].OperatorName = resolver.User(outputReactions[i].OperatorID)
+				}
+			}
+		}
+
 		result := api.OutputMessageReactionList{
 			MessageID: msgReactListMessageID,
 			Reactions: outputReactions,
@@ -791,25 +1180,40 @@ func detectIDType(id string) string {
 	return "open_id"
 }
 
-// unescapeString processes escape sequences like \n, \t, \r, etc.
-// Users can send literal backslash-n by escaping as \\n
+// unescapeString processes the escape sequences \n, \t, \r, \" and \\.
+// Users can send literal backslash-n by escaping as \\n. Other backslashes
+// are kept, so markdown escapes like \* reach the markdown parser.
 func unescapeString(s string) string {
-	// Wrap string in quotes and use strconv.Unquote to process escape sequences
-	// This handles \n -> newline, \t -> tab, \r -> carriage return, \\ -> backslash, etc.
-	quoted := `"` + s + `"`
-	unquoted, err := strconv.Unquote(quoted)
-	if err != nil {
-		// If unquoting fails (e.g., malformed escape), return original string
+	if !strings.Contains(s, `\`) {
 		return s
 	}
-	return unquoted
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(s[i+1])
+		default:
+			b.WriteString(s[i : i+2])
+		}
+		i++
+	}
+	return b.String()
 }
 
 // buildTextContent creates JSON content for text messages.
 func buildTextContent(text string) (string, error) {
-	unescapedText := unescapeString(text)
 	payload := map[string]string{
-		"text": unescapedText,
+		"text": text,
 	}
 	content, err := json.Marshal(payload)
 	if err != nil {
@@ -818,89 +1222,68 @@ func buildTextContent(text string) (string, error) {
 	return string(content), nil
 }
 
-type postElement struct {
-	tag    string
-	text   string
-	href   string
-	userID string
-	bold   bool
-	italic bool
-}
+const (
+	imagePlaceholder = "{{image}}"
+	videoPlaceholder = "{{video}}"
+)
 
-const imagePlaceholder = "{{image}}"
+// placeholderPattern matches image and video placeholders in post text
+var placeholderPattern = regexp.MustCompile(`\{\{(image|video)\}\}`)
 
-// buildMarkdownPostContent creates JSON content for markdown-lite post messages.
+// buildMarkdownPostContent creates JSON content for markdown post messages.
 func buildMarkdownPostContent(text string) (string, error) {
 	return buildMarkdownPostContentWithImages(text, nil)
 }
 
 // buildMarkdownPostContentWithImages creates JSON content with image placeholders.
 func buildMarkdownPostContentWithImages(text string, imageKeys []string) (string, error) {
-	unescapedText := unescapeString(text)
-	var lines []string
-	if unescapedText != "" {
-		lines = strings.Split(unescapedText, "\n")
-	}
-	contentLines := make([][]map[string]interface{}, 0, len(lines)+len(imageKeys))
-	usedImages := 0
-
-	for _, line := range lines {
-		if strings.Contains(line, imagePlaceholder) {
-			segments := strings.Split(line, imagePlaceholder)
-			for index, segment := range segments {
-				if segment != "" {
-					elements := parseMarkdownLine(segment)
-					if len(elements) == 0 {
-						elements = append(elements, postElement{tag: "text", text: ""})
-					}
-					contentLines = append(contentLines, buildPostElements(elements))
-				}
-				if index < len(segments)-1 {
-					if usedImages >= len(imageKeys) {
-						return "", fmt.Errorf("not enough images for %s placeholders", imagePlaceholder)
-					}
-					contentLines = append(contentLines, []map[string]interface{}{
-						{
-							"tag":       "img",
-							"image_key": imageKeys[usedImages],
-						},
-					})
-					usedImages++
-				}
+	return buildMarkdownPostContentWithMedia(text, imageKeys, nil)
+}
+
+// buildMarkdownPostContentWithMedia creates JSON content with image and video
+// placeholders. Each placeholder takes the next key of its kind; leftover
+// images and then videos are appended on their own lines.
+func buildMarkdownPostContentWithMedia(text string, imageKeys, videoKeys []string) (string, error) {
+	usedImages, usedVideos := 0, 0
+	var embedErr error
+
+	// Placeholders become markdown images, which the converter puts on
+	// lines of their own
+	source := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if placeholder == imagePlaceholder {
+			if usedImages >= len(imageKeys) {
+				embedErr = fmt.Errorf("not enough images for %s placeholders", imagePlaceholder)
+				return placeholder
 			}
-			continue
+			usedImages++
+			return "![](" + imageKeys[usedImages-1] + ")"
 		}
-
-		elements := parseMarkdownLine(line)
-		if len(elements) == 0 {
-			elements = append(elements, postElement{tag: "text", text: ""})
+		if usedVideos >= len(videoKeys) {
+			embedErr = fmt.Errorf("not enough videos for %s placeholders", videoPlaceholder)
+			return placeholder
 		}
-		contentLines = append(contentLines, buildPostElements(elements))
+		usedVideos++
+		return "![](" + videoKeys[usedVideos-1] + ")"
+	})
+	if embedErr != nil {
+		return "", embedErr
 	}
 
-	for usedImages < len(imageKeys) {
-		contentLines = append(contentLines, []map[string]interface{}{
-			{
-				"tag":       "img",
-				"image_key": imageKeys[usedImages],
-			},
-		})
-		usedImages++
+	post := markdown.ToPost(source)
+	for ; usedImages < len(imageKeys); usedImages++ {
+		post.Content = append(post.Content, []markdown.Element{{Tag: "img", ImageKey: imageKeys[usedImages]}})
+	}
+	for ; usedVideos < len(videoKeys); usedVideos++ {
+		post.Content = append(post.Content, []markdown.Element{{Tag: "media", FileKey: videoKeys[usedVideos]}})
 	}
 
-	if len(contentLines) == 0 {
+	if len(post.Content) == 0 && post.Title == "" {
 		return "", fmt.Errorf("message content cannot be empty")
 	}
 
-	content := map[string]interface{}{
-		"zh_cn": map[string]interface{}{
-			"title":   "",
-			"content": contentLines,
-		},
-		"en_us": map[string]interface{}{
-			"title":   "",
-			"content": contentLines,
-		},
+	content := map[string]*markdown.Post{
+		"zh_cn": post,
+		"en_us": post,
 	}
 
 	jsonBytes, err := json.Marshal(content)
@@ -910,6 +1293,15 @@ func buildMarkdownPostContentWithImages(text string, imageKeys []string) (string
 	return string(jsonBytes), nil
 }
 
+// buildFileContent creates JSON content for file, media and audio messages
+func buildFileContent(fileKey string) (string, error) {
+	jsonBytes, err := json.Marshal(map[string]string{"file_key": fileKey})
+	if err != nil {
+		return "", fmt.Errorf("failed to build file content: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
 func convertMessageReaction(r api.MessageReaction) api.OutputMessageReactionItem {
 	item := api.OutputMessageReactionItem{
 		ReactionID: r.ReactionID,
@@ -927,133 +1319,6 @@ func convertMessageReaction(r api.MessageReaction) api.OutputMessageReactionItem
 	return item
 }
 
-func parseMarkdownLine(line string) []postElement {
-	var elements []postElement
-	var buffer strings.Builder
-
-	flushText := func(text string) {
-		if text == "" {
-			return
-		}
-		elements = append(elements, postElement{tag: "text", text: text})
-	}
-
-	i := 0
-	for i < len(line) {
-		if strings.HasPrefix(line[i:], "@{") {
-			closing := strings.Index(line[i+2:], "}")
-			if closing >= 0 {
-				flushText(buffer.String())
-				buffer.Reset()
-				userID := line[i+2 : i+2+closing]
-				elements = append(elements, postElement{tag: "at", userID: userID})
-				i += closing + 3
-				continue
-			}
-		}
-
-		if line[i] == '[' {
-			closeBracket := strings.Index(line[i+1:], "]")
-			if closeBracket >= 0 {
-				closeBracket += i + 1
-				if closeBracket+1 < len(line) && line[closeBracket+1] == '(' {
-					closeParen := strings.Index(line[closeBracket+2:], ")")
-					if closeParen >= 0 {
-						closeParen += closeBracket + 2
-						linkText := line[i+1 : closeBracket]
-						linkURL := line[closeBracket+2 : closeParen]
-						flushText(buffer.String())
-						buffer.Reset()
-						elements = append(elements, postElement{tag: "a", text: linkText, href: linkURL})
-						i = closeParen + 1
-						continue
-					}
-				}
-			}
-		}
-
-		if strings.HasPrefix(line[i:], "**") {
-			closing := strings.Index(line[i+2:], "**")
-			if closing >= 0 {
-				flushText(buffer.String())
-				buffer.Reset()
-				boldText := line[i+2 : i+2+closing]
-				if boldText != "" {
-					elements = append(elements, postElement{tag: "text", text: boldText, bold: true})
-				}
-				i += closing + 4
-				continue
-			}
-		}
-
-		if line[i] == '*' {
-			closing := strings.Index(line[i+1:], "*")
-			if closing >= 0 {
-				flushText(buffer.String())
-				buffer.Reset()
-				italicText := line[i+1 : i+1+closing]
-				if italicText != "" {
-					elements = append(elements, postElement{tag: "text", text: italicText, italic: true})
-				}
-				i += closing + 2
-				continue
-			}
-		}
-
-		buffer.WriteByte(line[i])
-		i++
-	}
-
-	flushText(buffer.String())
-	return elements
-}
-
-func buildPostElements(elements []postElement) []map[string]interface{} {
-	content := make([]map[string]interface{}, 0, len(elements))
-	for _, elem := range elements {
-		switch elem.tag {
-		case "text":
-			entry := map[string]interface{}{
-				"tag":  "text",
-				"text": elem.text,
-			}
-			style := buildPostTextStyle(elem.bold, elem.italic)
-			if len(style) > 0 {
-				entry["style"] = style
-			}
-			content = append(content, entry)
-		case "a":
-			entry := map[string]interface{}{
-				"tag":  "a",
-				"text": elem.text,
-				"href": elem.href,
-			}
-			style := buildPostTextStyle(elem.bold, elem.italic)
-			if len(style) > 0 {
-				entry["style"] = style
-			}
-			content = append(content, entry)
-		case "at":
-			content = append(content, map[string]interface{}{
-				"tag":     "at",
-				"user_id": elem.userID,
-			})
-		}
-	}
-	return content
-}
-
-func buildPostTextStyle(isBold, isItalic bool) []string {
-	var style []string
-	if isBold {
-		style = append(style, "bold")
-	}
-	if isItalic {
-		style = append(style, "italic")
-	}
-	return style
-}
-
 // --- msg recall ---
 
 var msgRecallCmd = &cobra.Command{
@@ -1083,6 +1348,26 @@ Examples:
 }
 
 func init() {
+	output.RegisterTable(api.OutputMessageList{}, output.Table{
+		Items: "messages",
+		Columns: []output.Column{
+			{Header: "MESSAGE_ID", Path: "message_id"},
+			{Header: "TIME", Path: "create_time"},
+			{Header: "SENDER", Path: "sender.id"},
+			{Header: "TYPE", Path: "msg_type"},
+			{Header: "CONTENT", Path: "content"},
+		},
+	})
+	output.RegisterTable(api.OutputMessageReactionList{}, output.Table{
+		Items: "reactions",
+		Columns: []output.Column{
+			{Header: "REACTION_ID", Path: "reaction_id"},
+			{Header: "EMOJI", Path: "emoji_type"},
+			{Header: "OPERATOR_ID", Path: "operator_id"},
+			{Header: "TIME", Path: "action_time"},
+		},
+	})
+
 	// msg history flags
 	msgHistoryCmd.Flags().StringVar(&msgHistoryChatID, "chat-id", "", "Chat ID or thread ID (required)")
 	msgHistoryCmd.Flags().StringVar(&msgHistoryType, "type", "chat", "Container type: 'chat' or 'thread'")
@@ -1090,21 +1375,20 @@ func init() {
 	msgHistoryCmd.Flags().StringVar(&msgHistoryEndTime, "end", "", "End time (Unix timestamp or ISO 8601)")
 	msgHistoryCmd.Flags().StringVar(&msgHistorySort, "sort", "", "Sort order: 'asc' or 'desc' (default: asc)")
 	msgHistoryCmd.Flags().IntVar(&msgHistoryLimit, "limit", 0, "Maximum number of messages to retrieve (0 = no limit)")
+	msgHistoryCmd.Flags().StringVar(&msgHistoryFormat, "format", contentFormatRaw, "Content format: raw, text or markdown")
+	msgHistoryCmd.Flags().BoolVar(&msgHistoryThreaded, "threaded", false, "Nest replies under their root message")
+	msgHistoryCmd.Flags().BoolVar(&msgHistoryTranscript, "transcript", false, "Output the conversation as a markdown transcript")
+	msgHistoryCmd.Flags().BoolVar(&msgHistoryResolve, "resolve-names", false, "Add sender and chat names")
 
 	// msg resource flags
 	msgResourceCmd.Flags().StringVar(&msgResourceMessageID, "message-id", "", "Message ID containing the resource (required)")
 	msgResourceCmd.Flags().StringVar(&msgResourceFileKey, "file-key", "", "Resource file key from message content (required)")
 	msgResourceCmd.Flags().StringVar(&msgResourceType, "type", "", "Resource type: 'image' or 'file' (required)")
-	msgResourceCmd.Flags().StringVar(&msgResourceOutput, "output", "", "Output file path (required)")
+	addOutPathFlag(msgResourceCmd, "", "Output file path (required)")
 
 	// msg send flags
-	msgSendCmd.Flags().StringVar(&msgSendTo, "to", "", "Recipient ID (user ID, open_id, email, or chat_id) (required)")
-	msgSendCmd.Flags().StringVar(&msgSendToType, "to-type", "", "Recipient ID type: open_id, user_id, email, chat_id (auto-detected if not specified)")
-	msgSendCmd.Flags().StringVar(&msgSendText, "text", "", "Message text (markdown-lite). Use {{image}} to place images")
-	msgSendCmd.Flags().StringSliceVar(&msgSendImages, "image", nil, "Image file path (repeatable)")
-	msgSendCmd.Flags().StringVar(&msgSendMsgType, "msg-type", "post", "Message type: post (default) or text")
-	msgSendCmd.Flags().StringVar(&msgSendParentID, "parent-id", "", "Parent message ID to reply to (optional)")
-	msgSendCmd.Flags().StringVar(&msgSendRootID, "root-id", "", "Root message ID for thread replies (optional)")
+	addSendFlags(msgSendCmd)
+	msgSendCmd.Flags().BoolVar(&msgSendQuiet, "quiet", false, "Don't write upload progress to stderr")
 
 	// msg react flags
 	msgReactCmd.Flags().StringVar(&msgReactMessageID, "message-id", "", "Message ID to react to (required)")
@@ -1115,6 +1399,7 @@ func init() {
 	msgReactListCmd.Flags().StringVar(&msgReactListMessageID, "message-id", "", "Message ID to list reactions for (required)")
 	msgReactListCmd.Flags().StringVar(&msgReactListReactionID, "reaction", "", "Emoji type to filter (optional)")
 	msgReactListCmd.Flags().IntVar(&msgReactListLimit, "limit", 0, "Maximum number of reactions to retrieve (0 = no limit)")
+	msgReactListCmd.Flags().BoolVar(&msgReactListResolve, "resolve-names", false, "Add the names of users who reacted")
 
 	// msg react remove flags
 	msgReactRemoveCmd.Flags().StringVar(&msgReactRemoveMessageID, "message-id", "", "Message ID to remove reaction from (required)")
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/card"
+	"github.com/yjwong/lark-cli/internal/output"
+)
+
+var (
+	msgCardPreviewVars     []string
+	msgCardPreviewVarsFile string
+)
+
+var msgCardCmd = &cobra.Command{
+	Use:   "card",
+	Short: "Work with interactive card templates",
+	Long: `Build interactive cards from YAML or JSON templates.
+
+Send a card with 'lark msg send --card <template>'.`,
+}
+
+var msgCardPreviewCmd = &cobra.Command{
+	Use:   "preview <template>",
+	Short: "Render a card template to card JSON without sending it",
+	Long: `Render a card template with variables, validate it against the card schema
+and print the card JSON that 'lark msg send --card' would send. Local images
+are not uploaded; their paths appear in place of image keys.
+
+Templates are YAML or JSON ("-" reads stdin). Either write a complete card
+(with "schema": "2.0") or use the short form:
+
+  header:
+    title: "Deployed {{.service}} {{.version}}"
+    color: green            # blue, green, orange, red, grey, ...
+  elements:
+    - markdown: "**Environment:** {{.env}}"
+    - fields:               # label/value pairs, two per row
+        - {label: Service, value: "{{.service}}"}
+        - {label: Commit, value: "{{.commit}}"}
+    - hr
+    - image: ./chart.png    # uploaded on send; img_xxx keys are used as is
+      alt: Latency
+    - columns:
+        - elements: [{markdown: "**Before**"}]
+        - elements: [{markdown: "**After**"}]
+    - buttons:
+        - {text: View logs, url: "{{.logs_url}}", type: primary}
+        - {text: Roll back, value: {action: rollback}, type: danger}
+    - markdown: "- {{.item}}"
+      each: changes         # repeat for each entry of a list variable
+    - markdown: "Tests failed"
+      if: "{{.failed}}"     # dropped when empty, 0 or false
+
+Elements with a "tag" key are passed through as card components.
+
+Variables come from --vars (a YAML or JSON file) and --var key=value; --var
+wins. Referencing an unset variable is an error.
+
+Examples:
+  lark msg card preview deploy.yaml --var service=api --var version=v1.4.2
+  lark msg card preview deploy.yaml --vars release.json
+  cat card.json | lark msg card preview -`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c := renderCard(args[0], msgCardPreviewVars, msgCardPreviewVarsFile, nil)
+		output.JSON(c)
+	},
+}
+
+// renderCard loads, renders and validates a card template, exiting on
+// error
+func renderCard(path string, assignments []string, varsFile string, resolve card.ImageResolver) map[string]interface{} {
+	c, err := loadCard(path, assignments, varsFile, resolve)
+	if err != nil {
+		fatalSendError(err)
+	}
+	return c
+}
+
+// loadCard loads, renders and validates a card template. Local images are
+// passed to resolve once the card is known to be valid, so nothing is
+// uploaded for a card that would be rejected.
+func loadCard(path string, assignments []string, varsFile string, resolve card.ImageResolver) (map[string]interface{}, error) {
+	tmpl, err := card.ParseFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &sendError{"FILE_ERROR", fmt.Errorf("card template not found: %s", path)}
+		}
+		return nil, &sendError{"VALIDATION_ERROR", err}
+	}
+
+	vars, err := loadTemplateVars(assignments, varsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := tmpl.Render(vars, nil)
+	if err != nil {
+		return nil, &sendError{"VALIDATION_ERROR", err}
+	}
+	if err := card.Validate(c); err != nil {
+		return nil, &sendError{"VALIDATION_ERROR", err}
+	}
+	if resolve == nil {
+		return c, nil
+	}
+
+	c, err = tmpl.Render(vars, resolve)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &sendError{"FILE_ERROR", err}
+		}
+		return nil, &sendError{"API_ERROR", err}
+	}
+	return c, nil
+}
+
+// loadTemplateVars reads --vars and applies --var assignments over it
+func loadTemplateVars(assignments []string, varsFile string) (card.Vars, error) {
+	vars := card.Vars{}
+	if varsFile != "" {
+		var err error
+		if vars, err = card.ReadVars(varsFile); err != nil {
+			return nil, &sendError{"FILE_ERROR", err}
+		}
+	}
+	for _, a := range assignments {
+		if err := vars.ParseVar(a); err != nil {
+			return nil, &sendError{"VALIDATION_ERROR", err}
+		}
+	}
+	return vars, nil
+}
+
+func init() {
+	msgCardPreviewCmd.Flags().StringArrayVar(&msgCardPreviewVars, "var", nil, "Template variable as key=value (repeatable)")
+	msgCardPreviewCmd.Flags().StringVar(&msgCardPreviewVarsFile, "vars", "", "YAML or JSON file of template variables")
+
+	msgCardCmd.AddCommand(msgCardPreviewCmd)
+	msgCmd.AddCommand(msgCardCmd)
+}
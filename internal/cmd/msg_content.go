@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/markdown"
+	"github.com/yjwong/lark-cli/internal/output"
+)
+
+// Formats for received message content
+const (
+	contentFormatRaw      = "raw"
+	contentFormatText     = "text"
+	contentFormatMarkdown = "markdown"
+)
+
+// validateContentFormat exits unless format is raw, text or markdown
+func validateContentFormat(format string) {
+	switch format {
+	case contentFormatRaw, contentFormatText, contentFormatMarkdown:
+	default:
+		output.Fatalf("VALIDATION_ERROR", "format must be 'raw', 'text' or 'markdown'")
+	}
+}
+
+// mentionKeyPattern matches mention keys like @_user_1 in text messages
+var mentionKeyPattern = regexp.MustCompile(`@_user_\d+|@_all`)
+
+// messageContent is the union of the content fields of non-post messages
+type messageContent struct {
+	Text      string   `json:"text"`
+	ImageKey  string   `json:"image_key"`
+	FileKey   string   `json:"file_key"`
+	FileName  string   `json:"file_name"`
+	Duration  int      `json:"duration"`
+	ChatID    string   `json:"chat_id"`
+	UserID    string   `json:"user_id"`
+	Template  string   `json:"template"`
+	FromUser  []string `json:"from_user"`
+	ToChatter []string `json:"to_chatters"`
+}
+
+// receivedPost is post or card content as the API returns it: a single
+// language, or one post per language when sent that way
+type receivedPost struct {
+	Title    string               `json:"title"`
+	Content  [][]markdown.Element `json:"content"`
+	Elements [][]markdown.Element `json:"elements"`
+}
+
+type postLocale struct {
+	Title   string               `json:"title"`
+	Content [][]markdown.Element `json:"content"`
+}
+
+// messageAttachments returns the images and files a message carries, for
+// use with 'lark msg resource'
+func messageAttachments(m api.Message) []api.OutputMessageAttachment {
+	if m.Body == nil || m.Deleted {
+		return nil
+	}
+	switch m.MsgType {
+	case "post":
+		post := parsePost(m.Body.Content)
+		if post == nil {
+			return nil
+		}
+		var attachments []api.OutputMessageAttachment
+		for _, line := range post.Content {
+			for _, e := range line {
+				switch e.Tag {
+				case "img":
+					attachments = append(attachments, api.OutputMessageAttachment{Type: "image", Key: e.ImageKey})
+				case "media":
+					attachments = append(attachments, api.OutputMessageAttachment{Type: "file", Key: e.FileKey})
+				}
+			}
+		}
+		return attachments
+	case "image", "file", "audio", "media":
+		var c messageContent
+		if err := json.Unmarshal([]byte(m.Body.Content), &c); err != nil {
+			return nil
+		}
+		if m.MsgType == "image" {
+			return []api.OutputMessageAttachment{{Type: "image", Key: c.ImageKey}}
+		}
+		return []api.OutputMessageAttachment{{Type: "file", Key: c.FileKey, Name: c.FileName}}
+	}
+	return nil
+}
+
+// renderMessageContent renders a message's content JSON as plain text or
+// markdown, with mention keys replaced by names. Content it can't parse is
+// returned as is.
+func renderMessageContent(m api.Message, format string) string {
+	if m.Body == nil {
+		return ""
+	}
+	raw := m.Body.Content
+	if format == contentFormatRaw || m.Deleted {
+		return raw
+	}
+
+	switch m.MsgType {
+	case "text":
+		var c messageContent
+		if err := json.Unmarshal([]byte(raw), &c); err != nil {
+			return raw
+		}
+		return renderPost(textPost(c.Text, m.Mentions), format)
+	case "post", "interactive":
+		post := parsePost(raw)
+		if post == nil {
+			return raw
+		}
+		resolveMentions(post, m.Mentions)
+		return renderPost(post, format)
+	case "merge_forward":
+		return "[Merged and forwarded messages]"
+	}
+
+	var c messageContent
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		return raw
+	}
+	switch m.MsgType {
+	case "image":
+		if format == contentFormatMarkdown {
+			return "![](" + c.ImageKey + ")"
+		}
+		return "[Image]"
+	case "media":
+		if format == contentFormatMarkdown {
+			return "![](" + c.FileKey + ")"
+		}
+		return attachmentLabel("Video", c.FileName)
+	case "file":
+		return attachmentLabel("File", c.FileName)
+	case "audio":
+		if c.Duration > 0 {
+			return fmt.Sprintf("[Audio: %ds]", (c.Duration+500)/1000)
+		}
+		return "[Audio]"
+	case "sticker":
+		return "[Sticker]"
+	case "share_chat":
+		return "[Shared chat: " + c.ChatID + "]"
+	case "share_user":
+		return "[Shared contact: " + c.UserID + "]"
+	case "system":
+		if c.Template == "" {
+			return raw
+		}
+		return strings.NewReplacer(
+			"{from_user}", strings.Join(c.FromUser, ", "),
+			"{to_chatters}", strings.Join(c.ToChatter, ", "),
+		).Replace(c.Template)
+	}
+	return raw
+}
+
+func attachmentLabel(kind, name string) string {
+	if name == "" {
+		return "[" + kind + "]"
+	}
+	return "[" + kind + ": " + name + "]"
+}
+
+func renderPost(post *markdown.Post, format string) string {
+	if format == contentFormatMarkdown {
+		return markdown.FromPost(post)
+	}
+	return markdown.PlainText(post)
+}
+
+// parsePost parses post or card content, picking one language when the
+// content has several
+func parsePost(raw string) *markdown.Post {
+	var p receivedPost
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		return nil
+	}
+	if p.Content != nil || p.Elements != nil {
+		content := p.Content
+		if content == nil {
+			content = p.Elements
+		}
+		return &markdown.Post{Title: p.Title, Content: content}
+	}
+
+	var locales map[string]*postLocale
+	if err := json.Unmarshal([]byte(raw), &locales); err != nil || len(locales) == 0 {
+		return nil
+	}
+	for _, lang := range []string{"en_us", "zh_cn"} {
+		if l := locales[lang]; l != nil {
+			return &markdown.Post{Title: l.Title, Content: l.Content}
+		}
+	}
+	langs := make([]string, 0, len(locales))
+	for lang := range locales {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	if l := locales[langs[0]]; l != nil {
+		return &markdown.Post{Title: l.Title, Content: l.Content}
+	}
+	return nil
+}
+
+// textPost turns a text message into a post, one line per line of text,
+// so both kinds render the same way
+func textPost(text string, mentions []api.MessageMention) *markdown.Post {
+	post := &markdown.Post{}
+	for _, line := range strings.Split(text, "\n") {
+		var elements []markdown.Element
+		start := 0
+		for _, loc := range mentionKeyPattern.FindAllStringIndex(line, -1) {
+			if start < loc[0] {
+				elements = append(elements, markdown.Element{Tag: "text", Text: line[start:loc[0]]})
+			}
+			elements = append(elements, markdown.Element{Tag: "at", UserID: line[loc[0]:loc[1]]})
+			start = loc[1]
+		}
+		elements = append(elements, markdown.Element{Tag: "text", Text: line[start:]})
+		post.Content = append(post.Content, elements)
+	}
+	resolveMentions(post, mentions)
+	return post
+}
+
+// resolveMentions replaces mention keys in at elements with the names the
+// message's mentions give them. A mention of a named user renders as
+// plain @name text.
+func resolveMentions(post *markdown.Post, mentions []api.MessageMention) {
+	names := make(map[string]string, len(mentions))
+	for _, mention := range mentions {
+		names[mention.Key] = mention.Name
+		names[mention.ID] = mention.Name
+	}
+	for _, line := range post.Content {
+		for i, e := range line {
+			if e.Tag != "at" {
+				continue
+			}
+			if e.UserID == "@_all" || e.UserID == "all" {
+				line[i] = markdown.Element{Tag: "at", UserID: "all"}
+				continue
+			}
+			name := e.UserName
+			if n := names[e.UserID]; n != "" {
+				name = n
+			}
+			if name != "" {
+				line[i] = markdown.Element{Tag: "text", Text: "@" + name}
+			} else if strings.HasPrefix(e.UserID, "@_") {
+				// An unknown key is only meaningful as the text it was
+				line[i] = markdown.Element{Tag: "text", Text: e.UserID}
+			}
+		}
+	}
+}
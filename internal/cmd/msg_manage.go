@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/output"
+)
+
+// --- msg edit ---
+
+var (
+	msgEditText    string
+	msgEditImages  []string
+	msgEditMsgType string
+)
+
+var msgEditCmd = &cobra.Command{
+	Use:   "edit <message-id>",
+	Short: "Edit a message the bot sent",
+	Long: `Replace the content of a text or post message the bot sent.
+
+--text is markdown, as with 'lark msg send'; use --image and {{image}} to
+include images in a post. The new content replaces the whole message, and
+the message is marked as edited. Messages can be edited within 14 days and
+at most 20 times.
+
+Examples:
+  lark msg edit om_xxx --text "Deploy is **done**"
+  lark msg edit om_xxx --text "Rollback complete" --msg-type text
+  lark msg edit om_xxx --text "Latency:\n{{image}}" --image ./latency.png`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		messageID := args[0]
+		text := unescapeString(msgEditText)
+		if msgEditMsgType != "post" && msgEditMsgType != "text" {
+			output.Fatalf("VALIDATION_ERROR", "--msg-type must be 'post' or 'text'")
+		}
+		if text == "" && len(msgEditImages) == 0 {
+			output.Fatalf("VALIDATION_ERROR", "--text or --image is required")
+		}
+		if msgEditMsgType == "text" {
+			if len(msgEditImages) > 0 {
+				output.Fatalf("VALIDATION_ERROR", "--image is only supported with --msg-type post")
+			}
+			if text == "" {
+				output.Fatalf("VALIDATION_ERROR", "--text is required with --msg-type text")
+			}
+		}
+		for _, imagePath := range msgEditImages {
+			checkUploadSize("image", imagePath, api.MaxMessageImageSize)
+		}
+
+		client := api.NewClient()
+
+		var content string
+		var err error
+		if msgEditMsgType == "text" {
+			content, err = buildTextContent(text)
+		} else {
+			imageKeys := make([]string, 0, len(msgEditImages))
+			for _, imagePath := range msgEditImages {
+				imageKey, err := client.UploadMessageImage(imagePath)
+				if err != nil {
+					if errors.Is(err, os.ErrNotExist) {
+						output.Fatalf("FILE_ERROR", "image not found: %s", imagePath)
+					}
+					output.Fatal("API_ERROR", err)
+				}
+				imageKeys = append(imageKeys, imageKey)
+			}
+			content, err = buildMarkdownPostContentWithImages(text, imageKeys)
+		}
+		if err != nil {
+			output.Fatal("VALIDATION_ERROR", err)
+		}
+
+		resp, err := client.UpdateMessage(messageID, msgEditMsgType, content)
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+
+		output.JSON(api.OutputEditMessage{
+			Success:    true,
+			MessageID:  messageID,
+			ChatID:     resp.Data.ChatID,
+			UpdateTime: formatMessageTime(resp.Data.UpdateTime),
+		})
+	},
+}
+
+// --- msg forward ---
+
+var (
+	msgForwardTo     string
+	msgForwardToType string
+)
+
+var msgForwardCmd = &cobra.Command{
+	Use:   "forward <message-id>",
+	Short: "Forward a message to a user or chat",
+	Long: `Forward a message to a user or chat as the bot.
+
+The bot must be able to see the message, i.e. be in the chat it was sent in.
+
+Examples:
+  lark msg forward om_xxx --to oc_xxx
+  lark msg forward om_xxx --to alice@example.com`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		toType := forwardRecipient(msgForwardTo, msgForwardToType)
+
+		client := api.NewClient()
+		resp, err := client.ForwardMessage(args[0], toType, msgForwardTo)
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+
+		output.JSON(api.OutputSendMessage{
+			Success:    true,
+			MessageID:  resp.Data.MessageID,
+			ChatID:     resp.Data.ChatID,
+			CreateTime: formatMessageTime(resp.Data.CreateTime),
+		})
+	},
+}
+
+// --- msg merge-forward ---
+
+var (
+	msgMergeForwardTo     string
+	msgMergeForwardToType string
+)
+
+var msgMergeForwardCmd = &cobra.Command{
+	Use:   "merge-forward <message-id>...",
+	Short: "Forward messages as one merged message",
+	Long: `Forward several messages from the same chat to a user or chat as a single
+merged message, the way "Forward > Combine and forward" does in the client.
+
+Messages that couldn't be forwarded (e.g. recalled ones) are listed in
+invalid_message_ids; the rest are still sent.
+
+Examples:
+  lark msg merge-forward om_1 om_2 om_3 --to oc_xxx
+  lark msg merge-forward om_1 om_2 --to ou_xxx`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		toType := forwardRecipient(msgMergeForwardTo, msgMergeForwardToType)
+
+		client := api.NewClient()
+		resp, err := client.MergeForwardMessages(args, toType, msgMergeForwardTo)
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+		if resp.Data.Message == nil {
+			output.Fatalf("API_ERROR", "no messages were forwarded")
+		}
+
+		output.JSON(api.OutputMergeForward{
+			Success:           true,
+			MessageID:         resp.Data.Message.MessageID,
+			ChatID:            resp.Data.Message.ChatID,
+			CreateTime:        formatMessageTime(resp.Data.Message.CreateTime),
+			InvalidMessageIDs: resp.Data.InvalidMessageIDList,
+		})
+	},
+}
+
+// forwardRecipient validates a forward recipient and returns its ID type,
+// detecting it if toType is empty
+func forwardRecipient(to, toType string) string {
+	if to == "" {
+		output.Fatalf("VALIDATION_ERROR", "--to is required")
+	}
+	if toType == "" {
+		return detectIDType(to)
+	}
+	return toType
+}
+
+// --- msg pin / unpin ---
+
+var msgPinCmd = &cobra.Command{
+	Use:   "pin <message-id>",
+	Short: "Pin a message in its chat",
+	Long: `Pin a message to the top of its chat.
+
+Examples:
+  lark msg pin om_xxx`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		messageID := args[0]
+		client := api.NewClient()
+
+		pin, err := client.PinMessage(messageID)
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+
+		result := api.OutputMessagePin{
+			Success:   true,
+			MessageID: messageID,
+		}
+		if pin != nil {
+			result.ChatID = pin.ChatID
+			result.OperatorID = pin.OperatorID
+			result.CreateTime = formatMessageTime(pin.CreateTime)
+		}
+		output.JSON(result)
+	},
+}
+
+var msgUnpinCmd = &cobra.Command{
+	Use:   "unpin <message-id>",
+	Short: "Unpin a message",
+	Long: `Remove a message from its chat's pins.
+
+Examples:
+  lark msg unpin om_xxx`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		messageID := args[0]
+		client := api.NewClient()
+
+		if err := client.UnpinMessage(messageID); err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+
+		output.JSON(map[string]interface{}{
+			"success":    true,
+			"message_id": messageID,
+		})
+	},
+}
+
+// --- msg urgent ---
+
+var (
+	msgUrgentUsers []string
+	msgUrgentType  string
+)
+
+var msgUrgentCmd = &cobra.Command{
+	Use:   "urgent <message-id>",
+	Short: "Buzz users about a message",
+	Long: `Send an urgent notification (buzz) about a message the bot sent.
+
+The users must be in the message's chat. --type app buzzes them in Lark;
+sms and phone also text or call them, and use the tenant's urgent quota.
+Users who couldn't be buzzed are listed in invalid_user_ids.
+
+Examples:
+  lark msg urgent om_xxx --users ou_alice,ou_bob
+  lark msg urgent om_xxx --users ou_oncall --type phone`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(msgUrgentUsers) == 0 {
+			output.Fatalf("VALIDATION_ERROR", "--users is required")
+		}
+		if msgUrgentType != "app" && msgUrgentType != "sms" && msgUrgentType != "phone" {
+			output.Fatalf("VALIDATION_ERROR", "--type must be 'app', 'sms' or 'phone'")
+		}
+
+		// The API takes one ID type for all users; emails and chats can't
+		// be buzzed
+		userIDType := ""
+		for _, id := range msgUrgentUsers {
+			idType := detectIDType(id)
+			if idType != "open_id" && idType != "user_id" {
+				output.Fatalf("VALIDATION_ERROR", "--users takes open_ids or user IDs, not %s", id)
+			}
+			if userIDType != "" && idType != userIDType {
+				output.Fatalf("VALIDATION_ERROR", "--users must all be open_ids or all be user IDs")
+			}
+			userIDType = idType
+		}
+
+		messageID := args[0]
+		client := api.NewClient()
+		invalid, err := client.UrgentMessage(messageID, msgUrgentType, userIDType, msgUrgentUsers)
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+
+		output.JSON(api.OutputMessageUrgent{
+			Success:        true,
+			MessageID:      messageID,
+			Type:           msgUrgentType,
+			Users:          msgUrgentUsers,
+			InvalidUserIDs: invalid,
+		})
+	},
+}
+
+func init() {
+	msgEditCmd.Flags().StringVar(&msgEditText, "text", "", "New message text (markdown). Use {{image}} to place images")
+	msgEditCmd.Flags().StringSliceVar(&msgEditImages, "image", nil, "Image file path (repeatable)")
+	msgEditCmd.Flags().StringVar(&msgEditMsgType, "msg-type", "post", "Message type: post (default) or text")
+
+	msgForwardCmd.Flags().StringVar(&msgForwardTo, "to", "", "Recipient ID (user ID, open_id, email, or chat_id) (required)")
+	msgForwardCmd.Flags().StringVar(&msgForwardToType, "to-type", "", "Recipient ID type: open_id, user_id, email, chat_id (auto-detected if not specified)")
+
+	msgMergeForwardCmd.Flags().StringVar(&msgMergeForwardTo, "to", "", "Recipient ID (user ID, open_id, email, or chat_id) (required)")
+	msgMergeForwardCmd.Flags().StringVar(&msgMergeForwardToType, "to-type", "", "Recipient ID type: open_id, user_id, email, chat_id (auto-detected if not specified)")
+
+	msgUrgentCmd.Flags().StringSliceVar(&msgUrgentUsers, "users", nil, "Users to buzz: open_ids or user IDs, comma-separated (required)")
+	msgUrgentCmd.Flags().StringVar(&msgUrgentType, "type", "app", "How to buzz: app, sms or phone")
+
+	msgCmd.AddCommand(msgEditCmd)
+	msgCmd.AddCommand(msgForwardCmd)
+	msgCmd.AddCommand(msgMergeForwardCmd)
+	msgCmd.AddCommand(msgPinCmd)
+	msgCmd.AddCommand(msgUnpinCmd)
+	msgCmd.AddCommand(msgUrgentCmd)
+}
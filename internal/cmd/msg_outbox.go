@@ -0,0 +1,428 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/config"
+	"github.com/yjwong/lark-cli/internal/outbox"
+	"github.com/yjwong/lark-cli/internal/output"
+	timex "github.com/yjwong/lark-cli/internal/time"
+)
+
+// --- msg schedule ---
+
+var (
+	msgScheduleAt string
+	msgScheduleIn string
+)
+
+var msgScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Queue a message to send later",
+	Long: `Queue a message in the local outbox to be sent at a given time by
+'lark msg outbox run'.
+
+Takes the same flags as 'lark msg send', plus --at or --in for when to send.
+Templates are rendered now, so a missing variable is reported right away.
+Images, files and card templates are read when the message is sent, so
+they must still be in place then.
+
+--at is in your configured timezone unless it has an offset. Times in the
+past are sent on the next run.
+
+Examples:
+  lark msg schedule --at "2026-10-20T09:00" --to oc_xxx --template standup.md
+  lark msg schedule --in 2h --to oc_xxx --text "Deploy window opens now"
+  lark msg schedule --at "2026-10-24T17:00" --to oc_xxx --card release.yaml --var version=1.2.3`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sendAt := parseScheduleTime()
+		m := outgoingMessageFromFlags(cmd)
+		if m.Card == "-" {
+			output.Fatalf("VALIDATION_ERROR", "a card template from stdin can't be scheduled; save it to a file")
+		}
+
+		// Paths are stored absolute so the runner can work from anywhere
+		for i, imagePath := range m.Images {
+			checkUploadSize("image", imagePath, api.MaxMessageImageSize)
+			m.Images[i] = absPath(imagePath)
+		}
+		for i, filePath := range m.Files {
+			checkUploadSize("file", filePath, api.MaxMessageFileSize)
+			m.Files[i] = absPath(filePath)
+		}
+		if m.Card != "" {
+			if _, err := loadCard(m.Card, m.Vars, m.VarsFile, nil); err != nil {
+				fatalSendError(err)
+			}
+			m.Card = absPath(m.Card)
+			if m.VarsFile != "" {
+				m.VarsFile = absPath(m.VarsFile)
+			}
+		}
+
+		data, err := json.Marshal(m)
+		if err != nil {
+			output.Fatal("OUTBOX_ERROR", err)
+		}
+		ob, err := outbox.Open()
+		if err != nil {
+			output.Fatal("OUTBOX_ERROR", err)
+		}
+		defer ob.Close()
+
+		entry, err := ob.Add(sendAt, data)
+		if err != nil {
+			output.Fatal("OUTBOX_ERROR", err)
+		}
+		output.JSON(convertOutboxEntry(*entry))
+	},
+}
+
+// parseScheduleTime returns the time given by --at or --in
+func parseScheduleTime() time.Time {
+	if (msgScheduleAt == "") == (msgScheduleIn == "") {
+		output.Fatalf("VALIDATION_ERROR", "exactly one of --at or --in is required")
+	}
+	if msgScheduleIn != "" {
+		d, err := timex.ParseDuration(msgScheduleIn)
+		if err != nil {
+			output.Fatal("VALIDATION_ERROR", err)
+		}
+		return time.Now().Add(d)
+	}
+	t, err := timex.Parse(msgScheduleAt, scheduleLocation())
+	if err != nil {
+		output.Fatal("VALIDATION_ERROR", err)
+	}
+	return t
+}
+
+// scheduleLocation returns the configured timezone, falling back to local
+// time
+func scheduleLocation() *time.Location {
+	loc, err := time.LoadLocation(config.GetTimezone())
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// --- msg outbox ---
+
+var msgOutboxCmd = &cobra.Command{
+	Use:   "outbox",
+	Short: "Manage scheduled messages",
+	Long: `List, send, retry and cancel messages queued with 'lark msg schedule'.
+
+The outbox is a local database in the profile directory. Nothing is sent
+until 'lark msg outbox run' runs, from cron or as a long-running process.`,
+}
+
+var msgOutboxListStatus string
+
+var msgOutboxListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled messages",
+	Long: `List scheduled messages in the order they are due, with the IDs of the
+messages sent for each (recall them with 'lark msg recall').
+
+Examples:
+  lark msg outbox list
+  lark msg outbox list --status pending
+  lark msg outbox list --status failed -o table`,
+	Run: func(cmd *cobra.Command, args []string) {
+		switch msgOutboxListStatus {
+		case "", outbox.StatusPending, outbox.StatusSending, outbox.StatusSent, outbox.StatusFailed, outbox.StatusCanceled:
+		default:
+			output.Fatalf("VALIDATION_ERROR", "status must be 'pending', 'sending', 'sent', 'failed' or 'canceled'")
+		}
+
+		ob, err := outbox.Open()
+		if err != nil {
+			output.Fatal("OUTBOX_ERROR", err)
+		}
+		defer ob.Close()
+
+		entries, err := ob.List(msgOutboxListStatus)
+		if err != nil {
+			output.Fatal("OUTBOX_ERROR", err)
+		}
+		result := api.OutputOutboxList{Entries: []api.OutputOutboxEntry{}}
+		for _, e := range entries {
+			result.Entries = append(result.Entries, convertOutboxEntry(e))
+		}
+		result.Count = len(result.Entries)
+		output.JSON(result)
+	},
+}
+
+var (
+	msgOutboxRunWatch    bool
+	msgOutboxRunInterval time.Duration
+)
+
+var msgOutboxRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Send scheduled messages that are due",
+	Long: `Send every scheduled message that is due, record the IDs of the messages
+sent, and exit. Run it from cron, or with --watch to keep running and check
+the outbox every --interval.
+
+A message that fails to send is marked failed with the error and is not
+retried; requeue it with 'lark msg outbox retry'. Several runners can share
+an outbox; each message is sent once. With --watch, errors reading the
+outbox are reported on stderr and checked again on the next tick.
+
+Examples:
+  lark msg outbox run
+  lark msg outbox run --watch --interval 1m
+
+  # crontab: check every minute
+  * * * * * lark msg outbox run -o ndjson >> ~/lark-outbox.log`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if msgOutboxRunInterval <= 0 {
+			output.Fatalf("VALIDATION_ERROR", "interval must be positive")
+		}
+
+		ob, err := outbox.Open()
+		if err != nil {
+			output.Fatal("OUTBOX_ERROR", err)
+		}
+		defer ob.Close()
+
+		client := api.NewClient()
+		if !msgOutboxRunWatch {
+			result, err := runOutbox(client, ob)
+			if err != nil {
+				output.Fatal("OUTBOX_ERROR", err)
+			}
+			output.JSON(result)
+			return
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ticker := time.NewTicker(msgOutboxRunInterval)
+		defer ticker.Stop()
+		for {
+			// The database may be locked or briefly unavailable; report
+			// what was sent before the error and try again later
+			result, err := runOutbox(client, ob)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			if len(result.Entries) > 0 {
+				output.JSON(result)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+// runOutbox sends the messages that are due
+func runOutbox(client *api.Client, ob *outbox.Outbox) (api.OutputOutboxRun, error) {
+	result := api.OutputOutboxRun{Entries: []api.OutputOutboxEntry{}}
+
+	due, err := ob.Due(time.Now())
+	if err != nil {
+		return result, err
+	}
+	for _, e := range due {
+		claimed, err := ob.Claim(e.ID)
+		if err != nil {
+			return result, err
+		}
+		if !claimed {
+			continue
+		}
+
+		var m outgoingMessage
+		sent := &api.OutputSendMessage{}
+		sendErr := json.Unmarshal(e.Message, &m)
+		if sendErr == nil {
+			sent, sendErr = deliverMessage(client, &m, nil)
+		}
+		if err := ob.Finish(e.ID, sent.ChatID, sentMessageIDs(sent), sendErr); err != nil {
+			return result, err
+		}
+
+		done, err := ob.Get(e.ID)
+		if err != nil {
+			return result, err
+		}
+		if sendErr != nil {
+			result.Failed++
+		} else {
+			result.Sent++
+		}
+		result.Entries = append(result.Entries, convertOutboxEntry(*done))
+	}
+	return result, nil
+}
+
+// sentMessageIDs returns the IDs of the messages a send produced
+func sentMessageIDs(sent *api.OutputSendMessage) []string {
+	if len(sent.Messages) == 0 {
+		if sent.MessageID == "" {
+			return nil
+		}
+		return []string{sent.MessageID}
+	}
+	ids := make([]string, len(sent.Messages))
+	for i, m := range sent.Messages {
+		ids[i] = m.MessageID
+	}
+	return ids
+}
+
+var msgOutboxCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a scheduled message",
+	Long: `Cancel a scheduled message that hasn't been sent yet. Use 'lark msg recall'
+for messages that were already sent.
+
+Examples:
+  lark msg outbox cancel 3`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			output.Fatalf("VALIDATION_ERROR", "invalid outbox ID: %s", args[0])
+		}
+
+		ob, err := outbox.Open()
+		if err != nil {
+			output.Fatal("OUTBOX_ERROR", err)
+		}
+		defer ob.Close()
+
+		entry, err := ob.Cancel(id)
+		if err != nil {
+			output.Fatal("OUTBOX_ERROR", err)
+		}
+		output.JSON(convertOutboxEntry(*entry))
+	},
+}
+
+var msgOutboxRetryCmd = &cobra.Command{
+	Use:   "retry <id>",
+	Short: "Queue a failed scheduled message again",
+	Long: `Queue a failed scheduled message to be sent on the next run.
+
+This also clears a message stuck in 'sending' because its runner crashed or
+was killed mid-send. Check 'lark msg outbox list' first: some of its
+messages may already have gone out, and only retry an entry once no runner
+is still working on it.
+
+Examples:
+  lark msg outbox retry 3`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			output.Fatalf("VALIDATION_ERROR", "invalid outbox ID: %s", args[0])
+		}
+
+		ob, err := outbox.Open()
+		if err != nil {
+			output.Fatal("OUTBOX_ERROR", err)
+		}
+		defer ob.Close()
+
+		entry, err := ob.Retry(id)
+		if err != nil {
+			output.Fatal("OUTBOX_ERROR", err)
+		}
+		output.JSON(convertOutboxEntry(*entry))
+	},
+}
+
+// convertOutboxEntry converts an outbox entry to CLI output format, with
+// times in the configured timezone
+func convertOutboxEntry(e outbox.Entry) api.OutputOutboxEntry {
+	loc := scheduleLocation()
+	out := api.OutputOutboxEntry{
+		ID:         e.ID,
+		Status:     e.Status,
+		SendAt:     e.SendAt.In(loc).Format(time.RFC3339),
+		ChatID:     e.ChatID,
+		MessageIDs: e.MessageIDs,
+		Error:      e.Error,
+	}
+	if !e.SentAt.IsZero() {
+		out.SentAt = e.SentAt.In(loc).Format(time.RFC3339)
+	}
+
+	var m outgoingMessage
+	if err := json.Unmarshal(e.Message, &m); err == nil {
+		out.To = m.To
+		out.MsgType = m.MsgType
+		out.Text = m.Text
+		if m.Card != "" {
+			out.Card = filepath.Base(m.Card)
+		}
+		for _, path := range append(append([]string{}, m.Images...), m.Files...) {
+			out.Files = append(out.Files, filepath.Base(path))
+		}
+	}
+	return out
+}
+
+func init() {
+	output.RegisterTable(api.OutputOutboxList{}, output.Table{
+		Items: "entries",
+		Columns: []output.Column{
+			{Header: "ID", Path: "id"},
+			{Header: "STATUS", Path: "status"},
+			{Header: "SEND_AT", Path: "send_at"},
+			{Header: "TO", Path: "to"},
+			{Header: "TEXT", Path: "text"},
+		},
+	})
+	output.RegisterTable(api.OutputOutboxRun{}, output.Table{
+		Items: "entries",
+		Columns: []output.Column{
+			{Header: "ID", Path: "id"},
+			{Header: "STATUS", Path: "status"},
+			{Header: "TO", Path: "to"},
+			{Header: "MESSAGE_IDS", Path: "message_ids"},
+			{Header: "ERROR", Path: "error"},
+		},
+	})
+
+	addSendFlags(msgScheduleCmd)
+	msgScheduleCmd.Flags().StringVar(&msgScheduleAt, "at", "", "When to send (ISO 8601, e.g. 2026-10-20T09:00)")
+	msgScheduleCmd.Flags().StringVar(&msgScheduleIn, "in", "", "Send after this long (e.g. 30m, 2h)")
+
+	msgOutboxListCmd.Flags().StringVar(&msgOutboxListStatus, "status", "", "Only list messages with this status: pending, sending, sent, failed or canceled")
+	msgOutboxRunCmd.Flags().BoolVar(&msgOutboxRunWatch, "watch", false, "Keep running and check the outbox every --interval")
+	msgOutboxRunCmd.Flags().DurationVar(&msgOutboxRunInterval, "interval", 30*time.Second, "How often to check the outbox with --watch")
+
+	msgOutboxCmd.AddCommand(msgOutboxListCmd)
+	msgOutboxCmd.AddCommand(msgOutboxRunCmd)
+	msgOutboxCmd.AddCommand(msgOutboxRetryCmd)
+	msgOutboxCmd.AddCommand(msgOutboxCancelCmd)
+	msgCmd.AddCommand(msgScheduleCmd)
+	msgCmd.AddCommand(msgOutboxCmd)
+}
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/msgarchive"
+	"github.com/yjwong/lark-cli/internal/output"
+)
+
+// --- msg sync ---
+
+var (
+	msgSyncChatID        string
+	msgSyncSince         string
+	msgSyncFull          bool
+	msgSyncSkipReactions bool
+	msgSyncQuiet         bool
+)
+
+var msgSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Archive a chat's messages locally for searching",
+	Long: `Fetch a chat's messages and store them in the local message archive, which
+'lark msg search' searches without network calls.
+
+The first sync fetches the whole history (or from --since); later syncs only
+fetch messages newer than the newest archived one. Replies in topic threads
+are fetched with their thread, and later syncs check every archived topic
+thread for new replies, newest first, so replies to older topics aren't
+missed. Each message is stored with its sender, mentions, attachment keys,
+reactions and its text for full-text search.
+
+Reactions take one request per message; --skip-reactions leaves them out
+(reactions already archived are kept). Reactions on messages older than the
+last sync are only refreshed with --full.
+
+Examples:
+  lark msg sync --chat-id oc_xxxxx
+  lark msg sync --chat-id oc_xxxxx --since 2026-01-01
+  lark msg sync --chat-id oc_xxxxx --full --skip-reactions`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if msgSyncChatID == "" {
+			output.Fatalf("VALIDATION_ERROR", "chat-id is required")
+		}
+
+		archive, err := msgarchive.Open()
+		if err != nil {
+			output.Fatal("ARCHIVE_ERROR", err)
+		}
+		defer archive.Close()
+
+		state, err := archive.GetChatState(msgSyncChatID)
+		if err != nil {
+			output.Fatal("ARCHIVE_ERROR", err)
+		}
+		before, err := archive.CountMessages(msgSyncChatID)
+		if err != nil {
+			output.Fatal("ARCHIVE_ERROR", err)
+		}
+
+		incremental := state != nil && !state.LastCreateTime.IsZero() && !msgSyncFull
+		opts := &api.ListMessagesOptions{SortType: "ByCreateTimeAsc"}
+		switch {
+		case incremental:
+			// Start at the newest archived message; it is fetched again, as
+			// are any others from the same second
+			opts.StartTime = strconv.FormatInt(state.LastCreateTime.Unix(), 10)
+		case msgSyncSince != "":
+			opts.StartTime = parseTimeArg(msgSyncSince)
+		}
+
+		client := api.NewClient()
+		progress := func(format string, args ...interface{}) {
+			if !msgSyncQuiet {
+				fmt.Fprintf(os.Stderr, format, args...)
+			}
+		}
+
+		progress("Fetching messages from %s...\n", msgSyncChatID)
+		messages, err := listAllMessages(client, "chat", msgSyncChatID, opts, 0)
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+		messages, err = withThreadReplies(client, messages)
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+		if incremental {
+			threadIDs, err := archive.ThreadIDs(msgSyncChatID)
+			if err != nil {
+				output.Fatal("ARCHIVE_ERROR", err)
+			}
+			if messages, err = withNewThreadReplies(client, messages, threadIDs, state.LastCreateTime); err != nil {
+				output.Fatal("API_ERROR", err)
+			}
+		}
+
+		archived := make([]msgarchive.Message, len(messages))
+		var newest time.Time
+		for i, m := range messages {
+			archived[i] = archiveMessage(m, msgSyncChatID)
+			if archived[i].CreateTime.After(newest) {
+				newest = archived[i].CreateTime
+			}
+			if !msgSyncSkipReactions && !m.Deleted {
+				progress("\rFetching reactions: %d / %d", i+1, len(messages))
+				if archived[i].Reactions, err = reactionCounts(client, m.MessageID); err != nil {
+					output.Fatal("API_ERROR", err)
+				}
+			}
+		}
+		if !msgSyncSkipReactions && len(messages) > 0 {
+			progress("\n")
+		}
+
+		if err := archive.SaveMessages(archived); err != nil {
+			output.Fatal("ARCHIVE_ERROR", err)
+		}
+		if err := archive.UpdateChatState(msgSyncChatID, newest); err != nil {
+			output.Fatal("ARCHIVE_ERROR", err)
+		}
+		total, err := archive.CountMessages(msgSyncChatID)
+		if err != nil {
+			output.Fatal("ARCHIVE_ERROR", err)
+		}
+
+		output.JSON(api.OutputMessageSync{
+			ChatID:        msgSyncChatID,
+			Fetched:       len(messages),
+			NewMessages:   total - before,
+			TotalArchived: total,
+		})
+	},
+}
+
+// withThreadReplies adds the replies of every topic thread in messages that
+// the chat listing doesn't include
+func withThreadReplies(client *api.Client, messages []api.Message) ([]api.Message, error) {
+	seen := make(map[string]bool, len(messages))
+	for _, m := range messages {
+		seen[m.MessageID] = true
+	}
+
+	all := messages
+	fetched := map[string]bool{}
+	for _, m := range messages {
+		if m.ThreadID == "" || fetched[m.ThreadID] {
+			continue
+		}
+		fetched[m.ThreadID] = true
+		replies, err := listAllMessages(client, "thread", m.ThreadID, &api.ListMessagesOptions{SortType: "ByCreateTimeAsc"}, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list thread %s: %w", m.ThreadID, err)
+		}
+		for _, r := range replies {
+			if !seen[r.MessageID] {
+				seen[r.MessageID] = true
+				all = append(all, r)
+			}
+		}
+	}
+	return all, nil
+}
+
+// withNewThreadReplies adds replies created at or after since to archived
+// threads that messages doesn't already cover; they don't show up in the
+// chat listing once the thread's root is older than the last sync
+func withNewThreadReplies(client *api.Client, messages []api.Message, threadIDs []string, since time.Time) ([]api.Message, error) {
+	seen := make(map[string]bool, len(messages))
+	covered := map[string]bool{}
+	for _, m := range messages {
+		seen[m.MessageID] = true
+		covered[m.ThreadID] = true
+	}
+
+	all := messages
+	for _, id := range threadIDs {
+		if covered[id] {
+			continue
+		}
+		replies, err := threadRepliesSince(client, id, since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list thread %s: %w", id, err)
+		}
+		for _, r := range replies {
+			if !seen[r.MessageID] {
+				seen[r.MessageID] = true
+				all = append(all, r)
+			}
+		}
+	}
+	return all, nil
+}
+
+// threadRepliesSince lists a thread's messages created at or after since,
+// newest first, stopping at the first older page
+func threadRepliesSince(client *api.Client, threadID string, since time.Time) ([]api.Message, error) {
+	var replies []api.Message
+	opts := &api.ListMessagesOptions{SortType: "ByCreateTimeDesc", PageSize: 50}
+	for {
+		messages, more, next, err := client.ListMessages("thread", threadID, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range messages {
+			if messageTime(m) < since.UnixMilli() {
+				return replies, nil
+			}
+			replies = append(replies, m)
+		}
+		if !more {
+			return replies, nil
+		}
+		opts.PageToken = next
+	}
+}
+
+// archiveMessage converts an API message for the archive, with its text
+// rendered for searching
+func archiveMessage(m api.Message, chatID string) msgarchive.Message {
+	am := msgarchive.Message{
+		MessageID: m.MessageID,
+		ChatID:    m.ChatID,
+		RootID:    m.RootID,
+		ParentID:  m.ParentID,
+		ThreadID:  m.ThreadID,
+		MsgType:   m.MsgType,
+		Deleted:   m.Deleted,
+	}
+	if am.ChatID == "" {
+		am.ChatID = chatID
+	}
+	if ms, err := strconv.ParseInt(m.CreateTime, 10, 64); err == nil {
+		am.CreateTime = time.UnixMilli(ms)
+	}
+	if m.Sender != nil {
+		am.SenderID = m.Sender.ID
+		am.SenderType = m.Sender.SenderType
+	}
+	if m.Body != nil {
+		am.Content = m.Body.Content
+		if !m.Deleted {
+			am.Text = renderMessageContent(m, contentFormatText)
+		}
+	}
+	for _, mention := range m.Mentions {
+		am.Mentions = append(am.Mentions, msgarchive.Mention{Key: mention.Key, ID: mention.ID, Name: mention.Name})
+	}
+	for _, a := range messageAttachments(m) {
+		am.Attachments = append(am.Attachments, msgarchive.Attachment{Type: a.Type, Key: a.Key, Name: a.Name})
+	}
+	return am
+}
+
+// reactionCounts returns the number of each reaction on a message
+func reactionCounts(client *api.Client, messageID string) (map[string]int, error) {
+	counts := map[string]int{}
+	opts := &api.ListMessageReactionsOptions{PageSize: 50}
+	for {
+		reactions, more, next, err := client.ListMessageReactions(messageID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reactions on %s: %w", messageID, err)
+		}
+		for _, r := range reactions {
+			if r.ReactionType != nil {
+				counts[r.ReactionType.EmojiType]++
+			}
+		}
+		if !more {
+			return counts, nil
+		}
+		opts.PageToken = next
+	}
+}
+
+// --- msg search ---
+
+var (
+	msgSearchChatID string
+	msgSearchFrom   string
+	msgSearchSince  string
+	msgSearchBefore string
+	msgSearchLimit  int
+)
+
+var msgSearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search archived messages",
+	Long: `Search the local message archive (no network calls).
+
+Every word of the query must appear in a message; end a word with * to match
+words starting with it. Links are searchable too, so "grafana.example.com"
+finds messages that posted it. Without a query, the newest messages matching
+the filters are listed.
+
+The archive is filled by 'lark msg sync'. Results include each chat's sync
+freshness so you know if data is stale.
+
+Examples:
+  lark msg search "deploy freeze"
+  lark msg search "grafana.example.com" --chat oc_xxxxx
+  lark msg search "release*" --from ou_xxxxx --since 2026-07-01
+  lark msg search --chat oc_xxxxx --since 2026-10-01 --limit 20`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := &msgarchive.SearchOptions{
+			ChatID: msgSearchChatID,
+			From:   msgSearchFrom,
+			Limit:  msgSearchLimit,
+		}
+		if len(args) > 0 {
+			opts.Query = args[0]
+		}
+		if msgSearchSince != "" {
+			t := parseArchiveTime(msgSearchSince)
+			opts.Since = &t
+		}
+		if msgSearchBefore != "" {
+			t := parseArchiveTime(msgSearchBefore)
+			opts.Before = &t
+		}
+
+		archive, err := msgarchive.Open()
+		if err != nil {
+			output.Fatal("ARCHIVE_ERROR", err)
+		}
+		defer archive.Close()
+
+		result, err := archive.Search(opts)
+		if err != nil {
+			output.Fatal("SEARCH_ERROR", err)
+		}
+		output.JSON(result)
+	},
+}
+
+// parseArchiveTime parses a --since/--before value like parseTimeArg does
+func parseArchiveTime(s string) time.Time {
+	sec, _ := strconv.ParseInt(parseTimeArg(s), 10, 64)
+	return time.Unix(sec, 0)
+}
+
+func init() {
+	output.RegisterTable(msgarchive.SearchResult{}, output.Table{
+		Items: "results",
+		Columns: []output.Column{
+			{Header: "MESSAGE_ID", Path: "message_id"},
+			{Header: "TIME", Path: "create_time"},
+			{Header: "CHAT", Path: "chat_id"},
+			{Header: "SENDER", Path: "sender_id"},
+			{Header: "TEXT", Path: "text"},
+		},
+	})
+
+	msgSyncCmd.Flags().StringVar(&msgSyncChatID, "chat-id", "", "Chat ID to archive (required)")
+	msgSyncCmd.Flags().StringVar(&msgSyncSince, "since", "", "On first sync, start here (Unix timestamp or ISO 8601)")
+	msgSyncCmd.Flags().BoolVar(&msgSyncFull, "full", false, "Fetch the whole history again")
+	msgSyncCmd.Flags().BoolVar(&msgSyncSkipReactions, "skip-reactions", false, "Don't fetch reactions")
+	msgSyncCmd.Flags().BoolVar(&msgSyncQuiet, "quiet", false, "Don't write progress to stderr")
+
+	msgSearchCmd.Flags().StringVar(&msgSearchChatID, "chat", "", "Only search this chat")
+	msgSearchCmd.Flags().StringVar(&msgSearchFrom, "from", "", "Only messages from this sender ID")
+	msgSearchCmd.Flags().StringVar(&msgSearchSince, "since", "", "Only messages at or after this time (Unix timestamp or ISO 8601)")
+	msgSearchCmd.Flags().StringVar(&msgSearchBefore, "before", "", "Only messages before this time (Unix timestamp or ISO 8601)")
+	msgSearchCmd.Flags().IntVar(&msgSearchLimit, "limit", 50, "Maximum number of results")
+
+	msgCmd.AddCommand(msgSyncCmd)
+	msgCmd.AddCommand(msgSearchCmd)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs keep {{image}} and {{video}} in a message template as the
+// placeholders msg send fills with --image and --file
+var templateFuncs = template.FuncMap{
+	"image": func() string { return imagePlaceholder },
+	"video": func() string { return videoPlaceholder },
+}
+
+// renderMessageTemplate renders a markdown message template ("-" reads
+// stdin) with the variables from --vars and --var. Referencing an unset
+// variable is an error.
+func renderMessageTemplate(path string, assignments []string, varsFile string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", &sendError{"FILE_ERROR", fmt.Errorf("message template not found: %s", path)}
+		}
+		return "", &sendError{"FILE_ERROR", err}
+	}
+
+	vars, err := loadTemplateVars(assignments, varsFile)
+	if err != nil {
+		return "", err
+	}
+
+	t, err := template.New(filepath.Base(path)).
+		Option("missingkey=error").
+		Funcs(templateFuncs).
+		Parse(string(data))
+	if err != nil {
+		return "", &sendError{"VALIDATION_ERROR", err}
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, map[string]interface{}(vars)); err != nil {
+		if _, key, ok := strings.Cut(err.Error(), "map has no entry for key "); ok {
+			return "", &sendError{"VALIDATION_ERROR", fmt.Errorf("%s: variable %s is not set", t.Name(), key)}
+		}
+		return "", &sendError{"VALIDATION_ERROR", err}
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
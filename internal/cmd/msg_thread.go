@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/names"
+	"github.com/yjwong/lark-cli/internal/output"
+)
+
+var (
+	msgThreadFormat     string
+	msgThreadTranscript bool
+	msgThreadResolve    bool
+	msgThreadUntil      string
+)
+
+var msgThreadCmd = &cobra.Command{
+	Use:   "thread <message-id>",
+	Short: "Get a message's whole thread",
+	Long: `Get the thread a message belongs to: its root message with every reply
+nested under it, oldest first.
+
+The message can be the root or any reply. Topic threads are fetched as a
+thread container; replies outside a topic thread are found by scanning the
+chat from the root message up to --until, which defaults to 7 days after
+the root.
+
+--transcript exports the thread as a markdown transcript instead.
+--resolve-names adds sender names, as for 'lark msg history'.
+
+Examples:
+  lark msg thread om_xxxxx
+  lark msg thread om_xxxxx --format markdown
+  lark msg thread om_xxxxx --transcript
+  lark msg thread om_xxxxx --transcript --resolve-names
+  lark msg thread om_xxxxx --until 2026-12-31`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		validateContentFormat(msgThreadFormat)
+		client := api.NewClient()
+
+		until := ""
+		if msgThreadUntil != "" {
+			until = parseTimeArg(msgThreadUntil)
+		}
+
+		t, err := fetchThread(client, args[0], until)
+		if err != nil {
+			output.Fatal("API_ERROR", err)
+		}
+
+		var resolver *names.Resolver
+		if msgThreadResolve {
+			resolver = names.NewResolver(client)
+			resolveMessageNames(resolver, append([]api.Message{t.root}, t.replies...), "thread", t.threadID())
+		}
+
+		if msgThreadTranscript {
+			output.JSON(api.OutputMessageTranscript{
+				ChatID:   t.root.ChatID,
+				ThreadID: t.threadID(),
+				Format:   contentFormatMarkdown,
+				Content:  renderTranscript([]*messageThread{t}, resolver),
+			})
+			return
+		}
+
+		message := t.output(msgThreadFormat)
+		nameSenders([]api.OutputMessage{message}, resolver)
+		output.JSON(api.OutputMessageThread{
+			ChatID:   t.root.ChatID,
+			ThreadID: t.threadID(),
+			RootID:   t.root.MessageID,
+			Count:    1 + len(t.replies),
+			Message:  message,
+		})
+	},
+}
+
+// messageThread is a root message and its replies, oldest first
+type messageThread struct {
+	root    api.Message
+	replies []api.Message
+}
+
+// threadID returns the thread container ID, if the thread has one
+func (t *messageThread) threadID() string {
+	if t.root.ThreadID != "" {
+		return t.root.ThreadID
+	}
+	for _, r := range t.replies {
+		if r.ThreadID != "" {
+			return r.ThreadID
+		}
+	}
+	return ""
+}
+
+// output converts the thread to a root message with nested replies.
+// Replies to another reply keep the parent's ID.
+func (t *messageThread) output(format string) api.OutputMessage {
+	out := convertMessage(t.root, format)
+	for _, r := range t.replies {
+		reply := convertMessage(r, format)
+		if r.ParentID != "" && r.ParentID != t.root.MessageID {
+			reply.ParentID = r.ParentID
+		}
+		out.Replies = append(out.Replies, reply)
+	}
+	return out
+}
+
+// add adds replies the thread doesn't have yet and sorts them by time
+func (t *messageThread) add(messages []api.Message) {
+	have := map[string]bool{t.root.MessageID: true}
+	for _, r := range t.replies {
+		have[r.MessageID] = true
+	}
+	for _, m := range messages {
+		if !have[m.MessageID] {
+			have[m.MessageID] = true
+			t.replies = append(t.replies, m)
+		}
+	}
+	sort.SliceStable(t.replies, func(i, j int) bool {
+		return messageTime(t.replies[i]) < messageTime(t.replies[j])
+	})
+}
+
+func messageTime(m api.Message) int64 {
+	ms, _ := strconv.ParseInt(m.CreateTime, 10, 64)
+	return ms
+}
+
+// groupThreads groups messages into threads under their root, in the order
+// the roots (or their first reply) appear. Roots outside the list are
+// fetched, and so is the rest of every topic thread.
+func groupThreads(client *api.Client, messages []api.Message) ([]*messageThread, error) {
+	byRoot := map[string]*messageThread{}
+
+	// Roots first, so replies listed before their root (--sort desc) still
+	// find it
+	for _, m := range messages {
+		if m.RootID == "" || m.RootID == m.MessageID {
+			byRoot[m.MessageID] = &messageThread{root: m}
+		}
+	}
+	var ordered []*messageThread
+	placed := map[*messageThread]bool{}
+	for _, m := range messages {
+		rootID := m.RootID
+		if rootID == "" || rootID == m.MessageID {
+			rootID = m.MessageID
+		} else if byRoot[rootID] == nil {
+			root, err := client.GetMessage(rootID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get root message %s: %w", rootID, err)
+			}
+			byRoot[rootID] = &messageThread{root: *root}
+		}
+		t := byRoot[rootID]
+		if rootID != m.MessageID {
+			t.add([]api.Message{m})
+		}
+		if !placed[t] {
+			placed[t] = true
+			ordered = append(ordered, t)
+		}
+	}
+
+	for _, t := range ordered {
+		id := t.threadID()
+		if id == "" {
+			continue
+		}
+		replies, err := listAllMessages(client, "thread", id, &api.ListMessagesOptions{SortType: "ByCreateTimeAsc"}, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list thread %s: %w", id, err)
+		}
+		t.add(replies)
+	}
+	return ordered, nil
+}
+
+// threadScanWindow is how far past the root a chat is scanned for replies
+// outside a topic thread when no end time is given
+const threadScanWindow = 7 * 24 * time.Hour
+
+// fetchThread gets the thread a message belongs to. Replies outside a topic
+// thread are looked for up to until (Unix seconds), or threadScanWindow
+// after the root when empty.
+func fetchThread(client *api.Client, messageID, until string) (*messageThread, error) {
+	m, err := client.GetMessage(messageID)
+	if err != nil {
+		return nil, err
+	}
+	messages := []api.Message{*m}
+	if m.RootID != "" && m.RootID != m.MessageID {
+		root, err := client.GetMessage(m.RootID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get root message %s: %w", m.RootID, err)
+		}
+		messages = []api.Message{*root, *m}
+	}
+
+	threads, err := groupThreads(client, messages)
+	if err != nil {
+		return nil, err
+	}
+	t := threads[0]
+	if t.threadID() != "" || t.root.ChatID == "" {
+		return t, nil
+	}
+
+	// Plain replies have no thread container; find them in the chat
+	opts := &api.ListMessagesOptions{SortType: "ByCreateTimeAsc", EndTime: until}
+	if ms := messageTime(t.root); ms > 0 {
+		start := time.UnixMilli(ms)
+		opts.StartTime = strconv.FormatInt(start.Unix(), 10)
+		if opts.EndTime == "" {
+			opts.EndTime = strconv.FormatInt(start.Add(threadScanWindow).Unix(), 10)
+		}
+	}
+	chat, err := listAllMessages(client, "chat", t.root.ChatID, opts, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat %s: %w", t.root.ChatID, err)
+	}
+	var replies []api.Message
+	for _, c := range chat {
+		if c.RootID == t.root.MessageID && c.MessageID != t.root.MessageID {
+			replies = append(replies, c)
+		}
+	}
+	t.add(replies)
+	return t, nil
+}
+
+// renderTranscript renders threads as markdown, one message per paragraph
+// with replies quoted under their root and threads separated by rules.
+// Senders are named by r when it knows them.
+func renderTranscript(threads []*messageThread, r *names.Resolver) string {
+	var parts []string
+	for _, t := range threads {
+		senders := map[string]string{t.root.MessageID: messageSender(t.root, r)}
+		var b strings.Builder
+		b.WriteString(transcriptEntry(t.root, "", r))
+		for _, reply := range t.replies {
+			senders[reply.MessageID] = messageSender(reply, r)
+			replyTo := ""
+			if reply.ParentID != "" && reply.ParentID != t.root.MessageID {
+				replyTo = senders[reply.ParentID]
+			}
+			entry := transcriptEntry(reply, replyTo, r)
+			b.WriteString("\n\n> " + strings.ReplaceAll(entry, "\n", "\n> "))
+		}
+		parts = append(parts, strings.ReplaceAll(b.String(), "\n> \n", "\n>\n"))
+	}
+	return strings.Join(parts, "\n\n---\n\n") + "\n"
+}
+
+// transcriptEntry renders one message as a heading line and its content
+func transcriptEntry(m api.Message, replyTo string, r *names.Resolver) string {
+	heading := "**" + messageSender(m, r) + "** · " + formatMessageTime(m.CreateTime)
+	if replyTo != "" {
+		heading += " · replying to " + replyTo
+	}
+	content := "_(recalled)_"
+	if !m.Deleted {
+		content = renderMessageContent(m, contentFormatMarkdown)
+	}
+	return heading + "\n\n" + content
+}
+
+// messageSender returns the sender's resolved name or ID, or its type when
+// it has neither
+func messageSender(m api.Message, r *names.Resolver) string {
+	if m.Sender == nil {
+		return "unknown"
+	}
+	if name := r.User(m.Sender.ID); name != "" {
+		return name
+	}
+	if m.Sender.ID != "" {
+		return m.Sender.ID
+	}
+	return m.Sender.SenderType
+}
+
+func init() {
+	msgThreadCmd.Flags().StringVar(&msgThreadFormat, "format", contentFormatRaw, "Content format: raw, text or markdown")
+	msgThreadCmd.Flags().BoolVar(&msgThreadTranscript, "transcript", false, "Output the thread as a markdown transcript")
+	msgThreadCmd.Flags().BoolVar(&msgThreadResolve, "resolve-names", false, "Add sender names")
+	msgThreadCmd.Flags().StringVar(&msgThreadUntil, "until", "", "Scan for replies up to this time (Unix timestamp or ISO 8601; default 7 days after the root)")
+
+	msgCmd.AddCommand(msgThreadCmd)
+}
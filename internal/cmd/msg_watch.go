@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/config"
+	"github.com/yjwong/lark-cli/internal/larkws"
+	"github.com/yjwong/lark-cli/internal/output"
+)
+
+// Event types streamed by msg watch
+const (
+	eventMessageReceive  = "im.message.receive_v1"
+	eventReactionCreated = "im.message.reaction.created_v1"
+	eventReactionDeleted = "im.message.reaction.deleted_v1"
+	eventMessageRecalled = "im.message.recalled_v1"
+)
+
+var (
+	msgWatchChatIDs       []string
+	msgWatchEvents        []string
+	msgWatchMaxReconnects int
+	msgWatchCount         int
+	msgWatchQuiet         bool
+)
+
+var msgWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream message events in real time",
+	Long: `Open Lark's event subscription long connection and stream message events
+as NDJSON, one JSON object per line, until interrupted.
+
+Each line has a "type" of message, reaction_added, reaction_removed or
+recalled. New messages carry a "message" object in the same shape as
+'lark msg history'.
+
+The app must use long connection mode for event subscriptions and subscribe
+to the events you want (im.message.receive_v1, im.message.reaction.created_v1,
+im.message.reaction.deleted_v1, im.message.recalled_v1). The bot only receives
+events for chats it is in. Requires app_id and app_secret.
+
+The connection sends heartbeats and reconnects automatically; connection
+status is written to stderr (use --quiet to suppress it).
+
+Examples:
+  lark msg watch
+  lark msg watch --chat-id oc_xxxxx
+  lark msg watch --chat-id oc_xxxxx --events message
+  lark msg watch --chat-id oc_xxxxx --count 1   # wait for the next event
+  lark msg watch | jq -c 'select(.type == "message") | .message.content'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		types := map[string]bool{}
+		for _, e := range msgWatchEvents {
+			switch e = strings.ToLower(strings.TrimSpace(e)); e {
+			case "message", "reaction", "recall":
+				types[e] = true
+			default:
+				output.Fatalf("VALIDATION_ERROR", "invalid event %q (must be message, reaction or recall)", e)
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		w := &messageWatcher{
+			getMessage: api.NewClient().GetMessage,
+			types:      types,
+			chats:      newChatLRU(watchChatCacheSize),
+			encoder:    json.NewEncoder(os.Stdout),
+			remaining:  msgWatchCount,
+			stop:       stop,
+		}
+		if len(msgWatchChatIDs) > 0 {
+			w.chatFilter = make(map[string]bool)
+			for _, id := range msgWatchChatIDs {
+				w.chatFilter[id] = true
+			}
+		}
+
+		opts := larkws.Options{
+			AppID:          config.GetAppID(),
+			AppSecret:      config.GetAppSecret(),
+			BaseURL:        config.GetBaseURL(),
+			ReconnectCount: msgWatchMaxReconnects,
+		}
+		if !msgWatchQuiet {
+			opts.Log = os.Stderr
+		}
+
+		if err := larkws.NewClient(opts, w.handle).Run(ctx); err != nil {
+			output.Fatal("CONNECTION_ERROR", err)
+		}
+	},
+}
+
+// messageWatcher converts events to NDJSON lines
+type messageWatcher struct {
+	getMessage func(messageID string) (*api.Message, error)
+	types      map[string]bool // enabled event kinds; all when empty
+	chatFilter map[string]bool // chats to include; all when nil
+	chats      *chatLRU        // message ID -> chat ID for recent messages
+	encoder    *json.Encoder
+	remaining  int    // events left before stopping; unlimited when 0
+	stop       func() // ends the stream
+}
+
+func (w *messageWatcher) enabled(kind string) bool {
+	return len(w.types) == 0 || w.types[kind]
+}
+
+func (w *messageWatcher) handle(e *larkws.Event) error {
+	out := api.OutputMessageEvent{
+		EventID: e.Header.EventID,
+		Time:    formatMessageTime(e.Header.CreateTime),
+	}
+
+	switch e.Header.EventType {
+	case eventMessageReceive:
+		if !w.enabled("message") {
+			return nil
+		}
+		var ev api.MessageReceiveEvent
+		if err := json.Unmarshal(e.Event, &ev); err != nil {
+			return err
+		}
+		msg := convertMessage(messageFromEvent(&ev), contentFormatRaw)
+		out.Type = "message"
+		out.ChatID = ev.Message.ChatID
+		out.ChatType = ev.Message.ChatType
+		out.MessageID = ev.Message.MessageID
+		out.Time = msg.CreateTime
+		out.Message = &msg
+		w.chats.put(out.MessageID, out.ChatID)
+
+	case eventReactionCreated, eventReactionDeleted:
+		if !w.enabled("reaction") {
+			return nil
+		}
+		var ev api.MessageReactionEvent
+		if err := json.Unmarshal(e.Event, &ev); err != nil {
+			return err
+		}
+		out.Type = "reaction_added"
+		if e.Header.EventType == eventReactionDeleted {
+			out.Type = "reaction_removed"
+		}
+		out.MessageID = ev.MessageID
+		out.ChatID = w.chatOf(ev.MessageID)
+		reaction := api.OutputMessageReactionItem{
+			EmojiType:    ev.ReactionType.EmojiType,
+			OperatorID:   ev.UserID.OpenID,
+			OperatorType: ev.OperatorType,
+		}
+		if ev.OperatorType == "app" {
+			reaction.OperatorID = ev.AppID
+		}
+		if ev.ActionTime != "" {
+			reaction.ActionTime = formatMessageTime(ev.ActionTime)
+			out.Time = reaction.ActionTime
+		}
+		out.Reaction = &reaction
+
+	case eventMessageRecalled:
+		if !w.enabled("recall") {
+			return nil
+		}
+		var ev api.MessageRecalledEvent
+		if err := json.Unmarshal(e.Event, &ev); err != nil {
+			return err
+		}
+		out.Type = "recalled"
+		out.MessageID = ev.MessageID
+		out.ChatID = ev.ChatID
+		out.RecallType = ev.RecallType
+		if ev.RecallTime != "" {
+			out.Time = formatMessageTime(ev.RecallTime)
+		}
+
+	default:
+		return nil
+	}
+
+	if w.chatFilter != nil && !w.chatFilter[out.ChatID] {
+		return nil
+	}
+	if err := w.encoder.Encode(out); err != nil {
+		return err
+	}
+	if w.remaining > 0 {
+		w.remaining--
+		if w.remaining == 0 {
+			w.stop()
+		}
+	}
+	return nil
+}
+
+// chatOf returns the chat a message belongs to; reaction events don't say.
+// A failed lookup isn't cached so a later event can retry it.
+func (w *messageWatcher) chatOf(messageID string) string {
+	if chatID, ok := w.chats.get(messageID); ok {
+		return chatID
+	}
+	msg, err := w.getMessage(messageID)
+	if err != nil {
+		return ""
+	}
+	w.chats.put(messageID, msg.ChatID)
+	return msg.ChatID
+}
+
+// watchChatCacheSize bounds how many message -> chat mappings a long-running
+// watch keeps; reactions to older messages fall back to a lookup
+const watchChatCacheSize = 4096
+
+// chatLRU is a fixed-size map from message ID to chat ID that evicts the
+// least recently used entry
+type chatLRU struct {
+	size  int
+	order *list.List // front is most recent; values are *chatEntry
+	items map[string]*list.Element
+}
+
+type chatEntry struct {
+	messageID, chatID string
+}
+
+func newChatLRU(size int) *chatLRU {
+	return &chatLRU{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *chatLRU) get(messageID string) (string, bool) {
+	el, ok := c.items[messageID]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*chatEntry).chatID, true
+}
+
+func (c *chatLRU) put(messageID, chatID string) {
+	if el, ok := c.items[messageID]; ok {
+		el.Value.(*chatEntry).chatID = chatID
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[messageID] = c.order.PushFront(&chatEntry{messageID, chatID})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*chatEntry).messageID)
+	}
+}
+
+// messageFromEvent converts a receive event to the message shape returned by the IM API
+func messageFromEvent(ev *api.MessageReceiveEvent) api.Message {
+	m := api.Message{
+		MessageID:  ev.Message.MessageID,
+		RootID:     ev.Message.RootID,
+		ParentID:   ev.Message.ParentID,
+		ThreadID:   ev.Message.ThreadID,
+		MsgType:    ev.Message.MessageType,
+		CreateTime: ev.Message.CreateTime,
+		UpdateTime: ev.Message.UpdateTime,
+		ChatID:     ev.Message.ChatID,
+		Sender: &api.MessageSender{
+			ID:         ev.Sender.SenderID.OpenID,
+			IDType:     "open_id",
+			SenderType: ev.Sender.SenderType,
+			TenantKey:  ev.Sender.TenantKey,
+		},
+		Body: &api.MessageBody{Content: ev.Message.Content},
+	}
+	for _, mention := range ev.Message.Mentions {
+		m.Mentions = append(m.Mentions, api.MessageMention{
+			Key:       mention.Key,
+			ID:        mention.ID.OpenID,
+			IDType:    "open_id",
+			Name:      mention.Name,
+			TenantKey: mention.TenantKey,
+		})
+	}
+	return m
+}
+
+func init() {
+	msgWatchCmd.Flags().StringSliceVar(&msgWatchChatIDs, "chat-id", nil, "Only stream events from this chat (repeatable)")
+	msgWatchCmd.Flags().StringSliceVar(&msgWatchEvents, "events", nil, "Event kinds to stream: message, reaction, recall (default: all)")
+	msgWatchCmd.Flags().IntVar(&msgWatchMaxReconnects, "max-reconnects", 0, "Give up after this many failed reconnects (-1 = forever, 0 = server default)")
+	msgWatchCmd.Flags().IntVar(&msgWatchCount, "count", 0, "Exit after this many events (0 = run until interrupted)")
+	msgWatchCmd.Flags().BoolVar(&msgWatchQuiet, "quiet", false, "Don't write connection status to stderr")
+
+	msgCmd.AddCommand(msgWatchCmd)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yjwong/lark-cli/internal/api"
+)
+
+func TestChatLRU(t *testing.T) {
+	c := newChatLRU(2)
+	c.put("om_1", "oc_a")
+	c.put("om_2", "oc_b")
+	if chatID, ok := c.get("om_1"); !ok || chatID != "oc_a" {
+		t.Fatalf("get(om_1) = %q, %v", chatID, ok)
+	}
+
+	// om_2 is now the least recently used
+	c.put("om_3", "oc_c")
+	if _, ok := c.get("om_2"); ok {
+		t.Error("om_2 should have been evicted")
+	}
+	for id, want := range map[string]string{"om_1": "oc_a", "om_3": "oc_c"} {
+		if chatID, ok := c.get(id); !ok || chatID != want {
+			t.Errorf("get(%s) = %q, %v", id, chatID, ok)
+		}
+	}
+
+	// Updating an entry doesn't grow the cache
+	c.put("om_3", "")
+	if chatID, ok := c.get("om_3"); !ok || chatID != "" || c.order.Len() != 2 {
+		t.Errorf("after update: get(om_3) = %q, %v; len %d", chatID, ok, c.order.Len())
+	}
+}
+
+func TestChatOf(t *testing.T) {
+	calls := 0
+	fail := true
+	w := &messageWatcher{
+		chats: newChatLRU(2),
+		getMessage: func(messageID string) (*api.Message, error) {
+			calls++
+			if fail {
+				return nil, errors.New("rate limited")
+			}
+			return &api.Message{MessageID: messageID, ChatID: "oc_a"}, nil
+		},
+	}
+
+	if chatID := w.chatOf("om_1"); chatID != "" {
+		t.Errorf("chatOf after a failed lookup = %q", chatID)
+	}
+	if _, ok := w.chats.get("om_1"); ok {
+		t.Error("a failed lookup should not be cached")
+	}
+
+	fail = false
+	for i := 0; i < 2; i++ {
+		if chatID := w.chatOf("om_1"); chatID != "oc_a" {
+			t.Errorf("chatOf = %q, want oc_a", chatID)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("GetMessage called %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/config"
+	"github.com/yjwong/lark-cli/internal/output"
+	"github.com/yjwong/lark-cli/internal/secrets"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Profile commands",
+	Long: `Manage named profiles for working with multiple Lark tenants or accounts.
+
+Each profile has its own app credentials, tokens, mail credentials and mail cache.
+The "default" profile uses files directly in the config directory; named profiles
+live in profiles/<name>/ and may override settings with their own config.yaml.
+
+Select a profile with --profile, the LARK_PROFILE environment variable, or
+'lark profile use'. Named profiles are created on first login:
+  lark auth login --profile personal`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Long:  "List all profiles, marking the default and the currently active profile",
+	Run: func(cmd *cobra.Command, args []string) {
+		names, err := config.ListProfiles()
+		if err != nil {
+			output.Fatal("CONFIG_ERROR", err)
+		}
+
+		profiles := make([]api.OutputProfile, 0, len(names))
+		for _, name := range names {
+			profiles = append(profiles, api.OutputProfile{
+				Name:      name,
+				Directory: config.ProfileDir(name),
+				AppID:     config.ProfileAppID(name),
+				Default:   name == config.GetDefaultProfile(),
+				Active:    name == config.GetProfile(),
+			})
+		}
+
+		output.JSON(api.OutputProfileList{
+			Profiles: profiles,
+			Count:    len(profiles),
+		})
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Set the default profile",
+	Long: `Set the profile used when --profile and LARK_PROFILE are not given.
+
+Example:
+  lark profile use work`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		if err := config.ValidateProfileName(name); err != nil {
+			output.Fatal("VALIDATION_ERROR", err)
+		}
+		if !config.ProfileExists(name) {
+			output.Fatalf("NOT_FOUND", "Profile %q does not exist; create it with 'lark auth login --profile %s'", name, name)
+		}
+
+		if err := config.SetDefaultProfile(name); err != nil {
+			output.Fatal("CONFIG_ERROR", err)
+		}
+
+		output.Success(fmt.Sprintf("Default profile set to %s", name))
+	},
+}
+
+var profileDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a profile",
+	Long: `Delete a named profile, including its tokens, mail credentials and mail cache.
+
+The "default" profile and the current default profile cannot be deleted.
+
+Example:
+  lark profile delete personal`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		if err := config.DeleteProfile(name); err != nil {
+			output.Fatal("CONFIG_ERROR", err)
+		}
+
+		if config.GetSecretsBackend() == secrets.BackendKeyring {
+			if err := secrets.PurgeKeyring(name); err != nil {
+				output.Fatal("SECRETS_ERROR", err)
+			}
+		}
+
+		output.Success(fmt.Sprintf("Profile deleted: %s", name))
+	},
+}
+
+func init() {
+	output.RegisterTable(api.OutputProfileList{}, output.Table{
+		Items: "profiles",
+		Columns: []output.Column{
+			{Header: "NAME", Path: "name"},
+			{Header: "APP_ID", Path: "app_id"},
+			{Header: "DEFAULT", Path: "default"},
+			{Header: "ACTIVE", Path: "active"},
+			{Header: "DIRECTORY", Path: "directory"},
+		},
+	})
+
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileDeleteCmd)
+}
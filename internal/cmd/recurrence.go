@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	timex "github.com/yjwong/lark-cli/internal/time"
+)
+
+// recurrenceFlags holds the recurrence flags shared by cal create and cal update
+type recurrenceFlags struct {
+	repeat   string
+	on       string
+	until    string
+	count    int
+	interval int
+	rrule    string
+}
+
+// addRecurrenceFlags registers the recurrence flags on cmd.
+// allowNone enables "--repeat none" for clearing an existing recurrence.
+func addRecurrenceFlags(cmd *cobra.Command, f *recurrenceFlags, allowNone bool) {
+	repeatHelp := "Repeat frequency (daily, weekly, monthly, yearly)"
+	if allowNone {
+		repeatHelp = "Repeat frequency (daily, weekly, monthly, yearly, or none to stop repeating)"
+	}
+	cmd.Flags().StringVar(&f.repeat, "repeat", "", repeatHelp)
+	cmd.Flags().StringVar(&f.on, "on", "", "Weekdays to repeat on (e.g., MO,WE,FR)")
+	cmd.Flags().StringVar(&f.until, "until", "", "Last date of the recurrence (e.g., 2026-12-31)")
+	cmd.Flags().IntVar(&f.count, "count", 0, "Number of occurrences")
+	cmd.Flags().IntVar(&f.interval, "interval", 0, "Repeat every N days/weeks/months/years")
+	cmd.Flags().StringVar(&f.rrule, "rrule", "", "Raw RFC 5545 recurrence rule (e.g., FREQ=WEEKLY;BYDAY=MO,WE)")
+}
+
+// build returns the recurrence rule described by the flags.
+// set is false when no recurrence flag was given; rule is empty when
+// the recurrence should be cleared (--repeat none).
+func (f *recurrenceFlags) build(loc *time.Location) (rule string, set bool, err error) {
+	hasParts := f.on != "" || f.until != "" || f.count != 0 || f.interval != 0
+
+	if f.rrule != "" {
+		if f.repeat != "" || hasParts {
+			return "", false, fmt.Errorf("--rrule cannot be combined with --repeat, --on, --until, --count, or --interval")
+		}
+		r, err := timex.ParseRRule(f.rrule, loc)
+		if err != nil {
+			return "", false, err
+		}
+		return r.String(), true, nil
+	}
+
+	if f.repeat == "" {
+		if hasParts {
+			return "", false, fmt.Errorf("--on, --until, --count, and --interval require --repeat")
+		}
+		return "", false, nil
+	}
+
+	if strings.EqualFold(f.repeat, "none") {
+		if hasParts {
+			return "", false, fmt.Errorf("--repeat none cannot be combined with --on, --until, --count, or --interval")
+		}
+		return "", true, nil
+	}
+
+	freq, err := timex.ParseFrequency(f.repeat)
+	if err != nil {
+		return "", false, err
+	}
+	r := timex.Recurrence{Freq: freq}
+
+	if f.interval < 0 {
+		return "", false, fmt.Errorf("--interval must be positive")
+	}
+	r.Interval = f.interval
+
+	if f.on != "" {
+		days, err := timex.ParseByDay(f.on)
+		if err != nil {
+			return "", false, err
+		}
+		r.ByDay = days
+	}
+
+	if f.count < 0 {
+		return "", false, fmt.Errorf("--count must be positive")
+	}
+	if f.count > 0 && f.until != "" {
+		return "", false, fmt.Errorf("--count and --until are mutually exclusive")
+	}
+	r.Count = f.count
+
+	if f.until != "" {
+		until, err := timex.Parse(f.until, loc)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to parse --until: %v", err)
+		}
+		// A bare date includes every occurrence on that day
+		if !containsTimeSpec(f.until) {
+			until = timex.EndOfDay(until).Truncate(time.Second)
+		}
+		r.Until = until
+	}
+
+	return r.String(), true, nil
+}
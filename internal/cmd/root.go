@@ -23,13 +23,21 @@ func SetVersionInfo(v, c, d string) {
 	date = d
 }
 
+// Global flags shared by all commands
+var (
+	profileName  string
+	outputFormat string
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "lark",
 	Short: "Lark CLI for Claude Code",
 	Long: `A CLI tool to interact with Lark APIs.
 Designed for use by Claude Code with JSON output.
 
-All commands output JSON by default.`,
+All commands output JSON by default. Use -o/--output for yaml, table,
+ndjson, go-template or jsonpath output. Commands that save files take the
+file path as --out.`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
 }
@@ -46,8 +54,16 @@ var versionCmd = &cobra.Command{
 
 // Execute runs the root command
 func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		output.Fatal("COMMAND_ERROR", err)
+	}
+}
+
+// initConfig loads the configuration for the selected profile.
+// It runs after flag parsing so that --profile is honored.
+func initConfig() {
 	// Initialize config, but don't fail for version command
-	if err := config.Init(); err != nil {
+	if err := config.Init(profileName); err != nil {
 		// Allow version command to run without config
 		if len(os.Args) >= 2 && os.Args[1] == "version" {
 			// Skip config error for version command
@@ -56,12 +72,50 @@ func Execute() {
 		}
 	}
 
-	if err := rootCmd.Execute(); err != nil {
-		output.Fatal("COMMAND_ERROR", err)
+	if err := output.SetFormat(outputFormat); err != nil {
+		// Commands that used to take a file path as --output still accept
+		// one; outPath picks it up
+		if cmd, _, findErr := rootCmd.Find(os.Args[1:]); findErr != nil || cmd.Annotations[legacyOutputPath] == "" {
+			output.Fatal("VALIDATION_ERROR", err)
+		}
+	}
+}
+
+// legacyOutputPath annotates commands that took a file path as -o/--output
+// before that became the output format. Its value is the flag that now
+// takes the path.
+const legacyOutputPath = "legacy-output-path"
+
+// addOutPathFlag adds --out, the file path flag that replaced a command's
+// -o/--output
+func addOutPathFlag(c *cobra.Command, value, usage string) {
+	c.Flags().String("out", value, usage)
+	if c.Annotations == nil {
+		c.Annotations = map[string]string{}
 	}
+	c.Annotations[legacyOutputPath] = "out"
+}
+
+// outPath returns the file path from --out. For compatibility, an
+// -o/--output value that isn't an output format is taken as the path, with
+// a deprecation warning.
+func outPath(cmd *cobra.Command) string {
+	path, _ := cmd.Flags().GetString("out")
+	if cmd.Flags().Changed("out") || !cmd.Flags().Changed("output") {
+		return path
+	}
+	if output.SetFormat(outputFormat) == nil {
+		return path
+	}
+	fmt.Fprintf(os.Stderr, "Warning: -o/--output as a file path is deprecated, use --out\n")
+	return outputFormat
 }
 
 func init() {
+	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Profile to use (default: LARK_PROFILE or the configured default profile)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "json", "Output format: "+output.FormatsUsage)
+
 	rootCmd.AddCommand(authCmd)
 	rootCmd.AddCommand(bitableCmd)
 	rootCmd.AddCommand(calCmd)
@@ -71,6 +125,7 @@ func init() {
 	rootCmd.AddCommand(mailCmd)
 	rootCmd.AddCommand(minutesCmd)
 	rootCmd.AddCommand(msgCmd)
+	rootCmd.AddCommand(profileCmd)
 	rootCmd.AddCommand(sheetCmd)
 	rootCmd.AddCommand(versionCmd)
 }
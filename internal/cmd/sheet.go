@@ -189,6 +189,17 @@ func columnIndexToLetter(index int) string {
 }
 
 func init() {
+	output.RegisterTable(api.OutputSheetList{}, output.Table{
+		Items: "sheets",
+		Columns: []output.Column{
+			{Header: "SHEET_ID", Path: "sheet_id"},
+			{Header: "TITLE", Path: "title"},
+			{Header: "INDEX", Path: "index"},
+			{Header: "ROWS", Path: "row_count"},
+			{Header: "COLUMNS", Path: "column_count"},
+		},
+	})
+
 	// Register subcommands
 	sheetCmd.AddCommand(sheetListCmd)
 	sheetCmd.AddCommand(sheetReadCmd)
@@ -22,6 +22,8 @@ var (
 	updateVisibility      string
 	updateAttendeeAbility string
 	updateNoNotify        bool
+	updateScope           string
+	updateRecurrence      recurrenceFlags
 )
 
 var updateCmd = &cobra.Command{
@@ -31,15 +33,32 @@ var updateCmd = &cobra.Command{
 
 Only specified fields will be updated.
 
+For recurring events, --scope selects what the change applies to:
+  this       only the given instance (default); the ID must be an instance,
+             not the series itself as returned by 'cal create --repeat'
+  following  the given instance and every later one; the series is split
+             into the original (ending before this instance) and a new series
+  all        every instance in the series
+
 Examples:
   lark cal update abc123 --summary "New title"
   lark cal update abc123 --start "2026-01-03T10:00:00+08:00"
   lark cal update abc123 --location "New location"
   lark cal update abc123 --color "#9CA2A9"
-  lark cal update abc123 --visibility public`,
+  lark cal update abc123 --visibility public
+  lark cal update abc123 --repeat weekly --on TU,TH --scope all
+  lark cal update abc123 --start "2026-03-02T10:00:00+08:00" --scope following
+  lark cal update abc123 --repeat none --scope all`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		eventID := args[0]
+
+		switch updateScope {
+		case "this", "following", "all":
+		default:
+			output.Fatalf("VALIDATION_ERROR", "Invalid scope: %s (must be this, following, or all)", updateScope)
+		}
+
 		client := api.NewClient()
 
 		// Get primary calendar
@@ -55,6 +74,11 @@ Examples:
 			loc = time.Local
 		}
 
+		recurrence, recurrenceSet, err := updateRecurrence.build(loc)
+		if err != nil {
+			output.Fatalf("VALIDATION_ERROR", "Invalid recurrence: %v", err)
+		}
+
 		// Build update request with only provided fields
 		req := &api.UpdateEventRequest{}
 
@@ -65,14 +89,15 @@ Examples:
 			req.Description = updateDescription
 		}
 
+		// The existing event is needed for time changes and to resolve its series
+		existingEvent, err := client.GetEvent(cal.CalendarID, eventID)
+		if err != nil {
+			output.Fatalf("API_ERROR", "Failed to fetch existing event: %v", err)
+		}
+
 		// Handle start/end time updates
 		// Per Lark API docs: start_time and end_time must both be provided for time changes to take effect
 		if updateStart != "" || updateEnd != "" {
-			existingEvent, err := client.GetEvent(cal.CalendarID, eventID)
-			if err != nil {
-				output.Fatalf("API_ERROR", "Failed to fetch existing event: %v", err)
-			}
-
 			var currentStart, currentEnd time.Time
 			if existingEvent.StartTime != nil && existingEvent.StartTime.Timestamp != "" {
 				ts, _ := strconv.ParseInt(existingEvent.StartTime.Timestamp, 10, 64)
@@ -151,8 +176,47 @@ Examples:
 			}
 		}
 
+		if recurrenceSet {
+			req.Recurrence = &recurrence
+		}
+
+		// Resolve which event the change applies to
+		targetID := eventID
+		masterID := seriesMasterID(eventID, existingEvent)
+		switch updateScope {
+		case "this":
+			if recurrenceSet && existingEvent.RecurringEventID != "" {
+				output.Fatalf("VALIDATION_ERROR", "Cannot change the recurrence of a single instance; use --scope following or --scope all")
+			}
+			// Updating the series itself would change every instance
+			if masterID == eventID {
+				output.Fatalf("VALIDATION_ERROR", "Event %s is a recurring series, not an instance; pass an instance ID (see 'cal list') for --scope this, or use --scope all", eventID)
+			}
+		case "following", "all":
+			if masterID == "" {
+				output.Fatalf("VALIDATION_ERROR", "Event %s is not a recurring event; --scope %s only applies to recurring events", eventID, updateScope)
+			}
+			if masterID == eventID {
+				// Editing from the first instance is the same as editing the whole series
+				break
+			}
+
+			master, err := client.GetEvent(cal.CalendarID, masterID)
+			if err != nil {
+				output.Fatalf("API_ERROR", "Failed to fetch recurring event %s: %v", masterID, err)
+			}
+
+			if updateScope == "following" {
+				splitSeries(client, cal.CalendarID, master, existingEvent, req)
+				return
+			}
+
+			shiftSeriesTimes(req, existingEvent, master)
+			targetID = masterID
+		}
+
 		// Update event
-		event, err := client.UpdateEvent(cal.CalendarID, eventID, req)
+		event, err := client.UpdateEvent(cal.CalendarID, targetID, req)
 		if err != nil {
 			output.Fatal("API_ERROR", err)
 		}
@@ -177,4 +241,178 @@ func init() {
 	updateCmd.Flags().StringVar(&updateVisibility, "visibility", "", "Event visibility (default, public, or private)")
 	updateCmd.Flags().StringVar(&updateAttendeeAbility, "attendee-ability", "", "Guest permissions (none, can_see_others, can_invite_others, can_modify_event)")
 	updateCmd.Flags().BoolVar(&updateNoNotify, "no-notify", false, "Don't send notifications")
+	updateCmd.Flags().StringVar(&updateScope, "scope", "this", "For recurring events: this, following, or all")
+	addRecurrenceFlags(updateCmd, &updateRecurrence, true)
+}
+
+// seriesMasterID returns the ID of the recurring event that event belongs to,
+// or "" if it is a one-off event
+func seriesMasterID(eventID string, event *api.Event) string {
+	if event.RecurringEventID != "" {
+		return event.RecurringEventID
+	}
+	if event.Recurrence != "" {
+		return eventID
+	}
+	return ""
+}
+
+// eventTime converts a timestamped TimeInfo to a time.Time
+func eventTime(info *api.TimeInfo) (time.Time, bool) {
+	if info == nil || info.Timestamp == "" {
+		return time.Time{}, false
+	}
+	ts, err := strconv.ParseInt(info.Timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(ts, 0), true
+}
+
+// shiftSeriesTimes rewrites a time change made relative to one instance so
+// it applies to the series: moving an instance by 1h moves the series by 1h
+func shiftSeriesTimes(req *api.UpdateEventRequest, instance, master *api.Event) {
+	shift := func(newInfo, instanceInfo, masterInfo *api.TimeInfo) {
+		newTime, ok1 := eventTime(newInfo)
+		instanceTime, ok2 := eventTime(instanceInfo)
+		masterTime, ok3 := eventTime(masterInfo)
+		if !ok1 || !ok2 || !ok3 {
+			return
+		}
+		shifted := masterTime.Add(newTime.Sub(instanceTime))
+		newInfo.Timestamp = strconv.FormatInt(shifted.Unix(), 10)
+	}
+	if req.StartTime != nil {
+		shift(req.StartTime, instance.StartTime, master.StartTime)
+	}
+	if req.EndTime != nil {
+		shift(req.EndTime, instance.EndTime, master.EndTime)
+	}
+}
+
+// splitSeries applies an update to an instance and every later instance.
+// The series is ended just before the instance and a new series carrying
+// the update starts at the instance.
+func splitSeries(client *api.Client, calendarID string, master, instance *api.Event, req *api.UpdateEventRequest) {
+	instanceStart, ok := eventTime(instance.StartTime)
+	if !ok {
+		output.Fatalf("VALIDATION_ERROR", "Cannot split a series at an all-day instance")
+	}
+
+	loc := time.UTC
+	if master.StartTime != nil {
+		if l, err := time.LoadLocation(master.StartTime.Timezone); err == nil {
+			loc = l
+		}
+	}
+	rule, err := timex.ParseRRule(master.Recurrence, loc)
+	if err != nil {
+		output.Fatalf("API_ERROR", "Failed to parse recurrence of %s: %v", master.EventID, err)
+	}
+
+	newRule := master.Recurrence
+	if req.Recurrence != nil {
+		newRule = *req.Recurrence
+	} else if rule.Count > 0 {
+		output.Fatalf("VALIDATION_ERROR", "Series %s ends after a fixed number of occurrences; pass --repeat or --rrule for the following events", master.EventID)
+	}
+
+	event, err := client.CreateEvent(calendarID, followingSeries(master, instance, req, newRule))
+	if err != nil {
+		output.Fatalf("API_ERROR", "Failed to create the new series: %v", err)
+	}
+
+	attendees := seriesAttendees(master)
+	if len(attendees) > 0 {
+		notify := req.NeedNotify == nil || *req.NeedNotify
+		added, err := client.CreateEventAttendees(calendarID, event.EventID, attendees, notify)
+		if err != nil {
+			output.Fatalf("ATTENDEE_ERROR", "Failed to copy attendees to the new series %s: %v", event.EventID, err)
+		}
+		event.Attendees = added
+	}
+
+	// End the original series just before the instance
+	until := endRuleBefore(rule, instanceStart)
+	if _, err := client.UpdateEvent(calendarID, master.EventID, &api.UpdateEventRequest{
+		Recurrence: &until,
+		NeedNotify: req.NeedNotify,
+	}); err != nil {
+		// Don't leave two overlapping series behind
+		_ = client.DeleteEvent(calendarID, event.EventID)
+		output.Fatalf("API_ERROR", "Failed to end the original series: %v", err)
+	}
+
+	output.JSON(map[string]interface{}{
+		"success":         true,
+		"message":         fmt.Sprintf("Series split: %s ends before this instance, %s continues it", master.EventID, event.EventID),
+		"event":           api.ConvertToOutputEvent(*event),
+		"previous_series": master.EventID,
+	})
+}
+
+// followingSeries builds the series that continues a split from instance:
+// the master's fields overlaid with the update
+func followingSeries(master, instance *api.Event, req *api.UpdateEventRequest, rule string) *api.CreateEventRequest {
+	create := &api.CreateEventRequest{
+		Summary:         master.Summary,
+		Description:     master.Description,
+		StartTime:       instance.StartTime,
+		EndTime:         instance.EndTime,
+		Location:        master.Location,
+		Color:           master.Color,
+		Reminders:       master.Reminders,
+		Recurrence:      rule,
+		Vchat:           master.Vchat,
+		Visibility:      master.Visibility,
+		AttendeeAbility: master.AttendeeAbility,
+		NeedNotify:      req.NeedNotify,
+	}
+	if req.Summary != "" {
+		create.Summary = req.Summary
+	}
+	if req.Description != "" {
+		create.Description = req.Description
+	}
+	if req.StartTime != nil {
+		create.StartTime = req.StartTime
+	}
+	if req.EndTime != nil {
+		create.EndTime = req.EndTime
+	}
+	if req.Location != nil {
+		create.Location = req.Location
+	}
+	if req.Color != nil {
+		create.Color = *req.Color
+	}
+	if req.Visibility != "" {
+		create.Visibility = req.Visibility
+	}
+	if req.AttendeeAbility != "" {
+		create.AttendeeAbility = req.AttendeeAbility
+	}
+	return create
+}
+
+// seriesAttendees returns the master's user and external attendees, ready
+// to be added to another event
+func seriesAttendees(master *api.Event) []api.Attendee {
+	var attendees []api.Attendee
+	for _, a := range master.Attendees {
+		switch a.Type {
+		case "user":
+			attendees = append(attendees, api.Attendee{Type: a.Type, UserID: a.UserID, IsOptional: a.IsOptional})
+		case "third_party":
+			attendees = append(attendees, api.Attendee{Type: a.Type, ThirdPartyEmail: a.ThirdPartyEmail, IsOptional: a.IsOptional})
+		}
+	}
+	return attendees
+}
+
+// endRuleBefore returns rule changed to end with the last occurrence before t
+func endRuleBefore(rule timex.Recurrence, t time.Time) string {
+	rule.Count = 0
+	rule.Until, rule.UntilDay = t.Add(-time.Second), false
+	return rule.String()
 }
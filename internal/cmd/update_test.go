@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yjwong/lark-cli/internal/api"
+	timex "github.com/yjwong/lark-cli/internal/time"
+)
+
+func at(ts string) *api.TimeInfo {
+	return &api.TimeInfo{Timestamp: ts, Timezone: "Asia/Singapore"}
+}
+
+func TestSeriesMasterID(t *testing.T) {
+	tests := []struct {
+		event *api.Event
+		want  string
+	}{
+		{&api.Event{Recurrence: "FREQ=DAILY"}, "evt_1"},
+		{&api.Event{RecurringEventID: "evt_master"}, "evt_master"},
+		{&api.Event{}, ""},
+	}
+	for _, tt := range tests {
+		if got := seriesMasterID("evt_1", tt.event); got != tt.want {
+			t.Errorf("seriesMasterID(%+v) = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestShiftSeriesTimes(t *testing.T) {
+	// Daily 09:00-09:30 series; a later instance is moved an hour later
+	master := &api.Event{StartTime: at("1760662800"), EndTime: at("1760664600")}
+	instance := &api.Event{StartTime: at("1761008400"), EndTime: at("1761010200")}
+
+	req := &api.UpdateEventRequest{StartTime: at("1761012000"), EndTime: at("1761013800")}
+	shiftSeriesTimes(req, instance, master)
+	if req.StartTime.Timestamp != "1760666400" || req.EndTime.Timestamp != "1760668200" {
+		t.Errorf("shifted to %s-%s", req.StartTime.Timestamp, req.EndTime.Timestamp)
+	}
+
+	// Only the end changes
+	req = &api.UpdateEventRequest{EndTime: at("1761011100")}
+	shiftSeriesTimes(req, instance, master)
+	if req.StartTime != nil || req.EndTime.Timestamp != "1760665500" {
+		t.Errorf("shifted to %v-%s", req.StartTime, req.EndTime.Timestamp)
+	}
+
+	// All-day times are left alone
+	allDay := &api.Event{StartTime: &api.TimeInfo{Date: "2025-10-17"}, EndTime: &api.TimeInfo{Date: "2025-10-18"}}
+	req = &api.UpdateEventRequest{StartTime: at("1761012000")}
+	shiftSeriesTimes(req, instance, allDay)
+	if req.StartTime.Timestamp != "1761012000" {
+		t.Errorf("all-day master shifted start to %s", req.StartTime.Timestamp)
+	}
+}
+
+func TestFollowingSeries(t *testing.T) {
+	master := &api.Event{
+		EventID:     "evt_master",
+		Summary:     "Standup",
+		Description: "Daily sync",
+		StartTime:   at("1760662800"),
+		EndTime:     at("1760664600"),
+		Location:    &api.Location{Name: "Room 1"},
+		Color:       3,
+		Recurrence:  "FREQ=DAILY",
+		Visibility:  "default",
+	}
+	instance := &api.Event{StartTime: at("1761008400"), EndTime: at("1761010200")}
+
+	notify := false
+	create := followingSeries(master, instance, &api.UpdateEventRequest{}, master.Recurrence)
+	want := &api.CreateEventRequest{
+		Summary:     "Standup",
+		Description: "Daily sync",
+		StartTime:   instance.StartTime,
+		EndTime:     instance.EndTime,
+		Location:    master.Location,
+		Color:       3,
+		Recurrence:  "FREQ=DAILY",
+		Visibility:  "default",
+	}
+	if !reflect.DeepEqual(create, want) {
+		t.Errorf("followingSeries = %+v, want %+v", create, want)
+	}
+
+	color := 5
+	req := &api.UpdateEventRequest{
+		Summary:    "Team standup",
+		StartTime:  at("1761012000"),
+		Location:   &api.Location{Name: "Room 4"},
+		Color:      &color,
+		NeedNotify: &notify,
+	}
+	create = followingSeries(master, instance, req, "FREQ=WEEKLY;BYDAY=MO")
+	want = &api.CreateEventRequest{
+		Summary:     "Team standup",
+		Description: "Daily sync",
+		StartTime:   req.StartTime,
+		EndTime:     instance.EndTime,
+		Location:    req.Location,
+		Color:       5,
+		Recurrence:  "FREQ=WEEKLY;BYDAY=MO",
+		Visibility:  "default",
+		NeedNotify:  &notify,
+	}
+	if !reflect.DeepEqual(create, want) {
+		t.Errorf("followingSeries = %+v, want %+v", create, want)
+	}
+}
+
+func TestSeriesAttendees(t *testing.T) {
+	master := &api.Event{Attendees: []api.Attendee{
+		{Type: "user", UserID: "ou_alice", AttendeeID: "att_1", RsvpStatus: "accept"},
+		{Type: "third_party", ThirdPartyEmail: "guest@partner.com", IsOptional: true},
+		{Type: "chat", ChatID: "oc_team"},
+	}}
+	want := []api.Attendee{
+		{Type: "user", UserID: "ou_alice"},
+		{Type: "third_party", ThirdPartyEmail: "guest@partner.com", IsOptional: true},
+	}
+	if got := seriesAttendees(master); !reflect.DeepEqual(got, want) {
+		t.Errorf("seriesAttendees = %+v, want %+v", got, want)
+	}
+}
+
+func TestEndRuleBefore(t *testing.T) {
+	instanceStart := time.Date(2026, 10, 22, 9, 0, 0, 0, time.FixedZone("SGT", 8*3600))
+	for rule, want := range map[string]string{
+		"FREQ=DAILY":                         "FREQ=DAILY;UNTIL=20261022T005959Z",
+		"FREQ=WEEKLY;BYDAY=MO;COUNT=10":      "FREQ=WEEKLY;BYDAY=MO;UNTIL=20261022T005959Z",
+		"FREQ=WEEKLY;UNTIL=20271231T000000Z": "FREQ=WEEKLY;UNTIL=20261022T005959Z",
+		"FREQ=WEEKLY;UNTIL=20271231":         "FREQ=WEEKLY;UNTIL=20261022T005959Z",
+	} {
+		r, err := timex.ParseRRule(rule, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := endRuleBefore(r, instanceStart); got != want {
+			t.Errorf("endRuleBefore(%q) = %q, want %q", rule, got, want)
+		}
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -11,6 +14,9 @@ import (
 type Config struct {
 	AppID     string `mapstructure:"app_id"`
 	AppSecret string `mapstructure:"app_secret"`
+	Region    string `mapstructure:"region"`
+	BaseURL   string `mapstructure:"base_url"`
+	BotMode   bool   `mapstructure:"bot_mode"`
 	Defaults  struct {
 		Timezone        string `mapstructure:"timezone"`
 		ReminderMinutes int    `mapstructure:"reminder_minutes"`
@@ -18,13 +24,45 @@ type Config struct {
 	OAuth struct {
 		RedirectPort int `mapstructure:"redirect_port"`
 	} `mapstructure:"oauth"`
-	CustomEmojis map[string]string `mapstructure:"custom_emojis"`
+	Secrets struct {
+		Backend      string `mapstructure:"backend"`
+		IdentityFile string `mapstructure:"identity_file"`
+	} `mapstructure:"secrets"`
+	CustomEmojis   map[string]string `mapstructure:"custom_emojis"`
+	DefaultProfile string            `mapstructure:"default_profile"`
 }
 
+// Supported regions
+const (
+	RegionLark   = "lark"
+	RegionFeishu = "feishu"
+)
+
+// regionHosts maps each region to its Open Platform and accounts hosts
+var regionHosts = map[string]struct {
+	open     string
+	accounts string
+}{
+	RegionLark:   {open: "https://open.larksuite.com", accounts: "https://accounts.larksuite.com"},
+	RegionFeishu: {open: "https://open.feishu.cn", accounts: "https://accounts.feishu.cn"},
+}
+
+// DefaultProfile is the profile whose files live directly in the config
+// directory, matching the layout used before profiles existed
+const DefaultProfile = "default"
+
+// profilesDirName is the subdirectory holding named profiles
+const profilesDirName = "profiles"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
 var (
-	cfg     *Config
-	cfgDir  string
-	rootDir string
+	cfg            *Config
+	cfgDir         string
+	rootDir        string
+	profile        string
+	profileDir     string
+	defaultProfile string
 )
 
 // GetConfigDir returns the .lark directory path
@@ -32,13 +70,31 @@ func GetConfigDir() string {
 	return cfgDir
 }
 
+// GetProfile returns the name of the active profile
+func GetProfile() string {
+	return profile
+}
+
+// GetProfileDir returns the directory holding the active profile's
+// tokens, mail credentials and cache
+func GetProfileDir() string {
+	return profileDir
+}
+
+// GetDefaultProfile returns the profile used when none is specified
+func GetDefaultProfile() string {
+	return defaultProfile
+}
+
 // GetRootDir returns the project root directory
 func GetRootDir() string {
 	return rootDir
 }
 
-// Init initializes the configuration
-func Init() error {
+// Init initializes the configuration for the given profile.
+// An empty profile name falls back to LARK_PROFILE, then to the
+// default_profile recorded in config.yaml, then to "default".
+func Init(profileName string) error {
 	// Config directory can be set via LARK_CONFIG_DIR or legacy LARK_CAL_CONFIG_DIR
 	cfgDir = os.Getenv("LARK_CONFIG_DIR")
 	if cfgDir == "" {
@@ -63,11 +119,18 @@ func Init() error {
 	viper.SetDefault("defaults.timezone", "Asia/Singapore")
 	viper.SetDefault("defaults.reminder_minutes", 15)
 	viper.SetDefault("oauth.redirect_port", 9999)
+	viper.SetDefault("region", RegionLark)
+	viper.SetDefault("secrets.backend", "file")
 
 	// Environment variable bindings
 	viper.SetEnvPrefix("LARK")
 	viper.BindEnv("app_id", "LARK_APP_ID")
 	viper.BindEnv("app_secret", "LARK_APP_SECRET")
+	viper.BindEnv("region", "LARK_REGION")
+	viper.BindEnv("base_url", "LARK_BASE_URL")
+	viper.BindEnv("bot_mode", "LARK_BOT_MODE")
+	viper.BindEnv("secrets.backend", "LARK_SECRETS_BACKEND")
+	viper.BindEnv("secrets.identity_file", "LARK_SECRETS_IDENTITY")
 
 	// Read config file (if exists)
 	if err := viper.ReadInConfig(); err != nil {
@@ -77,11 +140,43 @@ func Init() error {
 		// Config file not found is OK, we'll use defaults and env vars
 	}
 
+	defaultProfile = viper.GetString("default_profile")
+	if defaultProfile == "" {
+		defaultProfile = DefaultProfile
+	}
+
+	if profileName == "" {
+		profileName = os.Getenv("LARK_PROFILE")
+	}
+	if profileName == "" {
+		profileName = defaultProfile
+	}
+	if err := ValidateProfileName(profileName); err != nil {
+		return err
+	}
+	profile = profileName
+	profileDir = ProfileDir(profileName)
+
+	// Named profiles layer their own config.yaml over the shared one
+	if profile != DefaultProfile {
+		profileConfig := filepath.Join(profileDir, "config.yaml")
+		if _, err := os.Stat(profileConfig); err == nil {
+			viper.SetConfigFile(profileConfig)
+			if err := viper.MergeInConfig(); err != nil {
+				return fmt.Errorf("error reading profile config: %w", err)
+			}
+		}
+	}
+
 	cfg = &Config{}
 	if err := viper.Unmarshal(cfg); err != nil {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if _, ok := regionHosts[GetRegion()]; !ok {
+		return fmt.Errorf("invalid region %q (must be %q or %q)", cfg.Region, RegionLark, RegionFeishu)
+	}
+
 	return nil
 }
 
@@ -113,14 +208,182 @@ func GetRedirectPort() int {
 	return viper.GetInt("oauth.redirect_port")
 }
 
-// TokensFilePath returns the path to the tokens file
-func TokensFilePath() string {
-	return filepath.Join(cfgDir, "tokens.json")
+// GetRegion returns the configured region ("lark" or "feishu")
+func GetRegion() string {
+	return strings.ToLower(viper.GetString("region"))
+}
+
+// GetBaseURL returns the Open Platform base URL (without the /open-apis suffix).
+// An explicit base_url takes precedence over the region default, which allows
+// pointing the CLI at a private deployment or a local stub server.
+func GetBaseURL() string {
+	if baseURL := viper.GetString("base_url"); baseURL != "" {
+		return strings.TrimRight(baseURL, "/")
+	}
+	if hosts, ok := regionHosts[GetRegion()]; ok {
+		return hosts.open
+	}
+	return regionHosts[RegionLark].open
+}
+
+// GetAccountsURL returns the base URL used for the OAuth authorize page.
+// When base_url is set, the same host serves the authorize endpoint.
+func GetAccountsURL() string {
+	if baseURL := viper.GetString("base_url"); baseURL != "" {
+		return strings.TrimRight(baseURL, "/")
+	}
+	if hosts, ok := regionHosts[GetRegion()]; ok {
+		return hosts.accounts
+	}
+	return regionHosts[RegionLark].accounts
+}
+
+// IsBotMode reports whether the CLI runs with the app's tenant access token
+// only, without a user login (e.g. in CI jobs)
+func IsBotMode() bool {
+	return viper.GetBool("bot_mode")
 }
 
-// TenantTokensFilePath returns the path to the tenant tokens file
-func TenantTokensFilePath() string {
-	return filepath.Join(cfgDir, "tenant_tokens.json")
+// GetSecretsBackend returns the secret storage backend (file, keyring or encrypted)
+func GetSecretsBackend() string {
+	return strings.ToLower(viper.GetString("secrets.backend"))
+}
+
+// GetSecretsIdentityFile returns the age identity file for the encrypted backend
+func GetSecretsIdentityFile() string {
+	path := viper.GetString("secrets.identity_file")
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// ValidateProfileName checks that a profile name is safe to use as a directory name
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, '-' and '_')", name)
+	}
+	return nil
+}
+
+// ProfileDir returns the directory for the named profile
+func ProfileDir(name string) string {
+	if name == DefaultProfile {
+		return cfgDir
+	}
+	return filepath.Join(cfgDir, profilesDirName, name)
+}
+
+// EnsureProfileDir creates the active profile's directory if needed.
+// Named profiles are created lazily on first write (e.g. after login).
+func EnsureProfileDir() error {
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	return nil
+}
+
+// ProfileExists reports whether the named profile has been created
+func ProfileExists(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	info, err := os.Stat(ProfileDir(name))
+	return err == nil && info.IsDir()
+}
+
+// ListProfiles returns all profile names, with "default" first
+func ListProfiles() ([]string, error) {
+	names := []string{DefaultProfile}
+
+	entries, err := os.ReadDir(filepath.Join(cfgDir, profilesDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return names, nil
+		}
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+
+	var named []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != DefaultProfile && ValidateProfileName(entry.Name()) == nil {
+			named = append(named, entry.Name())
+		}
+	}
+	sort.Strings(named)
+
+	return append(names, named...), nil
+}
+
+// ProfileAppID returns the app_id configured for the named profile,
+// falling back to the shared config for named profiles without one
+func ProfileAppID(name string) string {
+	if name != DefaultProfile {
+		if appID := readConfigValue(filepath.Join(ProfileDir(name), "config.yaml"), "app_id"); appID != "" {
+			return appID
+		}
+	}
+	return readConfigValue(filepath.Join(cfgDir, "config.yaml"), "app_id")
+}
+
+// SetDefaultProfile records the default profile in the shared config file
+func SetDefaultProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	path := filepath.Join(cfgDir, "config.yaml")
+	v := viper.New()
+	v.SetConfigFile(path)
+	if _, err := os.Stat(path); err == nil {
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("error reading config: %w", err)
+		}
+	}
+
+	v.Set("default_profile", name)
+	if err := v.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	defaultProfile = name
+	return nil
+}
+
+// DeleteProfile removes a named profile and all of its files
+func DeleteProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if name == DefaultProfile {
+		return fmt.Errorf("the %q profile cannot be deleted", DefaultProfile)
+	}
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	if name == defaultProfile {
+		return fmt.Errorf("profile %q is the default; switch with 'lark profile use' first", name)
+	}
+
+	if err := os.RemoveAll(ProfileDir(name)); err != nil {
+		return fmt.Errorf("failed to delete profile: %w", err)
+	}
+	return nil
+}
+
+// readConfigValue reads a single key from a YAML config file
+func readConfigValue(path, key string) string {
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return ""
+	}
+	return v.GetString(key)
 }
 
 // GetCustomEmojis returns the custom emoji mappings
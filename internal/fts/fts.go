@@ -0,0 +1,25 @@
+// Package fts builds SQLite FTS5 queries from search text typed by users.
+package fts
+
+import "strings"
+
+// Query turns search text into an FTS5 query that matches every word.
+// Words are quoted so URLs, addresses and filenames like "q4-report.pdf"
+// aren't read as query syntax; a trailing * still matches by prefix.
+// Blank text gives "", which callers treat as no text filter since FTS5
+// rejects an empty MATCH.
+func Query(text string) string {
+	words := strings.Fields(text)
+	for i, w := range words {
+		prefix := len(w) > 1 && strings.HasSuffix(w, "*")
+		if prefix {
+			w = w[:len(w)-1]
+		}
+		w = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+		if prefix {
+			w += "*"
+		}
+		words[i] = w
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,18 @@
+package fts
+
+import "testing"
+
+func TestQuery(t *testing.T) {
+	for text, want := range map[string]string{
+		"budget forecast":   `"budget" "forecast"`,
+		"example.com/specs": `"example.com/specs"`,
+		"ship* *":           `"ship"* "*"`,
+		`say "hi"`:          `"say" """hi"""`,
+		"  \t\n":            "",
+		"":                  "",
+	} {
+		if got := Query(text); got != want {
+			t.Errorf("Query(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package ics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// maxLineOctets is the longest content line allowed before folding
+const maxLineOctets = 75
+
+// Parse reads an iCalendar stream and returns its VCALENDAR component
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for n, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			c := NewComponent(strings.ToUpper(prop.Value))
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			} else if root == nil {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", n+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property %s outside a component", n+1, prop.Name)
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, prop)
+		}
+	}
+
+	if root == nil || root.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("no VCALENDAR found")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// unfold joins folded content lines
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine splits NAME;PARAM=VALUE;PARAM="QUOTED":value
+func parseLine(line string) (Property, error) {
+	prop := Property{}
+	i := strings.IndexAny(line, ";:")
+	if i < 0 {
+		return prop, fmt.Errorf("invalid content line: %s", line)
+	}
+	prop.Name = strings.ToUpper(line[:i])
+
+	for line[i] == ';' {
+		line = line[i+1:]
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			return prop, fmt.Errorf("invalid parameter in %s", prop.Name)
+		}
+		name := strings.ToUpper(line[:eq])
+		line = line[eq+1:]
+
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				return prop, fmt.Errorf("unterminated quote in %s", prop.Name)
+			}
+			value = line[1 : end+1]
+			line = line[end+2:]
+			i = 0
+			if line == "" {
+				return prop, fmt.Errorf("missing value for %s", prop.Name)
+			}
+		} else {
+			i = strings.IndexAny(line, ";:")
+			if i < 0 {
+				return prop, fmt.Errorf("missing value for %s", prop.Name)
+			}
+			value = line[:i]
+		}
+		if prop.Params == nil {
+			prop.Params = make(map[string]string)
+		}
+		prop.Params[name] = value
+		if line[i] != ';' && line[i] != ':' {
+			return prop, fmt.Errorf("invalid parameter in %s", prop.Name)
+		}
+	}
+
+	prop.Value = line[i+1:]
+	return prop, nil
+}
+
+// Write serializes a component with CRLF line endings and folding
+func Write(w io.Writer, c *Component) error {
+	bw := bufio.NewWriter(w)
+	writeComponent(bw, c)
+	return bw.Flush()
+}
+
+func writeComponent(w *bufio.Writer, c *Component) {
+	writeLine(w, "BEGIN:"+c.Name)
+	for _, p := range c.Properties {
+		writeLine(w, formatProperty(p))
+	}
+	for _, child := range c.Components {
+		writeComponent(w, child)
+	}
+	writeLine(w, "END:"+c.Name)
+}
+
+func formatProperty(p Property) string {
+	var b strings.Builder
+	b.WriteString(p.Name)
+
+	names := make([]string, 0, len(p.Params))
+	for name := range p.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := p.Params[name]
+		if strings.ContainsAny(value, ";:,") {
+			value = `"` + value + `"`
+		}
+		b.WriteString(";" + name + "=" + value)
+	}
+
+	b.WriteString(":" + p.Value)
+	return b.String()
+}
+
+// writeLine folds a content line at 75 octets without splitting UTF-8 sequences
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line + "\r\n")
+}
@@ -0,0 +1,180 @@
+// Package ics reads and writes iCalendar (RFC 5545) data.
+package ics
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Property is a content line such as DTSTART;TZID=Asia/Singapore:20261020T090000
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Param returns a parameter value, or "" if it is not set
+func (p *Property) Param(name string) string {
+	return p.Params[strings.ToUpper(name)]
+}
+
+// Component is a calendar component such as VCALENDAR, VEVENT or VALARM
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// NewComponent returns an empty component
+func NewComponent(name string) *Component {
+	return &Component{Name: name}
+}
+
+// Add appends a property. params may be nil.
+func (c *Component) Add(name, value string, params map[string]string) {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value})
+}
+
+// AddText appends a TEXT property, escaping the value
+func (c *Component) AddText(name, value string) {
+	c.Add(name, EscapeText(value), nil)
+}
+
+// Get returns the first property with the given name, or nil
+func (c *Component) Get(name string) *Property {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+// GetAll returns every property with the given name
+func (c *Component) GetAll(name string) []Property {
+	var props []Property
+	for _, p := range c.Properties {
+		if p.Name == name {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// Text returns the unescaped value of a TEXT property, or ""
+func (c *Component) Text(name string) string {
+	if p := c.Get(name); p != nil {
+		return UnescapeText(p.Value)
+	}
+	return ""
+}
+
+// Children returns the sub-components with the given name
+func (c *Component) Children(name string) []*Component {
+	var children []*Component
+	for _, child := range c.Components {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// EscapeText escapes a TEXT value
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// UnescapeText reverses EscapeText
+func UnescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// FormatUTC formats a DATE-TIME value in UTC
+func FormatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// FormatDate formats a DATE value
+func FormatDate(t time.Time) string {
+	return t.Format("20060102")
+}
+
+// Time parses a DATE or DATE-TIME property. Floating times (no Z and no
+// TZID) are interpreted in loc. allDay is true for VALUE=DATE.
+func (p *Property) Time(loc *time.Location) (t time.Time, allDay bool, err error) {
+	value := strings.TrimSpace(p.Value)
+	if p.Param("VALUE") == "DATE" || len(value) == 8 {
+		t, err = time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	if tzid := p.Param("TZID"); tzid != "" {
+		tzLoc, err := time.LoadLocation(strings.Trim(tzid, "/"))
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc = tzLoc
+	}
+	t, err = time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+var durationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseDuration parses a DURATION value such as -PT15M or P1D
+func ParseDuration(s string) (time.Duration, error) {
+	m := durationRe.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if m == nil || s == "P" {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] != "" {
+			n, _ := strconv.Atoi(m[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// FormatDuration formats a duration as a DURATION value
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	if d%(24*time.Hour) == 0 && d > 0 {
+		return fmt.Sprintf("%sP%dD", sign, d/(24*time.Hour))
+	}
+	return fmt.Sprintf("%sPT%dM", sign, d/time.Minute)
+}
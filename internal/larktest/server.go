@@ -0,0 +1,207 @@
+// Package larktest provides a fake Lark Open API server that replays
+// recorded fixtures, for testing commands without the real service.
+package larktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TenantTokenPath is served by every Server so bot and tenant-token
+// requests work without a fixture. The long connection endpoints in ws.go
+// are served the same way.
+const TenantTokenPath = "/open-apis/auth/v3/tenant_access_token/internal"
+
+// Fixture is a recorded API response
+type Fixture struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`            // full request path, e.g. /open-apis/calendar/v4/calendars/primary
+	Query  map[string]string `json:"query,omitempty"` // query parameters that must match, if set
+	Status int               `json:"status,omitempty"`
+	// ContentType defaults to application/json
+	ContentType string `json:"content_type,omitempty"`
+	// Body is the JSON response; Raw is used instead for non-JSON responses
+	Body json.RawMessage `json:"body,omitempty"`
+	Raw  string          `json:"raw,omitempty"`
+}
+
+// Request is a request received by the server
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   string
+}
+
+// Server is a fake Lark Open API
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	fixtures []Fixture
+	requests []Request
+}
+
+// NewServer starts a server that answers with the given fixtures
+func NewServer(fixtures []Fixture) *Server {
+	s := &Server{fixtures: fixtures}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// LoadFixtures reads every *.json file in dir. Each file holds an array of fixtures.
+func LoadFixtures(dir string) ([]Fixture, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var fixtures []Fixture
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var batch []Fixture
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		fixtures = append(fixtures, batch...)
+	}
+	return fixtures, nil
+}
+
+// describeBody returns the request body as recorded. Multipart uploads
+// are summarized one part per line, since their boundaries are random.
+func describeBody(contentType string, body []byte) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		return string(body)
+	}
+	var parts []string
+	reader := multipart.NewReader(strings.NewReader(string(body)), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		data, _ := io.ReadAll(part)
+		if part.FileName() != "" {
+			parts = append(parts, fmt.Sprintf("%s=<file %s, %d bytes>", part.FormName(), part.FileName(), len(data)))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s=%s", part.FormName(), data))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Requests returns the requests received since the last Reset, excluding
+// tenant token requests
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Reset forgets the recorded requests
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == TenantTokenPath:
+		writeJSON(w, http.StatusOK, `{"code":0,"msg":"ok","tenant_access_token":"t-test","expire":7200}`)
+		return
+	case r.Method == http.MethodPost && r.URL.Path == WSEndpointPath:
+		s.serveWSEndpoint(w, r)
+		return
+	case r.URL.Path == WSPath:
+		s.serveWS(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Body:   describeBody(r.Header.Get("Content-Type"), body),
+	})
+	s.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeJSON(w, http.StatusUnauthorized, `{"code":99991661,"msg":"missing access token"}`)
+		return
+	}
+
+	f := s.match(r)
+	if f == nil {
+		msg, _ := json.Marshal(fmt.Sprintf("no fixture for %s %s", r.Method, r.URL.RequestURI()))
+		writeJSON(w, http.StatusNotFound, fmt.Sprintf(`{"code":404,"msg":%s}`, msg))
+		return
+	}
+
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if f.Body == nil {
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		io.WriteString(w, f.Raw)
+		return
+	}
+	writeJSON(w, status, string(f.Body))
+}
+
+// match returns the most specific fixture for the request: the one with
+// the most matching query parameters
+func (s *Server) match(r *http.Request) *Fixture {
+	var best *Fixture
+	for i := range s.fixtures {
+		f := &s.fixtures[i]
+		if f.Method != r.Method || f.Path != r.URL.Path || !queryMatches(f.Query, r.URL.Query()) {
+			continue
+		}
+		if best == nil || len(f.Query) > len(best.Query) {
+			best = f
+		}
+	}
+	return best
+}
+
+func queryMatches(want map[string]string, got url.Values) bool {
+	for k, v := range want {
+		if got.Get(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	io.WriteString(w, body)
+}
@@ -0,0 +1,109 @@
+package larktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yjwong/lark-cli/internal/larkws"
+)
+
+// Long connection paths. Fixtures with method EVENT are pushed, in order,
+// to every client that connects to WSPath.
+const (
+	WSEndpointPath = "/callback/ws/endpoint"
+	WSPath         = "/ws"
+)
+
+// wsSplitSize is the payload size above which events are sent in parts,
+// so clients exercise reassembly
+const wsSplitSize = 512
+
+var upgrader = websocket.Upgrader{}
+
+// serveWSEndpoint answers the long connection endpoint request with this server's URL
+func (s *Server) serveWSEndpoint(w http.ResponseWriter, r *http.Request) {
+	wsURL := "ws" + strings.TrimPrefix(s.URL, "http") + WSPath + "?device_id=test&service_id=7"
+	body, _ := json.Marshal(map[string]interface{}{
+		"code": 0,
+		"msg":  "ok",
+		"data": map[string]interface{}{
+			"URL": wsURL,
+			"ClientConfig": map[string]int{
+				"PingInterval":      120,
+				"ReconnectCount":    0,
+				"ReconnectInterval": 1,
+				"ReconnectNonce":    1,
+			},
+		},
+	})
+	writeJSON(w, http.StatusOK, string(body))
+}
+
+// serveWS pushes EVENT fixtures as data frames. Frames the client sends
+// back (acks and pings) are read but not recorded, since a client that
+// exits after its last event may or may not get to ack it.
+func (s *Server) serveWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	go func() {
+		for _, f := range s.fixtures {
+			if f.Method != "EVENT" {
+				continue
+			}
+			for _, frame := range eventFrames(f.Body) {
+				if conn.WriteMessage(websocket.BinaryMessage, frame.Marshal()) != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// eventFrames wraps an event payload in one or more data frames
+func eventFrames(payload []byte) []*larkws.Frame {
+	var event struct {
+		Header struct {
+			EventID string `json:"event_id"`
+		} `json:"header"`
+	}
+	json.Unmarshal(payload, &event)
+
+	var parts [][]byte
+	for len(payload) > wsSplitSize {
+		parts = append(parts, payload[:wsSplitSize])
+		payload = payload[wsSplitSize:]
+	}
+	parts = append(parts, payload)
+
+	frames := make([]*larkws.Frame, len(parts))
+	for i, part := range parts {
+		frames[i] = &larkws.Frame{
+			Method:  1,
+			Service: 7,
+			Headers: []larkws.Header{
+				{Key: "type", Value: "event"},
+				{Key: "message_id", Value: fmt.Sprintf("msg_%s", event.Header.EventID)},
+				{Key: "sum", Value: strconv.Itoa(len(parts))},
+				{Key: "seq", Value: strconv.Itoa(i)},
+			},
+			Payload: part,
+		}
+	}
+	return frames
+}
@@ -0,0 +1,454 @@
+// Package larkws implements Lark's event subscription long connection: a
+// websocket that delivers app events without a public callback URL.
+package larkws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const endpointPath = "/callback/ws/endpoint"
+
+// Endpoint response codes that are worth retrying
+const (
+	codeSystemBusy    = 1
+	codeInternalError = 1000040343
+)
+
+// Default connection settings, used until the server sends its own
+const (
+	defaultPingInterval      = 120 * time.Second
+	defaultReconnectInterval = 120 * time.Second
+	defaultReconnectNonce    = 30 * time.Second
+)
+
+// Event is an event delivered over the connection (schema 2.0 envelope)
+type Event struct {
+	Schema string          `json:"schema"`
+	Header EventHeader     `json:"header"`
+	Event  json.RawMessage `json:"event"`
+}
+
+// EventHeader identifies an event
+type EventHeader struct {
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"` // e.g. im.message.receive_v1
+	CreateTime string `json:"create_time"`
+	AppID      string `json:"app_id"`
+	TenantKey  string `json:"tenant_key"`
+}
+
+// Handler processes an event. Returning an error reports a failure to Lark,
+// which may deliver the event again.
+type Handler func(*Event) error
+
+// Options configures a Client
+type Options struct {
+	AppID     string
+	AppSecret string
+	BaseURL   string    // Open Platform base URL, without /open-apis
+	Log       io.Writer // If set, connection status is written here
+
+	// ReconnectCount limits reconnect attempts after a connection is lost;
+	// -1 retries forever. The server's setting is used when 0.
+	ReconnectCount int
+}
+
+// clientConfig holds connection settings sent by the server
+type clientConfig struct {
+	ReconnectCount    int `json:"ReconnectCount"`
+	ReconnectInterval int `json:"ReconnectInterval"` // seconds
+	ReconnectNonce    int `json:"ReconnectNonce"`    // seconds
+	PingInterval      int `json:"PingInterval"`      // seconds
+}
+
+// Client maintains the long connection and dispatches events
+type Client struct {
+	opts       Options
+	handler    Handler
+	httpClient *http.Client
+
+	mu                sync.Mutex
+	pingInterval      time.Duration
+	reconnectCount    int
+	reconnectInterval time.Duration
+	reconnectNonce    time.Duration
+
+	writeMu   sync.Mutex
+	conn      *websocket.Conn
+	serviceID int32
+
+	fragments map[string][][]byte // message_id -> payload parts
+	seen      map[string]bool     // recently delivered event IDs
+}
+
+// ClientError is a failure that reconnecting won't fix, such as bad
+// credentials or an app without long connection mode enabled
+type ClientError struct {
+	Code int
+	Msg  string
+}
+
+func (e *ClientError) Error() string {
+	return fmt.Sprintf("long connection refused (code %d): %s", e.Code, e.Msg)
+}
+
+// NewClient creates a client that passes events to handler
+func NewClient(opts Options, handler Handler) *Client {
+	return &Client{
+		opts:              opts,
+		handler:           handler,
+		httpClient:        &http.Client{Timeout: 30 * time.Second},
+		pingInterval:      defaultPingInterval,
+		reconnectCount:    -1,
+		reconnectInterval: defaultReconnectInterval,
+		reconnectNonce:    defaultReconnectNonce,
+		fragments:         make(map[string][][]byte),
+		seen:              make(map[string]bool),
+	}
+}
+
+// Run connects and delivers events until ctx is cancelled or the connection
+// can't be re-established. It returns nil when ctx is cancelled.
+func (c *Client) Run(ctx context.Context) error {
+	attempts := 0
+	for {
+		err := c.connect(ctx)
+		if err == nil {
+			attempts = 0
+			err = c.serve(ctx)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		var clientErr *ClientError
+		if errors.As(err, &clientErr) {
+			return err
+		}
+
+		limit := c.opts.ReconnectCount
+		if limit == 0 {
+			c.mu.Lock()
+			limit = c.reconnectCount
+			c.mu.Unlock()
+		}
+		if limit >= 0 && attempts >= limit {
+			return fmt.Errorf("giving up after %d reconnect attempts: %w", attempts, err)
+		}
+		attempts++
+
+		delay := c.reconnectDelay(attempts)
+		c.logf("connection lost (%v), reconnecting in %s", err, delay.Round(time.Second))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// reconnectDelay spreads the first attempt over the nonce window so many
+// clients don't reconnect at once, then backs off at the server's interval
+func (c *Client) reconnectDelay(attempt int) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if attempt == 1 {
+		if c.reconnectNonce <= 0 {
+			return time.Second
+		}
+		return time.Second + time.Duration(rand.Int63n(int64(c.reconnectNonce)))
+	}
+	return c.reconnectInterval
+}
+
+// connect fetches a connection URL and opens the websocket
+func (c *Client) connect(ctx context.Context) error {
+	wsURL, err := c.endpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		if resp != nil {
+			code, _ := strconv.Atoi(resp.Header.Get("Handshake-Status"))
+			msg := resp.Header.Get("Handshake-Msg")
+			if code == http.StatusForbidden || code == 514 {
+				return &ClientError{Code: code, Msg: msg}
+			}
+			if msg != "" {
+				return fmt.Errorf("handshake failed (status %d): %s", code, msg)
+			}
+		}
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	c.writeMu.Lock()
+	c.conn = conn
+	c.serviceID = serviceIDFromURL(wsURL)
+	c.writeMu.Unlock()
+	c.logf("connected")
+	return nil
+}
+
+// endpoint asks the Open Platform for a websocket URL and connection settings
+func (c *Client) endpoint(ctx context.Context) (string, error) {
+	if c.opts.AppID == "" || c.opts.AppSecret == "" {
+		return "", &ClientError{Msg: "app_id and app_secret are required"}
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"AppID":     c.opts.AppID,
+		"AppSecret": c.opts.AppSecret,
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST", c.opts.BaseURL+endpointPath, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("locale", "zh")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("endpoint request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			URL          string        `json:"URL"`
+			ClientConfig *clientConfig `json:"ClientConfig"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse endpoint response (HTTP %d): %w", resp.StatusCode, err)
+	}
+
+	switch {
+	case result.Code == 0 && result.Data.URL != "":
+	case result.Code == codeSystemBusy || result.Code == codeInternalError || resp.StatusCode >= 500:
+		return "", fmt.Errorf("endpoint unavailable (code %d): %s", result.Code, result.Msg)
+	default:
+		return "", &ClientError{Code: result.Code, Msg: result.Msg}
+	}
+
+	if result.Data.ClientConfig != nil {
+		c.applyConfig(result.Data.ClientConfig)
+	}
+	return result.Data.URL, nil
+}
+
+// applyConfig updates connection settings; zero values keep the current ones
+func (c *Client) applyConfig(cfg *clientConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cfg.PingInterval > 0 {
+		c.pingInterval = time.Duration(cfg.PingInterval) * time.Second
+	}
+	if cfg.ReconnectCount != 0 {
+		c.reconnectCount = cfg.ReconnectCount
+	}
+	if cfg.ReconnectInterval > 0 {
+		c.reconnectInterval = time.Duration(cfg.ReconnectInterval) * time.Second
+	}
+	if cfg.ReconnectNonce > 0 {
+		c.reconnectNonce = time.Duration(cfg.ReconnectNonce) * time.Second
+	}
+}
+
+// serve reads frames until the connection fails or ctx is cancelled
+func (c *Client) serve(ctx context.Context) error {
+	conn, serviceID := c.conn, c.serviceID
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			conn.Close()
+		case <-done:
+		}
+	}()
+	go c.pingLoop(conn, serviceID, done)
+
+	for {
+		// A missed pong (or any traffic) for three ping intervals means the
+		// connection is dead even if the socket hasn't noticed yet
+		c.mu.Lock()
+		timeout := 3 * c.pingInterval
+		c.mu.Unlock()
+		conn.SetReadDeadline(time.Now().Add(timeout))
+
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+
+		var frame Frame
+		if err := frame.Unmarshal(data); err != nil {
+			c.logf("ignoring malformed frame: %v", err)
+			continue
+		}
+
+		switch frame.Method {
+		case methodControl:
+			c.handleControl(&frame)
+		case methodData:
+			if err := c.handleData(&frame); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// pingLoop sends a ping frame every ping interval
+func (c *Client) pingLoop(conn *websocket.Conn, serviceID int32, done <-chan struct{}) {
+	for {
+		c.mu.Lock()
+		interval := c.pingInterval
+		c.mu.Unlock()
+
+		select {
+		case <-done:
+			return
+		case <-time.After(interval):
+		}
+
+		ping := &Frame{
+			Method:  methodControl,
+			Service: serviceID,
+			Headers: []Header{{Key: "type", Value: "ping"}},
+		}
+		if err := c.write(conn, ping); err != nil {
+			return
+		}
+	}
+}
+
+func (c *Client) handleControl(frame *Frame) {
+	if frame.Header("type") != "pong" || len(frame.Payload) == 0 {
+		return
+	}
+	var cfg clientConfig
+	if err := json.Unmarshal(frame.Payload, &cfg); err == nil {
+		c.applyConfig(&cfg)
+	}
+}
+
+// handleData reassembles a data frame, dispatches the event and acknowledges it
+func (c *Client) handleData(frame *Frame) error {
+	if frame.Header("type") != "event" {
+		// Card callbacks and other frame types are acknowledged but unused
+		return c.ack(frame, http.StatusOK, time.Now())
+	}
+
+	payload := c.reassemble(frame)
+	if payload == nil {
+		return nil
+	}
+
+	start := time.Now()
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		c.logf("ignoring malformed event: %v", err)
+		return c.ack(frame, http.StatusInternalServerError, start)
+	}
+
+	status := http.StatusOK
+	if id := event.Header.EventID; id == "" || !c.seen[id] {
+		if err := c.handler(&event); err != nil {
+			c.logf("event %s failed: %v", event.Header.EventID, err)
+			status = http.StatusInternalServerError
+		} else if id != "" {
+			// Events are delivered at least once; drop redeliveries
+			if len(c.seen) >= 1000 {
+				c.seen = make(map[string]bool)
+			}
+			c.seen[id] = true
+		}
+	}
+	return c.ack(frame, status, start)
+}
+
+// reassemble collects the parts of a split payload and returns the whole
+// payload once every part has arrived, or nil while parts are missing
+func (c *Client) reassemble(frame *Frame) []byte {
+	sum, _ := strconv.Atoi(frame.Header("sum"))
+	if sum <= 1 {
+		return frame.Payload
+	}
+	seq, _ := strconv.Atoi(frame.Header("seq"))
+	id := frame.Header("message_id")
+	if seq < 0 || seq >= sum {
+		return nil
+	}
+
+	parts := c.fragments[id]
+	if len(parts) != sum {
+		parts = make([][]byte, sum)
+		c.fragments[id] = parts
+	}
+	parts[seq] = frame.Payload
+
+	var whole []byte
+	for _, p := range parts {
+		if p == nil {
+			return nil
+		}
+		whole = append(whole, p...)
+	}
+	delete(c.fragments, id)
+	return whole
+}
+
+// ack replies to a data frame with the handler's status
+func (c *Client) ack(frame *Frame, status int, start time.Time) error {
+	resp := *frame
+	resp.Headers = append([]Header(nil), frame.Headers...)
+	resp.SetHeader("biz_rt", strconv.FormatInt(time.Since(start).Milliseconds(), 10))
+	resp.Payload, _ = json.Marshal(map[string]interface{}{"code": status})
+	return c.write(c.conn, &resp)
+}
+
+func (c *Client) write(conn *websocket.Conn, frame *Frame) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return conn.WriteMessage(websocket.BinaryMessage, frame.Marshal())
+}
+
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.opts.Log != nil {
+		fmt.Fprintf(c.opts.Log, format+"\n", args...)
+	}
+}
+
+// serviceIDFromURL reads the service_id the server puts in the connection URL
+func serviceIDFromURL(wsURL string) int32 {
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return 0
+	}
+	id, _ := strconv.ParseInt(u.Query().Get("service_id"), 10, 32)
+	return int32(id)
+}
@@ -0,0 +1,195 @@
+package larkws
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Frame methods
+const (
+	methodControl int32 = 0
+	methodData    int32 = 1
+)
+
+// Header is a key/value pair carried by a frame
+type Header struct {
+	Key   string
+	Value string
+}
+
+// Frame is the protobuf envelope (pbbp2.Frame) used on the long connection:
+//
+//	message Frame {
+//	  uint64 SeqID = 1; uint64 LogID = 2; int32 service = 3; int32 method = 4;
+//	  repeated Header headers = 5; string payload_encoding = 6;
+//	  string payload_type = 7; bytes payload = 8; string LogIDNew = 9;
+//	}
+type Frame struct {
+	SeqID           uint64
+	LogID           uint64
+	Service         int32
+	Method          int32
+	Headers         []Header
+	PayloadEncoding string
+	PayloadType     string
+	Payload         []byte
+	LogIDNew        string
+}
+
+// Header returns the value of the named header, or ""
+func (f *Frame) Header(key string) string {
+	for _, h := range f.Headers {
+		if h.Key == key {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// SetHeader replaces or adds a header
+func (f *Frame) SetHeader(key, value string) {
+	for i := range f.Headers {
+		if f.Headers[i].Key == key {
+			f.Headers[i].Value = value
+			return
+		}
+	}
+	f.Headers = append(f.Headers, Header{Key: key, Value: value})
+}
+
+// Protobuf wire types
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// Marshal encodes the frame in protobuf wire format
+func (f *Frame) Marshal() []byte {
+	var b []byte
+	b = appendVarintField(b, 1, f.SeqID)
+	b = appendVarintField(b, 2, f.LogID)
+	b = appendVarintField(b, 3, uint64(int64(f.Service)))
+	b = appendVarintField(b, 4, uint64(int64(f.Method)))
+	for _, h := range f.Headers {
+		var hb []byte
+		hb = appendBytesField(hb, 1, []byte(h.Key))
+		hb = appendBytesField(hb, 2, []byte(h.Value))
+		b = appendBytesField(b, 5, hb)
+	}
+	if f.PayloadEncoding != "" {
+		b = appendBytesField(b, 6, []byte(f.PayloadEncoding))
+	}
+	if f.PayloadType != "" {
+		b = appendBytesField(b, 7, []byte(f.PayloadType))
+	}
+	if f.Payload != nil {
+		b = appendBytesField(b, 8, f.Payload)
+	}
+	if f.LogIDNew != "" {
+		b = appendBytesField(b, 9, []byte(f.LogIDNew))
+	}
+	return b
+}
+
+// Unmarshal decodes a protobuf-encoded frame, skipping unknown fields
+func (f *Frame) Unmarshal(data []byte) error {
+	*f = Frame{}
+	return eachField(data, func(num int, wire int, v uint64, bytes []byte) error {
+		switch num {
+		case 1:
+			f.SeqID = v
+		case 2:
+			f.LogID = v
+		case 3:
+			f.Service = int32(v)
+		case 4:
+			f.Method = int32(v)
+		case 5:
+			var h Header
+			err := eachField(bytes, func(num int, wire int, v uint64, bytes []byte) error {
+				switch num {
+				case 1:
+					h.Key = string(bytes)
+				case 2:
+					h.Value = string(bytes)
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("header: %w", err)
+			}
+			f.Headers = append(f.Headers, h)
+		case 6:
+			f.PayloadEncoding = string(bytes)
+		case 7:
+			f.PayloadType = string(bytes)
+		case 8:
+			f.Payload = append([]byte(nil), bytes...)
+		case 9:
+			f.LogIDNew = string(bytes)
+		}
+		return nil
+	})
+}
+
+var errTruncated = errors.New("truncated frame")
+
+// eachField walks the fields of a protobuf message. Varint fields are
+// passed in v and length-delimited fields in bytes.
+func eachField(data []byte, fn func(num int, wire int, v uint64, bytes []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errTruncated
+		}
+		data = data[n:]
+		num, wire := int(key>>3), int(key&7)
+
+		var v uint64
+		var bytes []byte
+		switch wire {
+		case wireVarint:
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errTruncated
+			}
+			bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case 1: // 64-bit
+			if len(data) < 8 {
+				return errTruncated
+			}
+			data = data[8:]
+		case 5: // 32-bit
+			if len(data) < 4 {
+				return errTruncated
+			}
+			data = data[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wire)
+		}
+
+		if err := fn(num, wire, v, bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendVarintField(b []byte, num int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3|wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func appendBytesField(b []byte, num int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,231 @@
+package mail
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+// Flags that Mark can set or clear
+const (
+	FlagSeen    = string(imap.FlagSeen)
+	FlagFlagged = string(imap.FlagFlagged)
+)
+
+// Selection is the messages an action applies to
+type Selection struct {
+	UIDs []uint32
+	// FromCache is set when the UIDs were picked by searching the cache.
+	// They are only trusted while the cache matches the mailbox's
+	// UIDVALIDITY, since UIDs may name other messages after it changes.
+	FromCache bool
+}
+
+// ActionResult describes the messages a mark, move or delete acted on
+type ActionResult struct {
+	Mailbox string   `json:"mailbox"`
+	UIDs    []uint32 `json:"uids"`
+	Count   int      `json:"count"`
+	// Missing lists requested UIDs that aren't in the mailbox, usually
+	// because they were moved or deleted since the last sync
+	Missing []uint32 `json:"missing,omitempty"`
+	// Flags holds each message's flags after a mark
+	Flags map[uint32][]string `json:"flags,omitempty"`
+	// To is the mailbox the messages were moved to, and NewUIDs their UIDs
+	// there if the server reports them
+	To       string            `json:"to,omitempty"`
+	NewUIDs  map[uint32]uint32 `json:"new_uids,omitempty"`
+	Expunged bool              `json:"expunged,omitempty"`
+	// Truncated is set by callers when the selection stopped at a limit
+	Truncated bool `json:"truncated,omitempty"`
+	// Warning notes that the cache didn't match the mailbox, so the UIDs
+	// may not be the messages the caller expected
+	Warning string `json:"warning,omitempty"`
+}
+
+// mailboxAction is the state an action works with: the selected mailbox,
+// the requested messages that are in it, and the cache if it holds this
+// mailbox's current UIDs
+type mailboxAction struct {
+	client *Client
+	cache  *Cache
+	mbox   *Mailbox
+	uids   []imap.UID
+	result *ActionResult
+}
+
+// runAction selects a mailbox, checks which of the selected UIDs are in it
+// and calls fn to act on them. The cache is nil in fn if it doesn't match
+// the mailbox, in which case the next sync rebuilds it; UIDs picked from
+// such a cache are refused.
+func runAction(mailbox string, sel Selection, fn func(a *mailboxAction) error) (*ActionResult, error) {
+	uids := sel.UIDs
+	if len(uids) == 0 {
+		return nil, fmt.Errorf("no messages given")
+	}
+
+	cache, err := OpenCache()
+	if err != nil {
+		return nil, err
+	}
+	defer cache.Close()
+
+	client, err := Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	mbox, err := client.SelectMailbox(mailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := cache.GetMailboxState(mailbox)
+	if err != nil {
+		return nil, err
+	}
+	a := &mailboxAction{client: client, cache: cache, mbox: mbox}
+	stale := state != nil && state.UIDValidity != mbox.UIDValidity
+	if state == nil || stale {
+		a.cache = nil
+	}
+	if sel.FromCache && a.cache == nil {
+		return nil, fmt.Errorf("the cache of %s is stale, run 'lark mail sync'", mailbox)
+	}
+
+	requested := make([]imap.UID, len(uids))
+	for i, uid := range uids {
+		requested[i] = imap.UID(uid)
+	}
+	if a.uids, err = client.ExistingUIDs(requested); err != nil {
+		return nil, err
+	}
+
+	a.result = &ActionResult{Mailbox: mailbox, UIDs: []uint32{}, Count: len(a.uids)}
+	if stale {
+		a.result.Warning = fmt.Sprintf("the UIDs of %s have changed since the last sync; if these UIDs came from 'lark mail search', run 'lark mail sync' and search again", mailbox)
+	}
+	found := make(map[uint32]bool, len(a.uids))
+	for _, uid := range a.uids {
+		found[uint32(uid)] = true
+		a.result.UIDs = append(a.result.UIDs, uint32(uid))
+	}
+	for _, uid := range uids {
+		if !found[uid] {
+			a.result.Missing = append(a.result.Missing, uid)
+		}
+	}
+
+	// Missing messages are gone from the mailbox, so they go from the
+	// cache too
+	if a.cache != nil {
+		if err := a.cache.DeleteEnvelopes(mailbox, a.result.Missing); err != nil {
+			return nil, err
+		}
+	}
+	if len(a.uids) == 0 {
+		return nil, fmt.Errorf("none of the messages are in %s", mailbox)
+	}
+
+	if err := fn(a); err != nil {
+		return nil, err
+	}
+	return a.result, nil
+}
+
+// Mark adds and removes flags, such as FlagSeen and FlagFlagged, on
+// messages and updates their cached flags
+func Mark(mailbox string, sel Selection, add, remove []string) (*ActionResult, error) {
+	return runAction(mailbox, sel, func(a *mailboxAction) error {
+		flags, err := a.client.StoreFlags(a.uids, imapFlags(add), imapFlags(remove))
+		if err != nil {
+			return err
+		}
+
+		a.result.Flags = make(map[uint32][]string, len(flags))
+		for uid, list := range flags {
+			a.result.Flags[uint32(uid)] = list
+		}
+		if a.cache != nil {
+			if _, err := a.cache.UpdateFlags(a.mbox.Name, a.result.Flags); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Move moves messages to another mailbox. Their cached envelopes move with
+// them if the server reports their new UIDs and the destination is cached,
+// and are dropped otherwise.
+func Move(mailbox string, sel Selection, dest string) (*ActionResult, error) {
+	if strings.EqualFold(mailbox, dest) {
+		return nil, fmt.Errorf("messages are already in %s", dest)
+	}
+	return runAction(mailbox, sel, func(a *mailboxAction) error {
+		return a.moveTo(dest)
+	})
+}
+
+// Delete moves messages to the Trash mailbox, or permanently deletes them
+// if expunge is set or they are already in the Trash
+func Delete(mailbox string, sel Selection, expunge bool) (*ActionResult, error) {
+	return runAction(mailbox, sel, func(a *mailboxAction) error {
+		if !expunge {
+			trash, err := a.client.FindSpecialMailbox(imap.MailboxAttrTrash)
+			if err != nil {
+				return err
+			}
+			if !strings.EqualFold(trash, mailbox) {
+				return a.moveTo(trash)
+			}
+		}
+
+		if err := a.client.ExpungeMessages(a.uids); err != nil {
+			return err
+		}
+		a.result.Expunged = true
+		if a.cache != nil {
+			return a.cache.DeleteEnvelopes(a.mbox.Name, a.result.UIDs)
+		}
+		return nil
+	})
+}
+
+func (a *mailboxAction) moveTo(dest string) error {
+	newUIDs, uidValidity, err := a.client.MoveMessages(a.uids, dest)
+	if err != nil {
+		return err
+	}
+	a.result.To = dest
+	if newUIDs != nil {
+		a.result.NewUIDs = make(map[uint32]uint32, len(newUIDs))
+		for uid, newUID := range newUIDs {
+			a.result.NewUIDs[uint32(uid)] = uint32(newUID)
+		}
+	}
+
+	if a.cache == nil {
+		return nil
+	}
+	if a.result.NewUIDs != nil {
+		destState, err := a.cache.GetMailboxState(dest)
+		if err != nil {
+			return err
+		}
+		if destState != nil && destState.UIDValidity == uidValidity {
+			return a.cache.MoveEnvelopes(a.mbox.Name, dest, a.result.NewUIDs)
+		}
+	}
+	return a.cache.DeleteEnvelopes(a.mbox.Name, a.result.UIDs)
+}
+
+func imapFlags(flags []string) []imap.Flag {
+	list := make([]imap.Flag, len(flags))
+	for i, f := range flags {
+		list[i] = imap.Flag(f)
+	}
+	return list
+}
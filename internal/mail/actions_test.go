@@ -0,0 +1,180 @@
+package mail
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+// setupActionTest starts a server with messages 1-4 in INBOX, all synced
+// to the cache along with the other mailboxes
+func setupActionTest(t *testing.T) *Cache {
+	t.Helper()
+
+	creds, user := newTestIMAPServer(t, "Archive", "Trash")
+	useTestConfig(t, creds)
+	for i := 1; i <= 4; i++ {
+		appendTestMessage(t, user, "INBOX", plainMessage(i, fmt.Sprintf("Message %d", i)))
+	}
+	appendTestMessage(t, user, "Trash", plainMessage(5, "Old"))
+	if _, err := SyncAll(nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := OpenCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func cachedUIDs(t *testing.T, cache *Cache, mailbox string) map[uint32]bool {
+	t.Helper()
+
+	uids, err := cache.GetCachedUIDs(mailbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uids
+}
+
+func TestMark(t *testing.T) {
+	cache := setupActionTest(t)
+
+	result, err := Mark("INBOX", Selection{UIDs: []uint32{1, 2, 9}}, []string{FlagSeen, FlagFlagged}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.UIDs, []uint32{1, 2}) || !reflect.DeepEqual(result.Missing, []uint32{9}) {
+		t.Errorf("result = %+v", result)
+	}
+	want := []string{`\Flagged`, `\Seen`}
+	if !reflect.DeepEqual(result.Flags[2], want) {
+		t.Errorf("flags = %v", result.Flags)
+	}
+
+	if _, err := Mark("INBOX", Selection{UIDs: []uint32{2}}, nil, []string{FlagSeen}); err != nil {
+		t.Fatal(err)
+	}
+	for uid, want := range map[uint32][]string{1: {`\Flagged`, `\Seen`}, 2: {`\Flagged`}, 3: {}} {
+		env, err := cache.GetEnvelope("INBOX", uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(env.Flags, want) {
+			t.Errorf("UID %d cached flags = %v, want %v", uid, env.Flags, want)
+		}
+	}
+
+	if _, err := Mark("INBOX", Selection{UIDs: []uint32{9}}, []string{FlagSeen}, nil); err == nil {
+		t.Error("expected an error when no message exists")
+	}
+}
+
+func TestMoveAndDelete(t *testing.T) {
+	cache := setupActionTest(t)
+
+	result, err := Move("INBOX", Selection{UIDs: []uint32{1, 2}}, "Archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.To != "Archive" || !reflect.DeepEqual(result.NewUIDs, map[uint32]uint32{1: 1, 2: 2}) {
+		t.Errorf("move result = %+v", result)
+	}
+	env, err := cache.GetEnvelope("Archive", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Subject != "Message 2" {
+		t.Errorf("Archive UID 2 is %q", env.Subject)
+	}
+
+	result, err = Delete("INBOX", Selection{UIDs: []uint32{3}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.To != "Trash" || result.Expunged {
+		t.Errorf("delete result = %+v", result)
+	}
+
+	if _, err := Delete("INBOX", Selection{UIDs: []uint32{4}}, true); err != nil {
+		t.Fatal(err)
+	}
+	// Deleting from the Trash purges
+	result, err = Delete("Trash", Selection{UIDs: []uint32{1}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Expunged {
+		t.Errorf("delete from Trash result = %+v", result)
+	}
+
+	for mailbox, want := range map[string]map[uint32]bool{
+		"INBOX":   {},
+		"Archive": {1: true, 2: true},
+		"Trash":   {2: true},
+	} {
+		if got := cachedUIDs(t, cache, mailbox); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s cached UIDs = %v, want %v", mailbox, got, want)
+		}
+	}
+
+	// The cache matches what a sync finds
+	sync, err := SyncAll(nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range sync.Mailboxes {
+		if r.Message != "already up to date" && r.Message != "mailbox is empty" {
+			t.Errorf("sync of %s: %s", r.Mailbox, r.Message)
+		}
+	}
+}
+
+func TestMoveWithoutDestinationCache(t *testing.T) {
+	creds, user := newTestIMAPServer(t, "Archive")
+	useTestConfig(t, creds)
+	appendTestMessage(t, user, "INBOX", plainMessage(1, "Message 1"), imap.FlagSeen)
+	if _, err := Sync("INBOX", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Move("INBOX", Selection{UIDs: []uint32{1}}, "Archive"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Sync("Archive", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NewMessages != 1 {
+		t.Errorf("sync result = %+v", result)
+	}
+}
+
+func TestActionOnStaleCache(t *testing.T) {
+	cache := setupActionTest(t)
+	state, err := cache.GetMailboxState("INBOX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.UpdateMailboxState("INBOX", state.UIDValidity+1, state.LastUID); err != nil {
+		t.Fatal(err)
+	}
+
+	// UIDs from a search of the stale cache are refused
+	if _, err := Mark("INBOX", Selection{UIDs: []uint32{1}, FromCache: true}, []string{FlagSeen}, nil); err == nil {
+		t.Error("expected an error for cached UIDs")
+	}
+
+	// UIDs given directly are used, with a warning
+	result, err := Mark("INBOX", Selection{UIDs: []uint32{1}}, []string{FlagSeen}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Warning == "" || !reflect.DeepEqual(result.UIDs, []uint32{1}) {
+		t.Errorf("result = %+v", result)
+	}
+}
@@ -3,8 +3,12 @@ package mail
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/yjwong/lark-cli/internal/config"
+	"github.com/yjwong/lark-cli/internal/fts"
 	_ "modernc.org/sqlite"
 )
 
@@ -15,6 +19,9 @@ type Cache struct {
 
 // OpenCache opens or creates the cache database
 func OpenCache() (*Cache, error) {
+	if err := config.EnsureProfileDir(); err != nil {
+		return nil, err
+	}
 	path := CacheFilePath()
 
 	db, err := sql.Open("sqlite", path)
@@ -69,6 +76,114 @@ func (c *Cache) init() error {
 		return fmt.Errorf("initializing cache schema: %w", err)
 	}
 
+	return c.migrate()
+}
+
+// migrations upgrade the cache schema one step at a time. The database's
+// user_version is the number of migrations applied, so existing caches
+// pick up only the steps they are missing. Add new steps at the end.
+var migrations = []string{
+	// 1: recipients, flags, size, attachment names and body text, with a
+	// full-text index over them. envelopes is rebuilt with an id column
+	// because the index refers to rows by id, and an implicit rowid can
+	// change on VACUUM. Copied rows have no size, which marks them for
+	// sync to fetch again.
+	`
+		CREATE TABLE envelopes_v1 (
+			id INTEGER PRIMARY KEY,
+			mailbox TEXT NOT NULL,
+			uid INTEGER NOT NULL,
+			message_id TEXT,
+			date INTEGER,
+			from_addr TEXT,
+			from_name TEXT,
+			subject TEXT,
+			to_addrs TEXT,
+			cc_addrs TEXT,
+			flags TEXT,
+			size INTEGER,
+			has_attachments INTEGER NOT NULL DEFAULT 0,
+			attachments TEXT,
+			body_text TEXT,
+			UNIQUE (mailbox, uid)
+		);
+
+		INSERT INTO envelopes_v1 (mailbox, uid, message_id, date, from_addr, from_name, subject)
+			SELECT mailbox, uid, message_id, date, from_addr, from_name, subject FROM envelopes;
+		DROP TABLE envelopes;
+		ALTER TABLE envelopes_v1 RENAME TO envelopes;
+
+		CREATE INDEX idx_envelopes_date ON envelopes(mailbox, date DESC);
+		CREATE INDEX idx_envelopes_from ON envelopes(mailbox, from_addr);
+		CREATE INDEX idx_envelopes_subject ON envelopes(mailbox, subject);
+
+		CREATE VIRTUAL TABLE envelopes_fts USING fts5(
+			subject, from_name, from_addr, to_addrs, cc_addrs, attachments, body_text,
+			content='envelopes', content_rowid='id'
+		);
+
+		CREATE TRIGGER envelopes_ai AFTER INSERT ON envelopes BEGIN
+			INSERT INTO envelopes_fts(rowid, subject, from_name, from_addr, to_addrs, cc_addrs, attachments, body_text)
+			VALUES (new.id, new.subject, new.from_name, new.from_addr, new.to_addrs, new.cc_addrs, new.attachments, new.body_text);
+		END;
+		CREATE TRIGGER envelopes_ad AFTER DELETE ON envelopes BEGIN
+			INSERT INTO envelopes_fts(envelopes_fts, rowid, subject, from_name, from_addr, to_addrs, cc_addrs, attachments, body_text)
+			VALUES ('delete', old.id, old.subject, old.from_name, old.from_addr, old.to_addrs, old.cc_addrs, old.attachments, old.body_text);
+		END;
+		CREATE TRIGGER envelopes_au AFTER UPDATE ON envelopes BEGIN
+			INSERT INTO envelopes_fts(envelopes_fts, rowid, subject, from_name, from_addr, to_addrs, cc_addrs, attachments, body_text)
+			VALUES ('delete', old.id, old.subject, old.from_name, old.from_addr, old.to_addrs, old.cc_addrs, old.attachments, old.body_text);
+			INSERT INTO envelopes_fts(rowid, subject, from_name, from_addr, to_addrs, cc_addrs, attachments, body_text)
+			VALUES (new.id, new.subject, new.from_name, new.from_addr, new.to_addrs, new.cc_addrs, new.attachments, new.body_text);
+		END;
+
+		INSERT INTO envelopes_fts(envelopes_fts) VALUES ('rebuild');
+	`,
+
+	// 2: the mailbox's HIGHESTMODSEQ and UIDNEXT as of its last complete
+	// sync, to find flag changes with CONDSTORE and skip unchanged
+	// mailboxes. The full-text index is only updated when an indexed
+	// column changes, so flag updates don't reindex bodies.
+	`
+		ALTER TABLE mailboxes ADD COLUMN highest_modseq INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE mailboxes ADD COLUMN uid_next INTEGER NOT NULL DEFAULT 0;
+
+		DROP TRIGGER envelopes_au;
+		CREATE TRIGGER envelopes_au
+		AFTER UPDATE OF subject, from_name, from_addr, to_addrs, cc_addrs, attachments, body_text ON envelopes BEGIN
+			INSERT INTO envelopes_fts(envelopes_fts, rowid, subject, from_name, from_addr, to_addrs, cc_addrs, attachments, body_text)
+			VALUES ('delete', old.id, old.subject, old.from_name, old.from_addr, old.to_addrs, old.cc_addrs, old.attachments, old.body_text);
+			INSERT INTO envelopes_fts(rowid, subject, from_name, from_addr, to_addrs, cc_addrs, attachments, body_text)
+			VALUES (new.id, new.subject, new.from_name, new.from_addr, new.to_addrs, new.cc_addrs, new.attachments, new.body_text);
+		END;
+	`,
+}
+
+// migrate applies the migrations the database doesn't have yet, each in
+// its own transaction
+func (c *Cache) migrate() error {
+	var version int
+	if err := c.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("reading cache schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := c.db.Begin()
+		if err != nil {
+			return fmt.Errorf("starting transaction: %w", err)
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrating cache schema to version %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrating cache schema to version %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migrating cache schema to version %d: %w", i+1, err)
+		}
+	}
 	return nil
 }
 
@@ -78,18 +193,23 @@ type MailboxState struct {
 	UIDValidity uint32
 	LastUID     uint32
 	LastSync    time.Time
+	// HighestModSeq and UIDNext are the server's values when the mailbox
+	// was last fully synced; HighestModSeq is 0 without CONDSTORE
+	HighestModSeq uint64
+	UIDNext       uint32
 }
 
 // GetMailboxState returns the cached state for a mailbox
 func (c *Cache) GetMailboxState(mailbox string) (*MailboxState, error) {
 	row := c.db.QueryRow(
-		`SELECT name, uidvalidity, last_uid, last_sync FROM mailboxes WHERE name = ?`,
+		`SELECT name, uidvalidity, last_uid, last_sync, highest_modseq, uid_next
+		 FROM mailboxes WHERE name = ?`,
 		mailbox,
 	)
 
 	var state MailboxState
 	var lastSyncUnix int64
-	err := row.Scan(&state.Name, &state.UIDValidity, &state.LastUID, &lastSyncUnix)
+	err := row.Scan(&state.Name, &state.UIDValidity, &state.LastUID, &lastSyncUnix, &state.HighestModSeq, &state.UIDNext)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -118,6 +238,19 @@ func (c *Cache) UpdateMailboxState(mailbox string, uidValidity, lastUID uint32)
 	return nil
 }
 
+// SetSyncPoint records the server's HIGHESTMODSEQ and UIDNEXT after a
+// complete sync of a mailbox
+func (c *Cache) SetSyncPoint(mailbox string, highestModSeq uint64, uidNext uint32) error {
+	_, err := c.db.Exec(
+		`UPDATE mailboxes SET highest_modseq = ?, uid_next = ?, last_sync = ? WHERE name = ?`,
+		highestModSeq, uidNext, time.Now().Unix(), mailbox,
+	)
+	if err != nil {
+		return fmt.Errorf("updating mailbox state: %w", err)
+	}
+	return nil
+}
+
 // CountEnvelopes returns the number of cached envelopes for a mailbox
 func (c *Cache) CountEnvelopes(mailbox string) (int, error) {
 	var count int
@@ -128,9 +261,11 @@ func (c *Cache) CountEnvelopes(mailbox string) (int, error) {
 	return count, nil
 }
 
-// GetCachedUIDs returns all cached UIDs for a mailbox as a set
+// GetCachedUIDs returns all cached UIDs for a mailbox. The value is false
+// for envelopes cached before the schema had all of an Envelope's fields,
+// which need fetching again.
 func (c *Cache) GetCachedUIDs(mailbox string) (map[uint32]bool, error) {
-	rows, err := c.db.Query(`SELECT uid FROM envelopes WHERE mailbox = ?`, mailbox)
+	rows, err := c.db.Query(`SELECT uid, size IS NOT NULL FROM envelopes WHERE mailbox = ?`, mailbox)
 	if err != nil {
 		return nil, fmt.Errorf("querying cached UIDs: %w", err)
 	}
@@ -139,10 +274,11 @@ func (c *Cache) GetCachedUIDs(mailbox string) (map[uint32]bool, error) {
 	uids := make(map[uint32]bool)
 	for rows.Next() {
 		var uid uint32
-		if err := rows.Scan(&uid); err != nil {
+		var complete bool
+		if err := rows.Scan(&uid, &complete); err != nil {
 			return nil, fmt.Errorf("scanning UID: %w", err)
 		}
-		uids[uid] = true
+		uids[uid] = complete
 	}
 	return uids, nil
 }
@@ -168,15 +304,22 @@ func (c *Cache) ClearMailbox(mailbox string) error {
 
 // CachedEnvelope represents a cached email envelope
 type CachedEnvelope struct {
-	UID       uint32
-	MessageID string
-	Date      time.Time
-	FromAddr  string
-	FromName  string
-	Subject   string
+	UID            uint32    `json:"uid"`
+	MessageID      string    `json:"message_id"`
+	Date           time.Time `json:"date"`
+	FromAddr       string    `json:"from_addr"`
+	FromName       string    `json:"from_name"`
+	Subject        string    `json:"subject"`
+	To             []Address `json:"to,omitempty"`
+	Cc             []Address `json:"cc,omitempty"`
+	Flags          []string  `json:"flags,omitempty"`
+	Size           int64     `json:"size,omitempty"`
+	HasAttachments bool      `json:"has_attachments,omitempty"`
+	Attachments    []string  `json:"attachments,omitempty"`
 }
 
-// InsertEnvelopes adds envelopes to the cache
+// InsertEnvelopes adds envelopes to the cache, updating any already cached.
+// Indexed body text is kept.
 func (c *Cache) InsertEnvelopes(mailbox string, envelopes []Envelope) error {
 	if len(envelopes) == 0 {
 		return nil
@@ -189,8 +332,21 @@ func (c *Cache) InsertEnvelopes(mailbox string, envelopes []Envelope) error {
 	defer tx.Rollback()
 
 	stmt, err := tx.Prepare(
-		`INSERT OR REPLACE INTO envelopes (mailbox, uid, message_id, date, from_addr, from_name, subject)
-		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO envelopes (mailbox, uid, message_id, date, from_addr, from_name, subject,
+			to_addrs, cc_addrs, flags, size, has_attachments, attachments)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(mailbox, uid) DO UPDATE SET
+			message_id = excluded.message_id,
+			date = excluded.date,
+			from_addr = excluded.from_addr,
+			from_name = excluded.from_name,
+			subject = excluded.subject,
+			to_addrs = excluded.to_addrs,
+			cc_addrs = excluded.cc_addrs,
+			flags = excluded.flags,
+			size = excluded.size,
+			has_attachments = excluded.has_attachments,
+			attachments = excluded.attachments`,
 	)
 	if err != nil {
 		return fmt.Errorf("preparing insert: %w", err)
@@ -198,7 +354,9 @@ func (c *Cache) InsertEnvelopes(mailbox string, envelopes []Envelope) error {
 	defer stmt.Close()
 
 	for _, env := range envelopes {
-		_, err := stmt.Exec(mailbox, uint32(env.UID), env.MessageID, env.Date, env.FromAddr, env.FromName, env.Subject)
+		_, err := stmt.Exec(mailbox, uint32(env.UID), env.MessageID, env.Date, env.FromAddr, env.FromName, env.Subject,
+			joinAddresses(env.To), joinAddresses(env.Cc), joinFlags(env.Flags), env.Size,
+			env.HasAttachments, strings.Join(env.Attachments, "\n"))
 		if err != nil {
 			return fmt.Errorf("inserting envelope: %w", err)
 		}
@@ -207,27 +365,210 @@ func (c *Cache) InsertEnvelopes(mailbox string, envelopes []Envelope) error {
 	return tx.Commit()
 }
 
+// DeleteEnvelopes removes envelopes of messages that are no longer in a
+// mailbox
+func (c *Cache) DeleteEnvelopes(mailbox string, uids []uint32) error {
+	if len(uids) == 0 {
+		return nil
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`DELETE FROM envelopes WHERE mailbox = ? AND uid = ?`)
+	if err != nil {
+		return fmt.Errorf("preparing delete: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, uid := range uids {
+		if _, err := stmt.Exec(mailbox, uid); err != nil {
+			return fmt.Errorf("deleting envelope: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MoveEnvelopes moves cached envelopes to another mailbox under their new
+// UIDs there, keeping their indexed bodies
+func (c *Cache) MoveEnvelopes(mailbox, dest string, newUIDs map[uint32]uint32) error {
+	if len(newUIDs) == 0 {
+		return nil
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Drop anything already cached under a new UID, so the move can't
+	// collide with it
+	del, err := tx.Prepare(`DELETE FROM envelopes WHERE mailbox = ? AND uid = ?`)
+	if err != nil {
+		return fmt.Errorf("preparing delete: %w", err)
+	}
+	defer del.Close()
+
+	stmt, err := tx.Prepare(`UPDATE envelopes SET mailbox = ?, uid = ? WHERE mailbox = ? AND uid = ?`)
+	if err != nil {
+		return fmt.Errorf("preparing update: %w", err)
+	}
+	defer stmt.Close()
+
+	for uid, newUID := range newUIDs {
+		if _, err := del.Exec(dest, newUID); err != nil {
+			return fmt.Errorf("deleting envelope: %w", err)
+		}
+		if _, err := stmt.Exec(dest, newUID, mailbox, uid); err != nil {
+			return fmt.Errorf("moving envelope: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateFlags stores the flags of cached envelopes, by UID, and returns
+// how many changed. UIDs that aren't cached are ignored.
+func (c *Cache) UpdateFlags(mailbox string, flags map[uint32][]string) (int, error) {
+	if len(flags) == 0 {
+		return 0, nil
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		`UPDATE envelopes SET flags = ? WHERE mailbox = ? AND uid = ? AND flags IS NOT ?`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("preparing update: %w", err)
+	}
+	defer stmt.Close()
+
+	var changed int
+	for uid, list := range flags {
+		joined := joinFlags(list)
+		res, err := stmt.Exec(joined, mailbox, uid, joined)
+		if err != nil {
+			return 0, fmt.Errorf("updating flags: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		changed += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+// PendingBodies returns the UIDs of cached envelopes whose body text hasn't
+// been indexed, skipping messages over maxSize bytes (0 for no limit). It
+// also returns how many were skipped.
+func (c *Cache) PendingBodies(mailbox string, maxSize int64) ([]uint32, int, error) {
+	rows, err := c.db.Query(
+		`SELECT uid, ? > 0 AND size > ? FROM envelopes
+		 WHERE mailbox = ? AND body_text IS NULL AND size IS NOT NULL
+		 ORDER BY uid`,
+		maxSize, maxSize, mailbox,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("querying unindexed bodies: %w", err)
+	}
+	defer rows.Close()
+
+	var uids []uint32
+	var skipped int
+	for rows.Next() {
+		var uid uint32
+		var tooLarge bool
+		if err := rows.Scan(&uid, &tooLarge); err != nil {
+			return nil, 0, fmt.Errorf("scanning UID: %w", err)
+		}
+		if tooLarge {
+			skipped++
+		} else {
+			uids = append(uids, uid)
+		}
+	}
+	return uids, skipped, rows.Err()
+}
+
+// SetBodies stores the body text of cached envelopes, by UID
+func (c *Cache) SetBodies(mailbox string, bodies map[uint32]string) error {
+	if len(bodies) == 0 {
+		return nil
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`UPDATE envelopes SET body_text = ? WHERE mailbox = ? AND uid = ?`)
+	if err != nil {
+		return fmt.Errorf("preparing update: %w", err)
+	}
+	defer stmt.Close()
+
+	for uid, text := range bodies {
+		if _, err := stmt.Exec(text, mailbox, uid); err != nil {
+			return fmt.Errorf("storing body text: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
 // SearchOptions specifies search filters
 type SearchOptions struct {
-	From    string
-	Subject string
-	Since   *time.Time
-	Before  *time.Time
-	Limit   int
+	Text          string // full-text query over subject, addresses, attachment names and bodies
+	From          string
+	To            string // matches To or Cc
+	Subject       string
+	Since         *time.Time
+	Before        *time.Time
+	HasAttachment bool
+	Limit         int
 }
 
 // SearchResult contains search results with cache metadata
 type SearchResult struct {
-	Mailbox     string           `json:"mailbox"`
-	LastSync    time.Time        `json:"last_sync"`
-	Freshness   string           `json:"freshness"`
-	TotalCached int              `json:"total_cached"`
-	Results     []CachedEnvelope `json:"results"`
-	Count       int              `json:"count"`
+	Mailbox       string      `json:"mailbox"`
+	LastSync      time.Time   `json:"last_sync"`
+	Freshness     string      `json:"freshness"`
+	TotalCached   int         `json:"total_cached"`
+	BodiesIndexed int         `json:"bodies_indexed"`
+	Results       []SearchHit `json:"results"`
+	Count         int         `json:"count"`
 }
 
-// Search queries the cache for matching envelopes
+// SearchHit is a matching envelope with the matched text highlighted
+type SearchHit struct {
+	CachedEnvelope
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// envelopeColumns are the envelopes columns scanEnvelope reads, in order
+const envelopeColumns = `e.uid, e.message_id, e.date, e.from_addr, e.from_name, e.subject,
+	e.to_addrs, e.cc_addrs, e.flags, e.size, e.has_attachments, e.attachments`
+
+// Search queries the cache for matching envelopes. Results are ordered by
+// relevance when there is a text query, newest first otherwise.
 func (c *Cache) Search(mailbox string, opts *SearchOptions) (*SearchResult, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+
 	// Get mailbox state for freshness info
 	state, err := c.GetMailboxState(mailbox)
 	if err != nil {
@@ -236,7 +577,7 @@ func (c *Cache) Search(mailbox string, opts *SearchOptions) (*SearchResult, erro
 
 	result := &SearchResult{
 		Mailbox: mailbox,
-		Results: []CachedEnvelope{},
+		Results: []SearchHit{},
 	}
 
 	if state != nil {
@@ -247,37 +588,59 @@ func (c *Cache) Search(mailbox string, opts *SearchOptions) (*SearchResult, erro
 	}
 
 	// Count total cached
-	row := c.db.QueryRow(`SELECT COUNT(*) FROM envelopes WHERE mailbox = ?`, mailbox)
-	row.Scan(&result.TotalCached)
-
-	// Build query
-	query := `SELECT uid, message_id, date, from_addr, from_name, subject
-			  FROM envelopes WHERE mailbox = ?`
-	args := []any{mailbox}
-
-	if opts != nil {
-		if opts.From != "" {
-			query += ` AND from_addr LIKE ?`
-			args = append(args, "%"+opts.From+"%")
-		}
-		if opts.Subject != "" {
-			query += ` AND subject LIKE ?`
-			args = append(args, "%"+opts.Subject+"%")
-		}
-		if opts.Since != nil {
-			query += ` AND date >= ?`
-			args = append(args, opts.Since.Unix())
-		}
-		if opts.Before != nil {
-			query += ` AND date < ?`
-			args = append(args, opts.Before.Unix())
-		}
+	row := c.db.QueryRow(
+		`SELECT COUNT(*), COUNT(body_text) FROM envelopes WHERE mailbox = ?`,
+		mailbox,
+	)
+	row.Scan(&result.TotalCached, &result.BodiesIndexed)
+
+	// Build query. The subject and sender count for more than the body
+	// when ranking.
+	query := `SELECT ` + envelopeColumns
+	var args []any
+	match := fts.Query(opts.Text)
+	if match != "" {
+		query += `, snippet(envelopes_fts, -1, '[', ']', '…', 16)
+			FROM envelopes_fts JOIN envelopes e ON e.id = envelopes_fts.rowid
+			WHERE envelopes_fts MATCH ? AND e.mailbox = ?`
+		args = append(args, match, mailbox)
+	} else {
+		query += `, '' FROM envelopes e WHERE e.mailbox = ?`
+		args = append(args, mailbox)
 	}
 
-	query += ` ORDER BY date DESC`
+	if opts.From != "" {
+		query += ` AND e.from_addr LIKE ?`
+		args = append(args, "%"+opts.From+"%")
+	}
+	if opts.To != "" {
+		query += ` AND (e.to_addrs LIKE ? OR e.cc_addrs LIKE ?)`
+		args = append(args, "%"+opts.To+"%", "%"+opts.To+"%")
+	}
+	if opts.Subject != "" {
+		query += ` AND e.subject LIKE ?`
+		args = append(args, "%"+opts.Subject+"%")
+	}
+	if opts.Since != nil {
+		query += ` AND e.date >= ?`
+		args = append(args, opts.Since.Unix())
+	}
+	if opts.Before != nil {
+		query += ` AND e.date < ?`
+		args = append(args, opts.Before.Unix())
+	}
+	if opts.HasAttachment {
+		query += ` AND e.has_attachments = 1`
+	}
+
+	if match != "" {
+		query += ` ORDER BY bm25(envelopes_fts, 4.0, 2.0, 2.0, 1.0, 1.0, 2.0, 1.0), e.date DESC`
+	} else {
+		query += ` ORDER BY e.date DESC`
+	}
 
 	limit := 50
-	if opts != nil && opts.Limit > 0 {
+	if opts.Limit > 0 {
 		limit = opts.Limit
 	}
 	query += fmt.Sprintf(` LIMIT %d`, limit)
@@ -289,22 +652,14 @@ func (c *Cache) Search(mailbox string, opts *SearchOptions) (*SearchResult, erro
 	defer rows.Close()
 
 	for rows.Next() {
-		var env CachedEnvelope
-		var dateUnix int64
-		var messageID, fromAddr, fromName, subject sql.NullString
-
-		err := rows.Scan(&env.UID, &messageID, &dateUnix, &fromAddr, &fromName, &subject)
-		if err != nil {
-			return nil, fmt.Errorf("scanning row: %w", err)
+		var hit SearchHit
+		if err := scanEnvelope(rows, &hit.CachedEnvelope, &hit.Snippet); err != nil {
+			return nil, err
 		}
-
-		env.MessageID = messageID.String
-		env.Date = time.Unix(dateUnix, 0)
-		env.FromAddr = fromAddr.String
-		env.FromName = fromName.String
-		env.Subject = subject.String
-
-		result.Results = append(result.Results, env)
+		result.Results = append(result.Results, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("searching cache: %w", err)
 	}
 
 	result.Count = len(result.Results)
@@ -314,21 +669,35 @@ func (c *Cache) Search(mailbox string, opts *SearchOptions) (*SearchResult, erro
 // GetEnvelope retrieves a single envelope by UID
 func (c *Cache) GetEnvelope(mailbox string, uid uint32) (*CachedEnvelope, error) {
 	row := c.db.QueryRow(
-		`SELECT uid, message_id, date, from_addr, from_name, subject
-		 FROM envelopes WHERE mailbox = ? AND uid = ?`,
+		`SELECT `+envelopeColumns+` FROM envelopes e WHERE e.mailbox = ? AND e.uid = ?`,
 		mailbox, uid,
 	)
 
 	var env CachedEnvelope
-	var dateUnix int64
-	var messageID, fromAddr, fromName, subject sql.NullString
-
-	err := row.Scan(&env.UID, &messageID, &dateUnix, &fromAddr, &fromName, &subject)
+	err := scanEnvelope(row, &env)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("querying envelope: %w", err)
+		return nil, err
+	}
+	return &env, nil
+}
+
+// scanEnvelope scans envelopeColumns, followed by any extra columns, into
+// env
+func scanEnvelope(row interface{ Scan(...any) error }, env *CachedEnvelope, extra ...any) error {
+	var dateUnix int64
+	var size sql.NullInt64
+	var messageID, fromAddr, fromName, subject, to, cc, flags, attachments sql.NullString
+
+	dest := append([]any{&env.UID, &messageID, &dateUnix, &fromAddr, &fromName, &subject,
+		&to, &cc, &flags, &size, &env.HasAttachments, &attachments}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		if err == sql.ErrNoRows {
+			return err
+		}
+		return fmt.Errorf("scanning row: %w", err)
 	}
 
 	env.MessageID = messageID.String
@@ -336,8 +705,48 @@ func (c *Cache) GetEnvelope(mailbox string, uid uint32) (*CachedEnvelope, error)
 	env.FromAddr = fromAddr.String
 	env.FromName = fromName.String
 	env.Subject = subject.String
+	env.To = splitAddresses(to.String)
+	env.Cc = splitAddresses(cc.String)
+	env.Flags = strings.Fields(flags.String)
+	env.Size = size.Int64
+	if attachments.String != "" {
+		env.Attachments = strings.Split(attachments.String, "\n")
+	}
+	return nil
+}
 
-	return &env, nil
+// joinFlags stores flags space-separated, sorted so the same flags always
+// compare equal
+func joinFlags(flags []string) string {
+	sorted := append([]string{}, flags...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, " ")
+}
+
+// joinAddresses stores addresses one per line as "Name <email>", which
+// reads well in search snippets
+func joinAddresses(addrs []Address) string {
+	lines := make([]string, len(addrs))
+	for i, a := range addrs {
+		lines[i] = formatAddress(a)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitAddresses parses addresses stored by joinAddresses
+func splitAddresses(s string) []Address {
+	if s == "" {
+		return nil
+	}
+	var addrs []Address
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.LastIndex(line, " <"); i >= 0 && strings.HasSuffix(line, ">") {
+			addrs = append(addrs, Address{Name: line[:i], Email: line[i+2 : len(line)-1]})
+		} else {
+			addrs = append(addrs, Address{Email: line})
+		}
+	}
+	return addrs
 }
 
 func formatFreshness(t time.Time) string {
@@ -0,0 +1,174 @@
+package mail
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+func TestCacheMigratesOldSchema(t *testing.T) {
+	useTestConfig(t, nil)
+
+	// A cache written before the schema was versioned
+	db, err := sql.Open("sqlite", CacheFilePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE mailboxes (name TEXT PRIMARY KEY, uidvalidity INTEGER NOT NULL,
+			last_uid INTEGER NOT NULL DEFAULT 0, last_sync INTEGER NOT NULL DEFAULT 0);
+		CREATE TABLE envelopes (mailbox TEXT NOT NULL, uid INTEGER NOT NULL, message_id TEXT,
+			date INTEGER, from_addr TEXT, from_name TEXT, subject TEXT, PRIMARY KEY (mailbox, uid));
+		INSERT INTO mailboxes VALUES ('INBOX', 7, 2, 0);
+		INSERT INTO envelopes VALUES ('INBOX', 1, 'a@example.com', 100, 'alice@example.com', 'Alice', 'Budget forecast');
+		INSERT INTO envelopes VALUES ('INBOX', 2, 'b@example.com', 200, 'bob@example.com', 'Bob', 'Lunch');
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 { // the second open finds nothing to migrate
+		cache, err := OpenCache()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		uids, err := cache.GetCachedUIDs("INBOX")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(uids, map[uint32]bool{1: false, 2: false}) {
+			t.Errorf("cached UIDs = %v", uids)
+		}
+
+		result, err := cache.Search("INBOX", &SearchOptions{Text: "budget"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Count != 1 || result.Results[0].Subject != "Budget forecast" || result.Results[0].Snippet != "[Budget] forecast" {
+			t.Errorf("search results = %+v", result.Results)
+		}
+
+		var version int
+		cache.db.QueryRow(`PRAGMA user_version`).Scan(&version)
+		if version != len(migrations) {
+			t.Errorf("user_version = %d", version)
+		}
+		cache.Close()
+	}
+}
+
+func TestSyncIndexesBodies(t *testing.T) {
+	creds, user := newTestIMAPServer(t)
+	useTestConfig(t, creds)
+
+	appendTestMessage(t, user, "INBOX", multipartMessage, imap.FlagSeen)
+	appendTestMessage(t, user, "INBOX", `From: Bob Lim <bob@example.com>
+To: me@example.com
+Subject: Lunch on Friday?
+Date: Tue, 20 Oct 2026 12:00:00 +0800
+Message-ID: <lunch@example.com>
+
+The new cafe near the office does a good laksa.
+`)
+	appendTestMessage(t, user, "INBOX", `From: Carol <carol@example.com>
+To: Dan <dan@example.com>
+Cc: me@example.com
+Subject: Cafe invoice
+Date: Wed, 21 Oct 2026 08:00:00 +0800
+Message-ID: <invoice@example.com>
+
+`+strings.Repeat("Line items for the cafe order.\n", 100))
+
+	result, err := Sync("INBOX", &SyncOptions{Bodies: true, MaxBodySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SyncResult{
+		Mailbox:       "INBOX",
+		NewMessages:   3,
+		TotalCached:   3,
+		BodiesIndexed: 2,
+		BodiesSkipped: 1,
+		Message:       "synced 3 new messages, indexed 2 bodies",
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("sync result = %+v", result)
+	}
+
+	cache, err := OpenCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	env, err := cache.GetEnvelope("INBOX", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTo := []Address{{Email: "me@example.com"}, {Name: "Bob Lim", Email: "bob@example.com"}}
+	if !reflect.DeepEqual(env.To, wantTo) || len(env.Cc) != 1 || env.Cc[0].Name != "Ché Wong" {
+		t.Errorf("to = %+v, cc = %+v", env.To, env.Cc)
+	}
+	if !reflect.DeepEqual(env.Flags, []string{`\Seen`}) || env.Size != int64(len(multipartMessage)) {
+		t.Errorf("flags = %v, size = %d", env.Flags, env.Size)
+	}
+	if !env.HasAttachments || !reflect.DeepEqual(env.Attachments, []string{"Q4 résumé.pdf"}) {
+		t.Errorf("attachments = %v (%v)", env.Attachments, env.HasAttachments)
+	}
+
+	search := func(opts *SearchOptions) []SearchHit {
+		t.Helper()
+		result, err := cache.Search("INBOX", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.TotalCached != 3 || result.BodiesIndexed != 2 {
+			t.Errorf("total_cached = %d, bodies_indexed = %d", result.TotalCached, result.BodiesIndexed)
+		}
+		return result.Results
+	}
+
+	// The subject match ranks first; the skipped body isn't indexed but its
+	// subject is
+	hits := search(&SearchOptions{Text: "cafe"})
+	if len(hits) != 3 || hits[0].UID != 3 || hits[0].Snippet != "[Cafe] invoice" {
+		t.Fatalf("cafe hits = %+v", hits)
+	}
+	if hits[2].UID != 1 || !strings.Contains(hits[2].Snippet, "The [café] numbers are in") {
+		t.Errorf("cafe hit 3 = %+v", hits[2])
+	}
+
+	if hits := search(&SearchOptions{Text: "laks*"}); len(hits) != 1 || hits[0].UID != 2 {
+		t.Errorf("laks* hits = %+v", hits)
+	}
+	if hits := search(&SearchOptions{Text: "résumé.pdf"}); len(hits) != 1 || hits[0].UID != 1 {
+		t.Errorf("attachment name hits = %+v", hits)
+	}
+	if hits := search(&SearchOptions{HasAttachment: true}); len(hits) != 1 || hits[0].UID != 1 {
+		t.Errorf("has-attachment hits = %+v", hits)
+	}
+	if hits := search(&SearchOptions{To: "dan@"}); len(hits) != 1 || hits[0].UID != 3 {
+		t.Errorf("to hits = %+v", hits)
+	}
+	if hits := search(&SearchOptions{Text: "cafe", To: "me@example.com"}); len(hits) != 3 {
+		t.Errorf("to me hits = %+v", hits)
+	}
+	// Blank text is no text filter rather than an empty FTS5 MATCH
+	if hits := search(&SearchOptions{Text: " \t"}); len(hits) != 3 || hits[0].Snippet != "" {
+		t.Errorf("blank text hits = %+v", hits)
+	}
+
+	// Nothing left to index, except the message over the cap
+	result, err = Sync("INBOX", &SyncOptions{Bodies: true, MaxBodySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NewMessages != 0 || result.BodiesIndexed != 0 || result.BodiesSkipped != 1 {
+		t.Errorf("second sync = %+v", result)
+	}
+}
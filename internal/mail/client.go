@@ -3,6 +3,9 @@ package mail
 import (
 	"crypto/tls"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/emersion/go-imap/v2"
 	"github.com/emersion/go-imap/v2/imapclient"
@@ -67,6 +70,18 @@ type Mailbox struct {
 	Name        string
 	NumMessages uint32
 	UIDValidity uint32
+	UIDNext     uint32
+	// HighestModSeq is only set if the server supports CONDSTORE
+	HighestModSeq uint64
+}
+
+// MailboxStatus holds a mailbox's message counts
+type MailboxStatus struct {
+	Name     string `json:"name"`
+	Messages uint32 `json:"messages"`
+	Unread   uint32 `json:"unread"`
+	// NoSelect mailboxes only hold other mailboxes, and have no counts
+	NoSelect bool `json:"noselect,omitempty"`
 }
 
 // ListMailboxes returns all mailboxes
@@ -83,17 +98,96 @@ func (c *Client) ListMailboxes() ([]string, error) {
 	return names, nil
 }
 
-// SelectMailbox selects a mailbox and returns its metadata
+// SelectableMailboxes returns the mailboxes that can hold messages
+func (c *Client) SelectableMailboxes() ([]string, error) {
+	mailboxes, err := c.imap.List("", "*", nil).Collect()
+	if err != nil {
+		return nil, fmt.Errorf("listing mailboxes: %w", err)
+	}
+
+	var names []string
+	for _, mbox := range mailboxes {
+		if !isNoSelect(mbox) {
+			names = append(names, mbox.Mailbox)
+		}
+	}
+	return names, nil
+}
+
+// MailboxStatuses returns every mailbox with its message and unread
+// counts, in one LIST command if the server supports LIST-STATUS and with a
+// STATUS command per mailbox otherwise
+func (c *Client) MailboxStatuses() ([]MailboxStatus, error) {
+	statusOptions := &imap.StatusOptions{NumMessages: true, NumUnseen: true}
+
+	var listOptions *imap.ListOptions
+	listStatus := c.imap.Caps().Has(imap.CapListStatus)
+	if listStatus {
+		listOptions = &imap.ListOptions{ReturnStatus: statusOptions}
+	}
+	mailboxes, err := c.imap.List("", "*", listOptions).Collect()
+	if err != nil {
+		return nil, fmt.Errorf("listing mailboxes: %w", err)
+	}
+
+	statuses := make([]MailboxStatus, len(mailboxes))
+	for i, mbox := range mailboxes {
+		statuses[i] = MailboxStatus{Name: mbox.Mailbox, NoSelect: isNoSelect(mbox)}
+		if statuses[i].NoSelect {
+			continue
+		}
+
+		data := mbox.Status
+		if !listStatus {
+			if data, err = c.imap.Status(mbox.Mailbox, statusOptions).Wait(); err != nil {
+				return nil, fmt.Errorf("getting status of %s: %w", mbox.Mailbox, err)
+			}
+		}
+		if data != nil && data.NumMessages != nil {
+			statuses[i].Messages = *data.NumMessages
+		}
+		if data != nil && data.NumUnseen != nil {
+			statuses[i].Unread = *data.NumUnseen
+		}
+	}
+	return statuses, nil
+}
+
+func isNoSelect(mbox *imap.ListData) bool {
+	for _, attr := range mbox.Attrs {
+		if strings.EqualFold(string(attr), string(imap.MailboxAttrNoSelect)) ||
+			strings.EqualFold(string(attr), string(imap.MailboxAttrNonExistent)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CondStore reports whether the server supports CONDSTORE, which lets
+// FetchFlags return only the flags that changed
+func (c *Client) CondStore() bool {
+	return c.imap.Caps().Has(imap.CapCondStore)
+}
+
+// SelectMailbox selects a mailbox and returns its metadata, enabling
+// CONDSTORE if the server supports it
 func (c *Client) SelectMailbox(name string) (*Mailbox, error) {
-	mbox, err := c.imap.Select(name, nil).Wait()
+	var options *imap.SelectOptions
+	if c.CondStore() {
+		options = &imap.SelectOptions{CondStore: true}
+	}
+
+	mbox, err := c.imap.Select(name, options).Wait()
 	if err != nil {
 		return nil, fmt.Errorf("selecting mailbox %s: %w", name, err)
 	}
 
 	return &Mailbox{
-		Name:        name,
-		NumMessages: mbox.NumMessages,
-		UIDValidity: mbox.UIDValidity,
+		Name:          name,
+		NumMessages:   mbox.NumMessages,
+		UIDValidity:   mbox.UIDValidity,
+		UIDNext:       uint32(mbox.UIDNext),
+		HighestModSeq: mbox.HighestModSeq,
 	}, nil
 }
 
@@ -105,49 +199,110 @@ type Envelope struct {
 	FromAddr  string
 	FromName  string
 	Subject   string
+	To        []Address
+	Cc        []Address
+	Flags     []string
+	Size      int64 // RFC822 size in bytes
+	// Attachments are the filenames of the parts that aren't body text or
+	// inline images, from the body structure
+	Attachments    []string
+	HasAttachments bool
 }
 
-// FetchEnvelopes fetches envelope data for a range of sequence numbers
-func (c *Client) FetchEnvelopes(start, end uint32) ([]Envelope, error) {
-	var seqSet imap.SeqSet
-	seqSet.AddRange(start, end)
+// envelopeFetchOptions fetches everything an Envelope holds
+var envelopeFetchOptions = &imap.FetchOptions{
+	Envelope:      true,
+	UID:           true,
+	Flags:         true,
+	RFC822Size:    true,
+	BodyStructure: &imap.FetchItemBodyStructure{Extended: true},
+}
 
-	fetchOptions := &imap.FetchOptions{
-		Envelope: true,
-		UID:      true,
+// newEnvelope converts fetched message data to an Envelope, or returns nil
+// if there is no envelope
+func newEnvelope(msg *imapclient.FetchMessageBuffer) *Envelope {
+	env := msg.Envelope
+	if env == nil {
+		return nil
 	}
 
-	messages, err := c.imap.Fetch(seqSet, fetchOptions).Collect()
-	if err != nil {
-		return nil, fmt.Errorf("fetching envelopes: %w", err)
+	e := &Envelope{
+		UID:       msg.UID,
+		MessageID: env.MessageID,
+		Subject:   env.Subject,
+		To:        imapAddresses(env.To),
+		Cc:        imapAddresses(env.Cc),
+		Size:      msg.RFC822Size,
+	}
+	if !env.Date.IsZero() {
+		e.Date = env.Date.Unix()
+	}
+	if len(env.From) > 0 {
+		e.FromAddr = env.From[0].Addr()
+		e.FromName = env.From[0].Name
+	}
+	for _, f := range msg.Flags {
+		e.Flags = append(e.Flags, string(f))
 	}
 
-	envelopes := make([]Envelope, 0, len(messages))
-	for _, msg := range messages {
-		env := msg.Envelope
-		if env == nil {
-			continue
-		}
+	if msg.BodyStructure != nil {
+		msg.BodyStructure.Walk(func(path []int, part imap.BodyStructure) bool {
+			single, ok := part.(*imap.BodyStructureSinglePart)
+			if !ok || !isAttachmentPart(single) {
+				return true
+			}
+			e.HasAttachments = true
+			if name := single.Filename(); name != "" {
+				if decoded, err := wordDecoder.DecodeHeader(name); err == nil {
+					name = decoded
+				}
+				e.Attachments = append(e.Attachments, name)
+			}
+			return true
+		})
+	}
+	return e
+}
 
-		e := Envelope{
-			UID:       msg.UID,
-			MessageID: env.MessageID,
-			Subject:   env.Subject,
-		}
+// isAttachmentPart reports whether a body structure part is an attachment,
+// as ParseMessage would list it, that isn't shown inline
+func isAttachmentPart(part *imap.BodyStructureSinglePart) bool {
+	disp := part.Disposition()
+	if disp != nil && strings.EqualFold(disp.Value, "inline") {
+		return false
+	}
+	if disp == nil && part.ID != "" {
+		return false
+	}
+	if (disp != nil && strings.EqualFold(disp.Value, "attachment")) || part.Filename() != "" {
+		return true
+	}
+	t := part.MediaType()
+	return t != "text/plain" && t != "text/html"
+}
 
-		if !env.Date.IsZero() {
-			e.Date = env.Date.Unix()
+func imapAddresses(list []imap.Address) []Address {
+	var addrs []Address
+	for _, a := range list {
+		if a.IsGroupStart() || a.IsGroupEnd() {
+			continue
 		}
+		addrs = append(addrs, Address{Name: a.Name, Email: a.Addr()})
+	}
+	return addrs
+}
 
-		if len(env.From) > 0 {
-			e.FromAddr = env.From[0].Addr()
-			e.FromName = env.From[0].Name
-		}
+// FetchEnvelopes fetches envelope data for a range of sequence numbers
+func (c *Client) FetchEnvelopes(start, end uint32) ([]Envelope, error) {
+	var seqSet imap.SeqSet
+	seqSet.AddRange(start, end)
 
-		envelopes = append(envelopes, e)
+	messages, err := c.imap.Fetch(seqSet, envelopeFetchOptions).Collect()
+	if err != nil {
+		return nil, fmt.Errorf("fetching envelopes: %w", err)
 	}
 
-	return envelopes, nil
+	return collectEnvelopes(messages), nil
 }
 
 // FetchEnvelopesByUID fetches envelope data for specific UIDs
@@ -158,42 +313,22 @@ func (c *Client) FetchEnvelopesByUID(uids []imap.UID) ([]Envelope, error) {
 
 	uidSet := imap.UIDSetNum(uids...)
 
-	fetchOptions := &imap.FetchOptions{
-		Envelope: true,
-		UID:      true,
-	}
-
-	messages, err := c.imap.Fetch(uidSet, fetchOptions).Collect()
+	messages, err := c.imap.Fetch(uidSet, envelopeFetchOptions).Collect()
 	if err != nil {
 		return nil, fmt.Errorf("fetching envelopes by UID: %w", err)
 	}
 
+	return collectEnvelopes(messages), nil
+}
+
+func collectEnvelopes(messages []*imapclient.FetchMessageBuffer) []Envelope {
 	envelopes := make([]Envelope, 0, len(messages))
 	for _, msg := range messages {
-		env := msg.Envelope
-		if env == nil {
-			continue
-		}
-
-		e := Envelope{
-			UID:       msg.UID,
-			MessageID: env.MessageID,
-			Subject:   env.Subject,
-		}
-
-		if !env.Date.IsZero() {
-			e.Date = env.Date.Unix()
+		if e := newEnvelope(msg); e != nil {
+			envelopes = append(envelopes, *e)
 		}
-
-		if len(env.From) > 0 {
-			e.FromAddr = env.From[0].Addr()
-			e.FromName = env.From[0].Name
-		}
-
-		envelopes = append(envelopes, e)
 	}
-
-	return envelopes, nil
+	return envelopes
 }
 
 // GetAllUIDs returns all message UIDs in the selected mailbox
@@ -238,6 +373,40 @@ func (c *Client) FetchNewEnvelopes(lastUID imap.UID) ([]Envelope, error) {
 	return c.FetchEnvelopesByUID(searchData.AllUIDs())
 }
 
+// FetchFlags returns the flags of every message in the selected mailbox.
+// If changedSince is set (requires CONDSTORE), only messages whose flags
+// changed after that mod-sequence are returned.
+func (c *Client) FetchFlags(changedSince uint64) (map[imap.UID][]string, error) {
+	var uidSet imap.UIDSet
+	uidSet.AddRange(1, 0) // 1 to * (all messages)
+
+	return c.fetchFlags(uidSet, changedSince)
+}
+
+func (c *Client) fetchFlags(uidSet imap.UIDSet, changedSince uint64) (map[imap.UID][]string, error) {
+	fetchOptions := &imap.FetchOptions{
+		UID:          true,
+		Flags:        true,
+		ChangedSince: changedSince,
+	}
+
+	messages, err := c.imap.Fetch(uidSet, fetchOptions).Collect()
+	if err != nil {
+		return nil, fmt.Errorf("fetching flags: %w", err)
+	}
+
+	flags := make(map[imap.UID][]string, len(messages))
+	for _, msg := range messages {
+		list := make([]string, 0, len(msg.Flags))
+		for _, f := range msg.Flags {
+			list = append(list, string(f))
+		}
+		sort.Strings(list)
+		flags[msg.UID] = list
+	}
+	return flags, nil
+}
+
 // FetchMessage fetches the full RFC822 message for a UID
 func (c *Client) FetchMessage(uid imap.UID) ([]byte, *Envelope, error) {
 	uidSet := imap.UIDSetNum(uid)
@@ -265,24 +434,182 @@ func (c *Client) FetchMessage(uid imap.UID) ([]byte, *Envelope, error) {
 		break
 	}
 
-	var envelope *Envelope
-	if msg.Envelope != nil {
-		env := msg.Envelope
-		envelope = &Envelope{
-			UID:       msg.UID,
-			MessageID: env.MessageID,
-			Subject:   env.Subject,
+	return body, newEnvelope(msg), nil
+}
+
+// FetchBodies fetches the full RFC822 messages for UIDs without marking
+// them as read
+func (c *Client) FetchBodies(uids []imap.UID) (map[imap.UID][]byte, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	fetchOptions := &imap.FetchOptions{
+		UID:         true,
+		BodySection: []*imap.FetchItemBodySection{{Peek: true}},
+	}
+
+	messages, err := c.imap.Fetch(imap.UIDSetNum(uids...), fetchOptions).Collect()
+	if err != nil {
+		return nil, fmt.Errorf("fetching messages: %w", err)
+	}
+
+	bodies := make(map[imap.UID][]byte, len(messages))
+	for _, msg := range messages {
+		for _, data := range msg.BodySection {
+			bodies[msg.UID] = data
+			break
+		}
+	}
+	return bodies, nil
+}
+
+// specialMailboxNames are the usual names of special-use mailboxes, for
+// servers that don't mark them with attributes
+var specialMailboxNames = map[imap.MailboxAttr][]string{
+	imap.MailboxAttrSent:    {"Sent", "Sent Messages", "Sent Items", "Sent Mail"},
+	imap.MailboxAttrTrash:   {"Trash", "Deleted Messages", "Deleted Items"},
+	imap.MailboxAttrArchive: {"Archive", "Archives"},
+}
+
+// FindSpecialMailbox returns the mailbox with a special-use attribute such
+// as \Sent, falling back to a mailbox with one of the usual names
+func (c *Client) FindSpecialMailbox(attr imap.MailboxAttr) (string, error) {
+	var options *imap.ListOptions
+	if c.imap.Caps().Has(imap.CapSpecialUse) {
+		options = &imap.ListOptions{ReturnSpecialUse: true}
+	}
+	mailboxes, err := c.imap.List("", "*", options).Collect()
+	if err != nil {
+		return "", fmt.Errorf("listing mailboxes: %w", err)
+	}
+
+	for _, mbox := range mailboxes {
+		for _, a := range mbox.Attrs {
+			if strings.EqualFold(string(a), string(attr)) {
+				return mbox.Mailbox, nil
+			}
+		}
+	}
+	for _, name := range specialMailboxNames[attr] {
+		for _, mbox := range mailboxes {
+			if strings.EqualFold(mbox.Mailbox, name) {
+				return mbox.Mailbox, nil
+			}
 		}
-		if !env.Date.IsZero() {
-			envelope.Date = env.Date.Unix()
+	}
+	return "", fmt.Errorf("no %s mailbox found", strings.TrimPrefix(string(attr), "\\"))
+}
+
+// SaveSent appends a sent message to the Sent mailbox, marked as read, and
+// returns the mailbox name
+func (c *Client) SaveSent(msg []byte) (string, error) {
+	mailbox, err := c.FindSpecialMailbox(imap.MailboxAttrSent)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := c.imap.Append(mailbox, int64(len(msg)), &imap.AppendOptions{
+		Flags: []imap.Flag{imap.FlagSeen},
+		Time:  time.Now(),
+	})
+	if _, err := cmd.Write(msg); err != nil {
+		cmd.Close()
+		return "", fmt.Errorf("appending to %s: %w", mailbox, err)
+	}
+	if err := cmd.Close(); err != nil {
+		return "", fmt.Errorf("appending to %s: %w", mailbox, err)
+	}
+	if _, err := cmd.Wait(); err != nil {
+		return "", fmt.Errorf("appending to %s: %w", mailbox, err)
+	}
+	return mailbox, nil
+}
+
+// ExistingUIDs returns which of the given UIDs are in the selected mailbox
+func (c *Client) ExistingUIDs(uids []imap.UID) ([]imap.UID, error) {
+	criteria := &imap.SearchCriteria{
+		UID: []imap.UIDSet{imap.UIDSetNum(uids...)},
+	}
+
+	searchData, err := c.imap.UIDSearch(criteria, nil).Wait()
+	if err != nil {
+		return nil, fmt.Errorf("searching for UIDs: %w", err)
+	}
+	return searchData.AllUIDs(), nil
+}
+
+// StoreFlags adds and removes flags on messages in the selected mailbox,
+// and returns their flags afterwards
+func (c *Client) StoreFlags(uids []imap.UID, add, remove []imap.Flag) (map[imap.UID][]string, error) {
+	uidSet := imap.UIDSetNum(uids...)
+
+	for _, store := range []*imap.StoreFlags{
+		{Op: imap.StoreFlagsAdd, Flags: add, Silent: true},
+		{Op: imap.StoreFlagsDel, Flags: remove, Silent: true},
+	} {
+		if len(store.Flags) == 0 {
+			continue
 		}
-		if len(env.From) > 0 {
-			envelope.FromAddr = env.From[0].Addr()
-			envelope.FromName = env.From[0].Name
+		if err := c.imap.Store(uidSet, store, nil).Close(); err != nil {
+			return nil, fmt.Errorf("storing flags: %w", err)
 		}
 	}
 
-	return body, envelope, nil
+	return c.fetchFlags(uidSet, 0)
+}
+
+// MoveMessages moves messages from the selected mailbox to another one. If
+// the server reports the UIDs they were given there (UIDPLUS), they are
+// returned by old UID, along with the destination's UIDVALIDITY. Without
+// MOVE, it copies and expunges the messages, which needs UIDPLUS for the
+// same reason as ExpungeMessages.
+func (c *Client) MoveMessages(uids []imap.UID, dest string) (map[imap.UID]imap.UID, uint32, error) {
+	caps := c.imap.Caps()
+	if !caps.Has(imap.CapMove) && !caps.Has(imap.CapUIDPlus) {
+		return nil, 0, fmt.Errorf("the server supports neither MOVE nor UIDPLUS, so messages can't be moved individually")
+	}
+
+	data, err := c.imap.Move(imap.UIDSetNum(uids...), dest).Wait()
+	if err != nil {
+		return nil, 0, fmt.Errorf("moving to %s: %w", dest, err)
+	}
+
+	srcSet, ok1 := data.SourceUIDs.(imap.UIDSet)
+	destSet, ok2 := data.DestUIDs.(imap.UIDSet)
+	if !ok1 || !ok2 {
+		return nil, 0, nil
+	}
+	src, ok1 := srcSet.Nums()
+	dst, ok2 := destSet.Nums()
+	if !ok1 || !ok2 || len(src) != len(dst) {
+		return nil, 0, nil
+	}
+
+	newUIDs := make(map[imap.UID]imap.UID, len(src))
+	for i, uid := range src {
+		newUIDs[uid] = dst[i]
+	}
+	return newUIDs, data.UIDValidity, nil
+}
+
+// ExpungeMessages permanently deletes messages from the selected mailbox.
+// It needs UIDPLUS, as a plain EXPUNGE would also remove any other messages
+// marked \Deleted.
+func (c *Client) ExpungeMessages(uids []imap.UID) error {
+	if !c.imap.Caps().Has(imap.CapUIDPlus) {
+		return fmt.Errorf("the server doesn't support UIDPLUS, so messages can't be expunged individually")
+	}
+
+	uidSet := imap.UIDSetNum(uids...)
+	store := &imap.StoreFlags{Op: imap.StoreFlagsAdd, Flags: []imap.Flag{imap.FlagDeleted}, Silent: true}
+	if err := c.imap.Store(uidSet, store, nil).Close(); err != nil {
+		return fmt.Errorf("marking deleted: %w", err)
+	}
+	if err := c.imap.UIDExpunge(uidSet).Close(); err != nil {
+		return fmt.Errorf("expunging: %w", err)
+	}
+	return nil
 }
 
 // TestConnection attempts to connect and list mailboxes
@@ -0,0 +1,292 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	netmail "net/mail"
+	"strings"
+	"time"
+
+	msgmail "github.com/emersion/go-message/mail"
+)
+
+// Draft is an email to send
+type Draft struct {
+	From        Address
+	To          []Address
+	Cc          []Address
+	Bcc         []Address // recipients left out of the headers
+	Subject     string
+	Body        string // plain text
+	InReplyTo   string
+	References  []string
+	Attachments []DraftAttachment
+}
+
+// DraftAttachment is a file attached to a draft
+type DraftAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Recipients returns the addresses the draft is delivered to: To, Cc and
+// Bcc
+func (d *Draft) Recipients() []string {
+	var rcpts []string
+	for _, list := range [][]Address{d.To, d.Cc, d.Bcc} {
+		for _, a := range list {
+			rcpts = append(rcpts, a.Email)
+		}
+	}
+	return rcpts
+}
+
+// Build renders the draft as an RFC822 message with a new Message-ID, which
+// it returns without angle brackets
+func (d *Draft) Build() (string, []byte, error) {
+	var h msgmail.Header
+	h.SetDate(time.Now())
+	h.SetAddressList("From", toMailAddresses([]Address{d.From}))
+	h.SetAddressList("To", toMailAddresses(d.To))
+	if len(d.Cc) > 0 {
+		h.SetAddressList("Cc", toMailAddresses(d.Cc))
+	}
+	h.SetSubject(d.Subject)
+	if d.InReplyTo != "" {
+		h.SetMsgIDList("In-Reply-To", []string{d.InReplyTo})
+	}
+	if len(d.References) > 0 {
+		h.SetMsgIDList("References", d.References)
+	}
+	domain := "localhost"
+	if _, after, ok := strings.Cut(d.From.Email, "@"); ok {
+		domain = after
+	}
+	if err := h.GenerateMessageIDWithHostname(domain); err != nil {
+		return "", nil, fmt.Errorf("generating Message-ID: %w", err)
+	}
+	messageID, _ := h.MessageID()
+
+	var buf bytes.Buffer
+	var textHeader msgmail.InlineHeader
+	textHeader.SetContentType("text/plain", map[string]string{"charset": "utf-8"})
+
+	if len(d.Attachments) == 0 {
+		h.SetContentType("text/plain", map[string]string{"charset": "utf-8"})
+		w, err := msgmail.CreateSingleInlineWriter(&buf, h)
+		if err != nil {
+			return "", nil, fmt.Errorf("building message: %w", err)
+		}
+		if err := writePart(w, []byte(d.Body)); err != nil {
+			return "", nil, err
+		}
+		return messageID, buf.Bytes(), nil
+	}
+
+	mw, err := msgmail.CreateWriter(&buf, h)
+	if err != nil {
+		return "", nil, fmt.Errorf("building message: %w", err)
+	}
+	w, err := mw.CreateSingleInline(textHeader)
+	if err != nil {
+		return "", nil, fmt.Errorf("building message: %w", err)
+	}
+	if err := writePart(w, []byte(d.Body)); err != nil {
+		return "", nil, err
+	}
+	for _, a := range d.Attachments {
+		var ah msgmail.AttachmentHeader
+		ah.SetContentType(a.ContentType, nil)
+		ah.SetFilename(a.Filename)
+		w, err := mw.CreateAttachment(ah)
+		if err != nil {
+			return "", nil, fmt.Errorf("attaching %s: %w", a.Filename, err)
+		}
+		if err := writePart(w, a.Data); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", nil, fmt.Errorf("building message: %w", err)
+	}
+	return messageID, buf.Bytes(), nil
+}
+
+func writePart(w io.WriteCloser, data []byte) error {
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("building message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("building message: %w", err)
+	}
+	return nil
+}
+
+// NewReply starts a reply to orig from self. It goes to the Reply-To or
+// From addresses; with all, the other To and Cc recipients are copied in.
+// body is quoted above the original message.
+func NewReply(orig *Message, self Address, all bool, body string) *Draft {
+	d := &Draft{
+		From:       self,
+		Subject:    prefixSubject("Re: ", orig.Subject, "re:"),
+		InReplyTo:  orig.MessageID,
+		References: threadReferences(orig),
+	}
+
+	d.To = orig.ReplyTo
+	if len(d.To) == 0 {
+		d.To = orig.From
+	}
+	if all {
+		seen := map[string]bool{strings.ToLower(self.Email): true}
+		for _, a := range d.To {
+			seen[strings.ToLower(a.Email)] = true
+		}
+		for _, a := range append(append([]Address{}, orig.To...), orig.Cc...) {
+			if !seen[strings.ToLower(a.Email)] {
+				seen[strings.ToLower(a.Email)] = true
+				d.Cc = append(d.Cc, a)
+			}
+		}
+	}
+
+	var b strings.Builder
+	if body != "" {
+		b.WriteString(strings.TrimRight(body, "\n"))
+		b.WriteString("\n\n")
+	}
+	sender := "someone"
+	if len(orig.From) > 0 {
+		sender = formatAddress(orig.From[0])
+	}
+	if orig.Date.IsZero() {
+		fmt.Fprintf(&b, "%s wrote:\n", sender)
+	} else {
+		fmt.Fprintf(&b, "On %s, %s wrote:\n", orig.Date.Format("Mon, 2 Jan 2006 at 15:04"), sender)
+	}
+	for _, line := range strings.Split(orig.Body, "\n") {
+		if line == "" || strings.HasPrefix(line, ">") {
+			b.WriteString(">" + line + "\n")
+		} else {
+			b.WriteString("> " + line + "\n")
+		}
+	}
+	d.Body = b.String()
+	return d
+}
+
+// NewForward starts a forward of orig from self, with body above the
+// forwarded message and the original's attachments attached. raw is the
+// original message the attachments are read from. A forward isn't a reply,
+// so only References links it to the original.
+func NewForward(orig *Message, raw []byte, self Address, body string) (*Draft, error) {
+	d := &Draft{
+		From:       self,
+		Subject:    prefixSubject("Fwd: ", orig.Subject, "fwd:", "fw:"),
+		References: threadReferences(orig),
+	}
+
+	var b strings.Builder
+	if body != "" {
+		b.WriteString(strings.TrimRight(body, "\n"))
+		b.WriteString("\n\n")
+	}
+	b.WriteString("---------- Forwarded message ---------\n")
+	fmt.Fprintf(&b, "From: %s\n", formatAddresses(orig.From))
+	if !orig.Date.IsZero() {
+		fmt.Fprintf(&b, "Date: %s\n", orig.Date.Format("Mon, 2 Jan 2006 at 15:04"))
+	}
+	fmt.Fprintf(&b, "Subject: %s\n", orig.Subject)
+	fmt.Fprintf(&b, "To: %s\n", formatAddresses(orig.To))
+	if len(orig.Cc) > 0 {
+		fmt.Fprintf(&b, "Cc: %s\n", formatAddresses(orig.Cc))
+	}
+	b.WriteString("\n")
+	b.WriteString(orig.Body)
+	b.WriteString("\n")
+	d.Body = b.String()
+
+	for _, a := range orig.Attachments {
+		att, data, err := ExtractAttachment(raw, a.Index)
+		if err != nil {
+			return nil, err
+		}
+		name := att.Filename
+		if name == "" {
+			name = fmt.Sprintf("attachment-%d", att.Index)
+		}
+		d.Attachments = append(d.Attachments, DraftAttachment{
+			Filename:    name,
+			ContentType: att.ContentType,
+			Data:        data,
+		})
+	}
+	return d, nil
+}
+
+// ParseAddresses parses addresses like "alice@example.com" or
+// "Alice Tan <alice@example.com>"
+func ParseAddresses(list []string) ([]Address, error) {
+	var addrs []Address
+	for _, s := range list {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		a, err := netmail.ParseAddress(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", s, err)
+		}
+		addrs = append(addrs, Address{Name: a.Name, Email: a.Address})
+	}
+	return addrs, nil
+}
+
+// threadReferences returns the References of a reply to orig: the
+// original's references followed by its Message-ID
+func threadReferences(orig *Message) []string {
+	refs := append([]string{}, orig.References...)
+	if len(refs) == 0 && len(orig.InReplyTo) > 0 {
+		refs = append(refs, orig.InReplyTo...)
+	}
+	if orig.MessageID != "" {
+		refs = append(refs, orig.MessageID)
+	}
+	return refs
+}
+
+// prefixSubject adds prefix to a subject unless it already starts with one
+// of the given prefixes, compared case-insensitively
+func prefixSubject(prefix, subject string, existing ...string) string {
+	lower := strings.ToLower(subject)
+	for _, p := range existing {
+		if strings.HasPrefix(lower, p) {
+			return subject
+		}
+	}
+	return prefix + subject
+}
+
+func formatAddress(a Address) string {
+	if a.Name == "" {
+		return a.Email
+	}
+	return a.Name + " <" + a.Email + ">"
+}
+
+func formatAddresses(addrs []Address) string {
+	parts := make([]string, len(addrs))
+	for i, a := range addrs {
+		parts[i] = formatAddress(a)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func toMailAddresses(addrs []Address) []*msgmail.Address {
+	out := make([]*msgmail.Address, len(addrs))
+	for i, a := range addrs {
+		out[i] = &msgmail.Address{Name: a.Name, Address: a.Email}
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package mail
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewReply(t *testing.T) {
+	orig, err := ParseMessage([]byte(multipartMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	self := Address{Email: "me@example.com"}
+	d := NewReply(orig, self, true, "Thanks!")
+	d.Cc = append(d.Cc, Address{Email: "dan@example.com"})
+
+	messageID, raw, err := d.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(messageID, "@example.com") {
+		t.Errorf("messageID = %q", messageID)
+	}
+
+	m, err := ParseMessage(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MessageID != messageID {
+		t.Errorf("MessageID = %q, want %q", m.MessageID, messageID)
+	}
+	if m.Subject != "Re: Q4 report – final" {
+		t.Errorf("Subject = %q", m.Subject)
+	}
+	if !reflect.DeepEqual(m.To, []Address{{Email: "team@example.com"}}) {
+		t.Errorf("To = %+v", m.To)
+	}
+	wantCc := []Address{{Name: "Bob Lim", Email: "bob@example.com"}, {Name: "Ché Wong", Email: "che@example.com"}, {Email: "dan@example.com"}}
+	if !reflect.DeepEqual(m.Cc, wantCc) {
+		t.Errorf("Cc = %+v", m.Cc)
+	}
+	if !reflect.DeepEqual(m.InReplyTo, []string{"report-2@example.com"}) {
+		t.Errorf("InReplyTo = %v", m.InReplyTo)
+	}
+	wantRefs := []string{"report-0@example.com", "report-1@example.com", "report-2@example.com"}
+	if !reflect.DeepEqual(m.References, wantRefs) {
+		t.Errorf("References = %v", m.References)
+	}
+	wantBody := "Thanks!\n\nOn Mon, 19 Oct 2026 at 09:30, Alice Tan <alice@example.com> wrote:\n> Hi team,\n>\n> The café numbers"
+	if !strings.HasPrefix(m.Body, wantBody) {
+		t.Errorf("Body = %q", m.Body)
+	}
+	if len(m.Attachments) != 0 {
+		t.Errorf("Attachments = %+v", m.Attachments)
+	}
+
+	if got := NewReply(m, self, false, "").Subject; got != m.Subject {
+		t.Errorf("reply to a reply has subject %q", got)
+	}
+}
+
+func TestNewForward(t *testing.T) {
+	orig, err := ParseMessage([]byte(multipartMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := NewForward(orig, []byte(multipartMessage), Address{Email: "me@example.com"}, "FYI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.To = []Address{{Email: "dan@example.com"}}
+	d.Bcc = []Address{{Email: "eve@example.com"}}
+	if got := d.Recipients(); !reflect.DeepEqual(got, []string{"dan@example.com", "eve@example.com"}) {
+		t.Errorf("Recipients = %v", got)
+	}
+
+	_, raw, err := d.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "eve@example.com") {
+		t.Error("Bcc address is in the message")
+	}
+
+	m, err := ParseMessage(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Subject != "Fwd: Q4 report – final" {
+		t.Errorf("Subject = %q", m.Subject)
+	}
+	if len(m.InReplyTo) != 0 {
+		t.Errorf("InReplyTo = %v", m.InReplyTo)
+	}
+	if len(m.References) == 0 || m.References[len(m.References)-1] != orig.MessageID {
+		t.Errorf("References = %v", m.References)
+	}
+	if !strings.HasPrefix(m.Body, "FYI\n\n---------- Forwarded message ---------\nFrom: Alice Tan <alice@example.com>\n") {
+		t.Errorf("Body = %q", m.Body)
+	}
+	wantAttachments := []Attachment{
+		{Index: 1, Filename: "attachment-1", ContentType: "image/png", Size: 8},
+		{Index: 2, Filename: "Q4 résumé.pdf", ContentType: "application/pdf", Size: 19},
+	}
+	if !reflect.DeepEqual(m.Attachments, wantAttachments) {
+		t.Errorf("Attachments = %+v", m.Attachments)
+	}
+}
+
+func TestParseAddresses(t *testing.T) {
+	got, err := ParseAddresses([]string{"alice@example.com", "Bob Lim <bob@example.com>", " "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Address{{Email: "alice@example.com"}, {Name: "Bob Lim", Email: "bob@example.com"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+
+	if _, err := ParseAddresses([]string{"not an address"}); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
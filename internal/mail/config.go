@@ -3,41 +3,68 @@ package mail
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/yjwong/lark-cli/internal/config"
+	"github.com/yjwong/lark-cli/internal/secrets"
 )
 
-// Credentials holds IMAP connection settings
+// Credentials holds IMAP and SMTP connection settings. SMTP logs in with
+// the same username and password as IMAP.
 type Credentials struct {
 	Host     string `json:"host"`
 	Port     int    `json:"port"`
 	Username string `json:"username"`
 	Password string `json:"password"`
 	UseSSL   bool   `json:"use_ssl"`
+
+	SMTPHost     string `json:"smtp_host,omitempty"`
+	SMTPPort     int    `json:"smtp_port,omitempty"`
+	SMTPSecurity string `json:"smtp_security,omitempty"` // ssl, starttls or none
 }
 
-// CredentialsFilePath returns the path to the mail credentials file
-func CredentialsFilePath() string {
-	return filepath.Join(config.GetConfigDir(), "mail.json")
+// SMTP connection security
+const (
+	SMTPSecuritySSL      = "ssl"      // TLS from the start, usually port 465
+	SMTPSecuritySTARTTLS = "starttls" // upgraded with STARTTLS, usually port 587
+	SMTPSecurityNone     = "none"
+)
+
+// SMTPServer returns the SMTP host, port and security, defaulting anything
+// unset: the host is the IMAP host with "imap." replaced by "smtp.", over SSL
+// on port 465. Credentials saved before SMTP support get these defaults.
+func (c *Credentials) SMTPServer() (host string, port int, security string) {
+	host, port, security = c.SMTPHost, c.SMTPPort, c.SMTPSecurity
+	if host == "" {
+		host = "smtp." + strings.TrimPrefix(c.Host, "imap.")
+	}
+	if security == "" {
+		security = SMTPSecuritySSL
+	}
+	if port == 0 {
+		port = 465
+		if security != SMTPSecuritySSL {
+			port = 587
+		}
+	}
+	return host, port, security
 }
 
 // CacheFilePath returns the path to the mail cache database
 func CacheFilePath() string {
-	return filepath.Join(config.GetConfigDir(), "mail_cache.db")
+	return filepath.Join(config.GetProfileDir(), "mail_cache.db")
 }
 
-// LoadCredentials reads IMAP credentials from disk
+// LoadCredentials reads mail credentials from the secret store
 func LoadCredentials() (*Credentials, error) {
-	path := CredentialsFilePath()
-	data, err := os.ReadFile(path)
+	data, err := secrets.Load(secrets.KeyMail)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("mail not configured; run 'lark mail setup' first")
-		}
 		return nil, fmt.Errorf("failed to read mail credentials: %w", err)
 	}
+	if data == nil {
+		return nil, fmt.Errorf("mail not configured; run 'lark mail setup' first")
+	}
 
 	var creds Credentials
 	if err := json.Unmarshal(data, &creds); err != nil {
@@ -47,15 +74,14 @@ func LoadCredentials() (*Credentials, error) {
 	return &creds, nil
 }
 
-// SaveCredentials writes IMAP credentials to disk
+// SaveCredentials writes mail credentials to the secret store
 func SaveCredentials(creds *Credentials) error {
 	data, err := json.MarshalIndent(creds, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal credentials: %w", err)
 	}
 
-	path := CredentialsFilePath()
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	if err := secrets.Save(secrets.KeyMail, data); err != nil {
 		return fmt.Errorf("failed to write credentials: %w", err)
 	}
 
@@ -64,8 +90,7 @@ func SaveCredentials(creds *Credentials) error {
 
 // ClearCredentials removes stored credentials
 func ClearCredentials() error {
-	path := CredentialsFilePath()
-	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+	if err := secrets.Remove(secrets.KeyMail); err != nil {
 		return fmt.Errorf("failed to remove credentials: %w", err)
 	}
 	return nil
@@ -73,7 +98,6 @@ func ClearCredentials() error {
 
 // HasCredentials checks if credentials are configured
 func HasCredentials() bool {
-	path := CredentialsFilePath()
-	_, err := os.Stat(path)
-	return err == nil
+	data, err := secrets.Load(secrets.KeyMail)
+	return err == nil && data != nil
 }
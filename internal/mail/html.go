@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// htmlHidden matches markup whose content isn't shown
+	htmlHidden = regexp.MustCompile(`(?is)<!--.*?-->|<head\b.*?</head\s*>|<script\b.*?</script\s*>|<style\b.*?</style\s*>`)
+	htmlSpace  = regexp.MustCompile(`\s+`)
+	htmlLink   = regexp.MustCompile(`(?is)<a\b[^>]*?\bhref\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a\s*>`)
+	htmlBreak  = regexp.MustCompile(`(?i)<br\b[^>]*>`)
+	htmlRule   = regexp.MustCompile(`(?i)<hr\b[^>]*>`)
+	htmlItem   = regexp.MustCompile(`(?i)<li\b[^>]*>`)
+	htmlCell   = regexp.MustCompile(`(?i)</t[dh]\s*>`)
+	htmlBlock  = regexp.MustCompile(`(?i)</?(p|div|tr|table|ul|ol|h[1-6]|blockquote|section|article|header|footer)\b[^>]*>`)
+	htmlTag    = regexp.MustCompile(`<[^>]*>`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText converts an HTML email body to readable plain text: block
+// elements become line breaks, list items become "- " lines and links keep
+// their URL in parentheses.
+func HTMLToText(s string) string {
+	s = htmlHidden.ReplaceAllString(s, "")
+	s = htmlSpace.ReplaceAllString(s, " ")
+
+	s = htmlLink.ReplaceAllStringFunc(s, func(a string) string {
+		m := htmlLink.FindStringSubmatch(a)
+		href, text := html.UnescapeString(m[1]), m[2]
+		label := strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(text, "")))
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "mailto:") || label == href {
+			return text
+		}
+		if label == "" {
+			return href
+		}
+		return text + " (" + href + ")"
+	})
+
+	s = htmlBreak.ReplaceAllString(s, "\n")
+	s = htmlRule.ReplaceAllString(s, "\n---\n")
+	s = htmlItem.ReplaceAllString(s, "\n- ")
+	s = htmlCell.ReplaceAllString(s, " ")
+	s = htmlBlock.ReplaceAllString(s, "\n\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
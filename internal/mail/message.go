@@ -0,0 +1,236 @@
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-message"
+	"github.com/emersion/go-message/charset"
+	msgmail "github.com/emersion/go-message/mail"
+)
+
+// Address is a decoded email address
+type Address struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+}
+
+// Attachment describes a part of a message that isn't its body
+type Attachment struct {
+	Index       int    `json:"index"` // 1-based, in the order the parts appear
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"` // decoded size in bytes
+	Inline      bool   `json:"inline,omitempty"`
+}
+
+// Message is a parsed RFC822 message
+type Message struct {
+	MessageID   string
+	Subject     string
+	Date        time.Time
+	From        []Address
+	To          []Address
+	Cc          []Address
+	ReplyTo     []Address
+	InReplyTo   []string
+	References  []string
+	Body        string // plain text; converted from HTML if there is no text part
+	BodyType    string // the content type the body came from, or "" if there is none
+	Attachments []Attachment
+}
+
+// wordDecoder decodes RFC 2047 encoded words in attachment filenames
+var wordDecoder = &mime.WordDecoder{CharsetReader: charset.Reader}
+
+// ParseMessage parses a raw RFC822 message, decoding its headers, body and
+// transfer encodings. Parts in unknown charsets are kept undecoded.
+func ParseMessage(raw []byte) (*Message, error) {
+	entity, err := readEntity(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	h := msgmail.Header{Header: entity.Header}
+	m := &Message{}
+	m.MessageID, _ = h.MessageID()
+	m.Subject, _ = h.Subject()
+	m.Date, _ = h.Date()
+	m.From = addressList(h, "From")
+	m.To = addressList(h, "To")
+	m.Cc = addressList(h, "Cc")
+	m.ReplyTo = addressList(h, "Reply-To")
+	m.InReplyTo, _ = h.MsgIDList("In-Reply-To")
+	m.References, _ = h.MsgIDList("References")
+
+	var html string
+	err = walkParts(entity, func(e *message.Entity, a *Attachment) (bool, error) {
+		if a != nil {
+			n, err := io.Copy(io.Discard, e.Body)
+			if err != nil {
+				return false, fmt.Errorf("reading attachment %d: %w", a.Index, err)
+			}
+			a.Size = n
+			m.Attachments = append(m.Attachments, *a)
+			return false, nil
+		}
+
+		// The first text part is the body; an HTML part is only used if
+		// there is no plain text alternative
+		data, err := io.ReadAll(e.Body)
+		if err != nil {
+			return false, fmt.Errorf("reading message body: %w", err)
+		}
+		t, _, _ := e.Header.ContentType()
+		switch {
+		case t == "text/html" && html == "":
+			html = string(data)
+		case t != "text/html" && m.BodyType == "":
+			m.Body = string(data)
+			m.BodyType = "text/plain"
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if m.BodyType == "" && html != "" {
+		m.Body = HTMLToText(html)
+		m.BodyType = "text/html"
+	}
+	m.Body = strings.TrimRight(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n")
+	return m, nil
+}
+
+// ExtractAttachment returns the attachment with the given index (as listed
+// by ParseMessage) and its decoded content
+func ExtractAttachment(raw []byte, index int) (*Attachment, []byte, error) {
+	entity, err := readEntity(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var found *Attachment
+	var data []byte
+	err = walkParts(entity, func(e *message.Entity, a *Attachment) (bool, error) {
+		if a == nil || a.Index != index {
+			return false, nil
+		}
+		if data, err = io.ReadAll(e.Body); err != nil {
+			return false, fmt.Errorf("reading attachment %d: %w", index, err)
+		}
+		a.Size = int64(len(data))
+		found = a
+		return true, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if found == nil {
+		return nil, nil, fmt.Errorf("message has no attachment %d", index)
+	}
+	return found, data, nil
+}
+
+// readEntity reads a message, tolerating unknown charsets and encodings
+func readEntity(raw []byte) (*message.Entity, error) {
+	entity, err := message.Read(bytes.NewReader(raw))
+	if err != nil && !message.IsUnknownCharset(err) && !message.IsUnknownEncoding(err) {
+		return nil, fmt.Errorf("parsing message: %w", err)
+	}
+	return entity, nil
+}
+
+// errStopWalk ends walkParts early
+var errStopWalk = errors.New("stop walk")
+
+// walkParts calls fn for every leaf part of a message, with the part's
+// attachment description or nil if it is body text. fn returns true to stop.
+func walkParts(entity *message.Entity, fn func(e *message.Entity, a *Attachment) (bool, error)) error {
+	index := 0
+	err := entity.Walk(func(path []int, e *message.Entity, err error) error {
+		if e.MultipartReader() != nil {
+			return nil
+		}
+
+		var a *Attachment
+		if isAttachment(e) {
+			index++
+			a = describeAttachment(e, index)
+		}
+		stop, err := fn(e, a)
+		if err != nil {
+			return err
+		}
+		if stop {
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil && err != errStopWalk {
+		return err
+	}
+	return nil
+}
+
+// isAttachment reports whether a leaf part is an attachment rather than
+// body text: anything that isn't unnamed, non-attachment text/plain or
+// text/html
+func isAttachment(e *message.Entity) bool {
+	disp, _, _ := e.Header.ContentDisposition()
+	if strings.EqualFold(disp, "attachment") || partFilename(e) != "" {
+		return true
+	}
+	t, _, err := e.Header.ContentType()
+	if err != nil || t == "" {
+		return false // text/plain by default
+	}
+	return t != "text/plain" && t != "text/html"
+}
+
+func describeAttachment(e *message.Entity, index int) *Attachment {
+	t, _, err := e.Header.ContentType()
+	if err != nil || t == "" {
+		t = "text/plain"
+	}
+	disp, _, _ := e.Header.ContentDisposition()
+	return &Attachment{
+		Index:       index,
+		Filename:    partFilename(e),
+		ContentType: t,
+		Inline:      strings.EqualFold(disp, "inline") || (disp == "" && e.Header.Get("Content-Id") != ""),
+	}
+}
+
+// partFilename returns a part's decoded filename, from Content-Disposition
+// or the older Content-Type name parameter
+func partFilename(e *message.Entity) string {
+	_, dparams, _ := e.Header.ContentDisposition()
+	name := dparams["filename"]
+	if name == "" {
+		_, cparams, _ := e.Header.ContentType()
+		name = cparams["name"]
+	}
+	if decoded, err := wordDecoder.DecodeHeader(name); err == nil {
+		name = decoded
+	}
+	return name
+}
+
+func addressList(h msgmail.Header, key string) []Address {
+	list, err := h.AddressList(key)
+	if err != nil {
+		return nil
+	}
+	addrs := make([]Address, 0, len(list))
+	for _, a := range list {
+		addrs = append(addrs, Address{Name: a.Name, Email: a.Address})
+	}
+	return addrs
+}
@@ -0,0 +1,140 @@
+package mail
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// multipartMessage is a text/HTML alternative with a quoted-printable body,
+// an inline image and a base64 attachment with an encoded filename
+var multipartMessage = strings.ReplaceAll(`From: Alice Tan <alice@example.com>
+To: me@example.com, "Bob Lim" <bob@example.com>
+Cc: =?UTF-8?Q?Ch=C3=A9_Wong?= <che@example.com>
+Reply-To: team@example.com
+Subject: =?UTF-8?Q?Q4_report_=E2=80=93_final?=
+Date: Mon, 19 Oct 2026 09:30:00 +0800
+Message-ID: <report-2@example.com>
+In-Reply-To: <report-1@example.com>
+References: <report-0@example.com> <report-1@example.com>
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="mixed"
+
+--mixed
+Content-Type: multipart/alternative; boundary="alt"
+
+--alt
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Hi team,
+
+The caf=C3=A9 numbers are in. See the attached report =E2=80=94 it's a long=
+ line that was soft-wrapped.
+
+--alt
+Content-Type: text/html; charset=utf-8
+
+<p>Hi team,</p><p>The caf&eacute; numbers are in.</p>
+--alt--
+
+--mixed
+Content-Type: image/png
+Content-Disposition: inline
+Content-ID: <chart>
+Content-Transfer-Encoding: base64
+
+iVBORw0KGgo=
+--mixed
+Content-Type: application/pdf; name="report.pdf"
+Content-Disposition: attachment; filename="=?UTF-8?Q?Q4_r=C3=A9sum=C3=A9.pdf?="
+Content-Transfer-Encoding: base64
+
+JVBERi0xLjQKJcOkw7zDtsOfCg==
+--mixed--
+`, "\n", "\r\n")
+
+func TestParseMessage(t *testing.T) {
+	m, err := ParseMessage([]byte(multipartMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Subject != "Q4 report – final" {
+		t.Errorf("Subject = %q", m.Subject)
+	}
+	if m.MessageID != "report-2@example.com" {
+		t.Errorf("MessageID = %q", m.MessageID)
+	}
+	if got := m.Date.UTC().Format("2006-01-02T15:04"); got != "2026-10-19T01:30" {
+		t.Errorf("Date = %s", got)
+	}
+	wantTo := []Address{{Email: "me@example.com"}, {Name: "Bob Lim", Email: "bob@example.com"}}
+	if !reflect.DeepEqual(m.To, wantTo) {
+		t.Errorf("To = %+v", m.To)
+	}
+	if len(m.Cc) != 1 || m.Cc[0].Name != "Ché Wong" {
+		t.Errorf("Cc = %+v", m.Cc)
+	}
+	if len(m.ReplyTo) != 1 || m.ReplyTo[0].Email != "team@example.com" {
+		t.Errorf("ReplyTo = %+v", m.ReplyTo)
+	}
+	if !reflect.DeepEqual(m.InReplyTo, []string{"report-1@example.com"}) {
+		t.Errorf("InReplyTo = %v", m.InReplyTo)
+	}
+	if !reflect.DeepEqual(m.References, []string{"report-0@example.com", "report-1@example.com"}) {
+		t.Errorf("References = %v", m.References)
+	}
+
+	wantBody := "Hi team,\n\nThe café numbers are in. See the attached report — it's a long line that was soft-wrapped."
+	if m.Body != wantBody || m.BodyType != "text/plain" {
+		t.Errorf("Body (%s) = %q", m.BodyType, m.Body)
+	}
+
+	wantAttachments := []Attachment{
+		{Index: 1, ContentType: "image/png", Size: 8, Inline: true},
+		{Index: 2, Filename: "Q4 résumé.pdf", ContentType: "application/pdf", Size: 19},
+	}
+	if !reflect.DeepEqual(m.Attachments, wantAttachments) {
+		t.Errorf("Attachments = %+v", m.Attachments)
+	}
+}
+
+func TestParseMessageHTMLOnly(t *testing.T) {
+	raw := "From: alice@example.com\r\n" +
+		"Subject: Launch\r\n" +
+		"Content-Type: text/html; charset=iso-8859-1\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"<html><head><style>p{color:red}</style></head><body>\r\n" +
+		"<h1>Launch  day</h1><p>Caf=E9 opens at <b>9</b>.<br>Bring:</p>\r\n" +
+		"<ul><li>Badge</li><li>Laptop</li></ul>\r\n" +
+		"<p><a href=3D\"https://example.com/rsvp\">RSVP</a> &amp; <a href=3D\"https://example.com\">https://example.com</a></p>\r\n" +
+		"</body></html>\r\n"
+
+	m, err := ParseMessage([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Launch day\n\nCafé opens at 9.\nBring:\n\n- Badge\n- Laptop\n\nRSVP (https://example.com/rsvp) & https://example.com"
+	if m.Body != want || m.BodyType != "text/html" {
+		t.Errorf("Body (%s) = %q, want %q", m.BodyType, m.Body, want)
+	}
+	if len(m.Attachments) != 0 {
+		t.Errorf("Attachments = %+v", m.Attachments)
+	}
+}
+
+func TestExtractAttachment(t *testing.T) {
+	a, data, err := ExtractAttachment([]byte(multipartMessage), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Filename != "Q4 résumé.pdf" || string(data[:8]) != "%PDF-1.4" || a.Size != int64(len(data)) {
+		t.Errorf("got %+v, %q", a, data)
+	}
+
+	if _, _, err := ExtractAttachment([]byte(multipartMessage), 3); err == nil {
+		t.Error("expected an error for a missing attachment")
+	}
+}
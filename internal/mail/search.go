@@ -1,8 +1,6 @@
 package mail
 
-import (
-	"time"
-)
+import "time"
 
 // Search performs a local cache search with the given options
 func Search(mailbox string, opts *SearchOptions) (*SearchResult, error) {
@@ -0,0 +1,271 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapserver"
+	"github.com/emersion/go-imap/v2/imapserver/imapmemserver"
+	"github.com/yjwong/lark-cli/internal/config"
+)
+
+const (
+	testUser     = "me@example.com"
+	testPassword = "secret"
+)
+
+// newTestIMAPServer starts an in-memory IMAP server with the given
+// mailboxes and returns credentials for it and its user
+func newTestIMAPServer(t *testing.T, mailboxes ...string) (*Credentials, *imapmemserver.User) {
+	t.Helper()
+
+	user := imapmemserver.NewUser(testUser, testPassword)
+	for _, name := range append([]string{"INBOX"}, mailboxes...) {
+		if err := user.Create(name, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	memServer := imapmemserver.New()
+	memServer.AddUser(user)
+
+	server := imapserver.New(&imapserver.Options{
+		NewSession: func(*imapserver.Conn) (imapserver.Session, *imapserver.GreetingData, error) {
+			return memServer.NewSession(), nil, nil
+		},
+		Caps: imap.CapSet{
+			imap.CapIMAP4rev1: {},
+			imap.CapUIDPlus:   {},
+			imap.CapMove:      {},
+		},
+		InsecureAuth: true,
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Close() })
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return &Credentials{
+		Host:     "127.0.0.1",
+		Port:     addr.Port,
+		Username: testUser,
+		Password: testPassword,
+	}, user
+}
+
+// useTestConfig points the config at a temporary directory holding creds,
+// so Connect and OpenCache use the test server and a fresh cache
+func useTestConfig(t *testing.T, creds *Credentials) {
+	t.Helper()
+
+	t.Setenv("LARK_CONFIG_DIR", t.TempDir())
+	t.Setenv("LARK_PROFILE", "")
+	if err := config.Init(""); err != nil {
+		t.Fatal(err)
+	}
+	if creds != nil {
+		if err := SaveCredentials(creds); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// appendTestMessage adds a message to a mailbox on the test server. Line
+// endings are converted to CRLF.
+func appendTestMessage(t *testing.T, user *imapmemserver.User, mailbox, raw string, flags ...imap.Flag) {
+	t.Helper()
+
+	raw = strings.ReplaceAll(strings.ReplaceAll(raw, "\r\n", "\n"), "\n", "\r\n")
+	opts := &imap.AppendOptions{Flags: flags, Time: time.Date(2026, 10, 19, 1, 30, 0, 0, time.UTC)}
+	if _, err := user.Append(mailbox, literal{bytes.NewReader([]byte(raw))}, opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// literal is an imap.LiteralReader for a message in memory
+type literal struct {
+	*bytes.Reader
+}
+
+// smtpDelivery is a message received by the test SMTP server
+type smtpDelivery struct {
+	From string
+	To   []string
+	Data string
+}
+
+// startTestSMTPServer starts a minimal plaintext SMTP server that accepts
+// any login and records what it receives. It points creds at the server.
+func startTestSMTPServer(t *testing.T, creds *Credentials) func() []smtpDelivery {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	var deliveries []smtpDelivery
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			serveSMTP(conn, func(d smtpDelivery) {
+				mu.Lock()
+				deliveries = append(deliveries, d)
+				mu.Unlock()
+			})
+		}
+	}()
+
+	creds.SMTPHost = "127.0.0.1"
+	creds.SMTPPort = ln.Addr().(*net.TCPAddr).Port
+	creds.SMTPSecurity = SMTPSecurityNone
+	return func() []smtpDelivery {
+		mu.Lock()
+		defer mu.Unlock()
+		return deliveries
+	}
+}
+
+func serveSMTP(conn net.Conn, deliver func(smtpDelivery)) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+	var cur smtpDelivery
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			reply("235 OK")
+		case "MAIL":
+			cur = smtpDelivery{From: strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")}
+			reply("250 OK")
+		case "RCPT":
+			cur.To = append(cur.To, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			cur.Data = data.String()
+			deliver(cur)
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSendMailAndSaveSent(t *testing.T) {
+	creds, user := newTestIMAPServer(t, "Sent Items")
+	delivered := startTestSMTPServer(t, creds)
+
+	d := &Draft{
+		From:    Address{Email: testUser},
+		To:      []Address{{Name: "Alice Tan", Email: "alice@example.com"}},
+		Bcc:     []Address{{Email: "eve@example.com"}},
+		Subject: "Status",
+		Body:    "Done.\n.\nReally.\n",
+	}
+	messageID, raw, err := d.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SendMail(creds, d.From.Email, d.Recipients(), raw); err != nil {
+		t.Fatal(err)
+	}
+
+	got := delivered()
+	if len(got) != 1 {
+		t.Fatalf("delivered %d messages", len(got))
+	}
+	if got[0].From != testUser || strings.Join(got[0].To, ",") != "alice@example.com,eve@example.com" {
+		t.Errorf("delivered from %s to %v", got[0].From, got[0].To)
+	}
+	m, err := ParseMessage([]byte(got[0].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MessageID != messageID || m.Body != "Done.\n.\nReally." {
+		t.Errorf("delivered %q: %q", m.MessageID, m.Body)
+	}
+
+	client, err := ConnectWithCredentials(creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	mailbox, err := client.SaveSent(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mailbox != "Sent Items" {
+		t.Errorf("saved to %q", mailbox)
+	}
+	status, err := user.Status("Sent Items", &imap.StatusOptions{NumMessages: true, NumUnseen: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *status.NumMessages != 1 || *status.NumUnseen != 0 {
+		t.Errorf("Sent Items has %d messages, %d unseen", *status.NumMessages, *status.NumUnseen)
+	}
+}
+
+func TestMailboxStatuses(t *testing.T) {
+	creds, user := newTestIMAPServer(t, "Archive")
+	appendTestMessage(t, user, "INBOX", "Subject: one\n\nHi.\n", imap.FlagSeen)
+	appendTestMessage(t, user, "INBOX", "Subject: two\n\nHi.\n")
+	appendTestMessage(t, user, "Archive", "Subject: three\n\nHi.\n", imap.FlagSeen)
+
+	client, err := ConnectWithCredentials(creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	got, err := client.MailboxStatuses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MailboxStatus{
+		{Name: "Archive", Messages: 1, Unread: 0},
+		{Name: "INBOX", Messages: 2, Unread: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+}
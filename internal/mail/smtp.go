@@ -0,0 +1,76 @@
+package mail
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// smtpTimeout bounds connecting to the SMTP server
+const smtpTimeout = 30 * time.Second
+
+// SendMail delivers a message over SMTP to every recipient, logging in with
+// the IMAP username and password
+func SendMail(creds *Credentials, from string, recipients []string, msg []byte) error {
+	host, port, security := creds.SMTPServer()
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: host}
+
+	var conn net.Conn
+	var err error
+	switch security {
+	case SMTPSecuritySSL:
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: smtpTimeout}, "tcp", addr, tlsConfig)
+	case SMTPSecuritySTARTTLS, SMTPSecurityNone:
+		conn, err = net.DialTimeout("tcp", addr, smtpTimeout)
+	default:
+		return fmt.Errorf("unknown SMTP security %q (must be ssl, starttls or none)", security)
+	}
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	if security == SMTPSecuritySTARTTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s doesn't support STARTTLS", addr)
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+	if ok, _ := c.Extension("AUTH"); ok {
+		if err := c.Auth(smtp.PlainAuth("", creds.Username, creds.Password, host)); err != nil {
+			return fmt.Errorf("SMTP login failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("sending from %s: %w", from, err)
+	}
+	for _, rcpt := range recipients {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("sending to %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	return c.Quit()
+}
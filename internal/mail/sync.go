@@ -3,6 +3,8 @@ package mail
 import (
 	"fmt"
 	"io"
+	"regexp"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,16 +16,33 @@ import (
 type SyncOptions struct {
 	Workers  int
 	Progress io.Writer // If set, progress is written here
+	// Bodies also fetches and indexes the body text of messages that don't
+	// have it yet, skipping messages larger than MaxBodySize bytes (0 for
+	// no limit)
+	Bodies      bool
+	MaxBodySize int64
 }
 
 // SyncResult contains the result of a sync operation
 type SyncResult struct {
 	Mailbox     string `json:"mailbox"`
 	NewMessages int    `json:"new_messages"`
-	TotalCached int    `json:"total_cached"`
-	Message     string `json:"message"`
+	// Refreshed counts envelopes cached by an older version that were
+	// fetched again to fill in recipients, flags, size and attachments
+	Refreshed     int    `json:"refreshed,omitempty"`
+	Expunged      int    `json:"expunged,omitempty"`      // deleted or moved away
+	FlagsUpdated  int    `json:"flags_updated,omitempty"` // read, flagged and other flag changes
+	TotalCached   int    `json:"total_cached"`
+	BodiesIndexed int    `json:"bodies_indexed,omitempty"`
+	BodiesSkipped int    `json:"bodies_skipped,omitempty"` // over the size cap
+	Message       string `json:"message"`
+	Error         string `json:"error,omitempty"` // set by SyncAll if this mailbox failed
 }
 
+// bodyBatchSize is how many messages are fetched at a time when indexing
+// bodies; whole messages are much larger than envelopes
+const bodyBatchSize = 50
+
 // Sync fetches new messages from the server and updates the cache
 func Sync(mailbox string, opts *SyncOptions) (*SyncResult, error) {
 	if opts == nil {
@@ -71,78 +90,355 @@ func Sync(mailbox string, opts *SyncOptions) (*SyncResult, error) {
 		Mailbox: mailbox,
 	}
 
-	if mbox.NumMessages == 0 {
-		if err := cache.UpdateMailboxState(mailbox, mbox.UIDValidity, 0); err != nil {
-			return nil, err
-		}
-		result.Message = "mailbox is empty"
-		return result, nil
-	}
-
 	// Get cached UIDs
 	cachedUIDs, err := cache.GetCachedUIDs(mailbox)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get all server UIDs
-	if opts.Progress != nil {
-		fmt.Fprintf(opts.Progress, "Checking for new messages...\n")
+	// With CONDSTORE, an unchanged HIGHESTMODSEQ and UIDNEXT mean no flag
+	// changes and no new messages, and an unchanged message count then
+	// rules out expunges
+	if state != nil && mbox.HighestModSeq > 0 &&
+		state.HighestModSeq == mbox.HighestModSeq && state.UIDNext == mbox.UIDNext &&
+		len(cachedUIDs) == int(mbox.NumMessages) && allComplete(cachedUIDs) {
+		result.TotalCached = len(cachedUIDs)
+		return finishSync(client, cache, mbox, state.LastUID, opts, result)
 	}
-	serverUIDs, err := client.GetAllUIDs()
-	if err != nil {
-		return nil, fmt.Errorf("getting server UIDs: %w", err)
+
+	// Get all server UIDs. QRESYNC would report expunged UIDs directly
+	// (VANISHED), but the IMAP library can't parse those responses yet.
+	var serverUIDs []imap.UID
+	if mbox.NumMessages > 0 {
+		if opts.Progress != nil {
+			fmt.Fprintf(opts.Progress, "Checking for new messages...\n")
+		}
+		serverUIDs, err = client.GetAllUIDs()
+		if err != nil {
+			return nil, fmt.Errorf("getting server UIDs: %w", err)
+		}
 	}
 
-	// Find missing UIDs
+	// Remove messages that were expunged or moved to another mailbox
+	onServer := make(map[uint32]bool, len(serverUIDs))
+	for _, uid := range serverUIDs {
+		onServer[uint32(uid)] = true
+	}
+	var gone []uint32
+	for uid := range cachedUIDs {
+		if !onServer[uid] {
+			gone = append(gone, uid)
+		}
+	}
+	if err := cache.DeleteEnvelopes(mailbox, gone); err != nil {
+		return nil, err
+	}
+	result.Expunged = len(gone)
+
+	// Pick up flag changes: with CONDSTORE only those since the last sync,
+	// otherwise the flags of every message
+	if len(cachedUIDs) > len(gone) {
+		var changedSince uint64
+		if state != nil && mbox.HighestModSeq > 0 {
+			changedSince = state.HighestModSeq
+		}
+		flags, err := client.FetchFlags(changedSince)
+		if err != nil {
+			return nil, err
+		}
+		byUID := make(map[uint32][]string, len(flags))
+		for uid, list := range flags {
+			byUID[uint32(uid)] = list
+		}
+		if result.FlagsUpdated, err = cache.UpdateFlags(mailbox, byUID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Find missing UIDs, and cached ones that need fetching again
 	var missingUIDs []imap.UID
+	var refreshed int
 	for _, uid := range serverUIDs {
-		if !cachedUIDs[uint32(uid)] {
+		complete, cached := cachedUIDs[uint32(uid)]
+		if !complete {
 			missingUIDs = append(missingUIDs, uid)
 		}
+		if cached && !complete {
+			refreshed++
+		}
 	}
 
-	if len(missingUIDs) == 0 {
-		// Update state to track current max UID
-		if len(serverUIDs) > 0 {
-			maxUID := serverUIDs[len(serverUIDs)-1]
-			if err := cache.UpdateMailboxState(mailbox, mbox.UIDValidity, uint32(maxUID)); err != nil {
-				return nil, err
-			}
+	if len(missingUIDs) > 0 {
+		if opts.Progress != nil {
+			fmt.Fprintf(opts.Progress, "Found %d messages to sync\n", len(missingUIDs))
 		}
 
-		result.TotalCached = len(cachedUIDs)
-		result.Message = "already up to date"
-		return result, nil
+		// Fetch missing UIDs in parallel
+		var newCount int
+		if opts.Workers > 1 && len(missingUIDs) > 100 {
+			newCount, err = fetchMissingUIDsParallel(cache, mailbox, mbox.UIDValidity, missingUIDs, opts)
+		} else {
+			newCount, err = fetchMissingUIDs(cache, mailbox, mbox.UIDValidity, missingUIDs, opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result.NewMessages = max(newCount-refreshed, 0)
+		result.Refreshed = min(refreshed, newCount)
 	}
 
-	if opts.Progress != nil {
-		fmt.Fprintf(opts.Progress, "Found %d messages to sync\n", len(missingUIDs))
+	if result.TotalCached, err = cache.CountEnvelopes(mailbox); err != nil {
+		return nil, err
+	}
+
+	var lastUID uint32
+	if len(serverUIDs) > 0 {
+		lastUID = uint32(serverUIDs[len(serverUIDs)-1])
 	}
+	return finishSync(client, cache, mbox, lastUID, opts, result)
+}
+
+// SyncAllResult contains the result of syncing several mailboxes
+type SyncAllResult struct {
+	Mailboxes     []*SyncResult `json:"mailboxes"`
+	Synced        int           `json:"synced"`
+	Failed        int           `json:"failed,omitempty"`
+	NewMessages   int           `json:"new_messages"`
+	Expunged      int           `json:"expunged,omitempty"`
+	FlagsUpdated  int           `json:"flags_updated,omitempty"`
+	TotalCached   int           `json:"total_cached"`
+	BodiesIndexed int           `json:"bodies_indexed,omitempty"`
+}
+
+// SyncAll syncs every selectable mailbox whose name matches one of the
+// include globs (all of them if there are none) and none of the exclude
+// globs. A mailbox that fails to sync is reported in its result and the
+// rest are still synced; an error is only returned if none could be.
+func SyncAll(include, exclude []string, opts *SyncOptions) (*SyncAllResult, error) {
+	client, err := Connect()
+	if err != nil {
+		return nil, err
+	}
+	names, err := client.SelectableMailboxes()
+	client.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	names, err = filterMailboxes(names, include, exclude)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no mailboxes match")
+	}
+
+	all := &SyncAllResult{Mailboxes: []*SyncResult{}}
+	var lastErr error
+	for _, name := range names {
+		if opts != nil && opts.Progress != nil {
+			fmt.Fprintf(opts.Progress, "Syncing %s...\n", name)
+		}
+		result, err := Sync(name, opts)
+		if err != nil {
+			lastErr = err
+			all.Failed++
+			all.Mailboxes = append(all.Mailboxes, &SyncResult{Mailbox: name, Error: err.Error()})
+			continue
+		}
+		all.Synced++
+		all.NewMessages += result.NewMessages
+		all.Expunged += result.Expunged
+		all.FlagsUpdated += result.FlagsUpdated
+		all.TotalCached += result.TotalCached
+		all.BodiesIndexed += result.BodiesIndexed
+		all.Mailboxes = append(all.Mailboxes, result)
+	}
+
+	if all.Synced == 0 {
+		return nil, fmt.Errorf("syncing %s: %w", names[len(names)-1], lastErr)
+	}
+	return all, nil
+}
 
-	// Fetch missing UIDs in parallel
-	var newCount int
-	if opts.Workers > 1 && len(missingUIDs) > 100 {
-		newCount, err = fetchMissingUIDsParallel(cache, mailbox, mbox.UIDValidity, missingUIDs, opts)
-	} else {
-		newCount, err = fetchMissingUIDs(cache, mailbox, mbox.UIDValidity, missingUIDs, opts)
+// filterMailboxes keeps the names matching an include glob, or all of them
+// if there are none, and then drops those matching an exclude glob. Globs
+// are case-insensitive; * matches any run of characters, including the
+// hierarchy separator, and ? matches one.
+func filterMailboxes(names, include, exclude []string) ([]string, error) {
+	inc, err := compileGlobs(include)
+	if err != nil {
+		return nil, err
 	}
+	exc, err := compileGlobs(exclude)
 	if err != nil {
 		return nil, err
 	}
 
-	result.NewMessages = newCount
-	result.TotalCached = len(cachedUIDs) + newCount
+	var kept []string
+	for _, name := range names {
+		if len(inc) > 0 && !matchesAny(inc, name) {
+			continue
+		}
+		if matchesAny(exc, name) {
+			continue
+		}
+		kept = append(kept, name)
+	}
+	return kept, nil
+}
 
-	if result.NewMessages == 0 {
-		result.Message = "already up to date"
-	} else {
-		result.Message = fmt.Sprintf("synced %d new messages", result.NewMessages)
+func compileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(globs))
+	for _, glob := range globs {
+		var b strings.Builder
+		b.WriteString("(?i)^")
+		for _, r := range glob {
+			switch r {
+			case '*':
+				b.WriteString(".*")
+			case '?':
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		b.WriteString("$")
+		re, err := regexp.Compile(b.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid mailbox pattern %q: %w", glob, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(res []*regexp.Regexp, name string) bool {
+	for _, re := range res {
+		if re.MatchString(name) {
+			return true
+		}
 	}
+	return false
+}
 
+// finishSync indexes bodies if asked to, then records the sync point so
+// the next sync can tell what changed
+func finishSync(client *Client, cache *Cache, mbox *Mailbox, lastUID uint32, opts *SyncOptions, result *SyncResult) (*SyncResult, error) {
+	if err := syncBodies(client, cache, mbox.Name, opts, result); err != nil {
+		return nil, err
+	}
+
+	if err := cache.UpdateMailboxState(mbox.Name, mbox.UIDValidity, lastUID); err != nil {
+		return nil, err
+	}
+	if err := cache.SetSyncPoint(mbox.Name, mbox.HighestModSeq, mbox.UIDNext); err != nil {
+		return nil, err
+	}
+
+	result.Message = syncMessage(result)
 	return result, nil
 }
 
+// syncMessage summarizes what a sync changed
+func syncMessage(r *SyncResult) string {
+	var parts []string
+	if r.NewMessages > 0 {
+		parts = append(parts, fmt.Sprintf("synced %d new messages", r.NewMessages))
+	}
+	if r.Refreshed > 0 {
+		parts = append(parts, fmt.Sprintf("refreshed %d cached messages", r.Refreshed))
+	}
+	if r.Expunged > 0 {
+		parts = append(parts, fmt.Sprintf("removed %d deleted or moved messages", r.Expunged))
+	}
+	if r.FlagsUpdated > 0 {
+		parts = append(parts, fmt.Sprintf("updated flags of %d messages", r.FlagsUpdated))
+	}
+	if r.BodiesIndexed > 0 {
+		parts = append(parts, fmt.Sprintf("indexed %d bodies", r.BodiesIndexed))
+	}
+
+	switch {
+	case len(parts) > 0:
+		return strings.Join(parts, ", ")
+	case r.TotalCached == 0:
+		return "mailbox is empty"
+	default:
+		return "already up to date"
+	}
+}
+
+// allComplete reports whether every cached envelope has all its fields
+func allComplete(cachedUIDs map[uint32]bool) bool {
+	for _, complete := range cachedUIDs {
+		if !complete {
+			return false
+		}
+	}
+	return true
+}
+
+// syncBodies indexes the body text of cached messages that don't have it,
+// if opts asks for it. client must have mailbox selected.
+func syncBodies(client *Client, cache *Cache, mailbox string, opts *SyncOptions, result *SyncResult) error {
+	if !opts.Bodies {
+		return nil
+	}
+
+	uids, skipped, err := cache.PendingBodies(mailbox, opts.MaxBodySize)
+	if err != nil {
+		return err
+	}
+	result.BodiesSkipped = skipped
+	if len(uids) == 0 {
+		return nil
+	}
+
+	if opts.Progress != nil {
+		fmt.Fprintf(opts.Progress, "Indexing %d message bodies\n", len(uids))
+	}
+
+	for i := 0; i < len(uids); i += bodyBatchSize {
+		end := min(i+bodyBatchSize, len(uids))
+
+		batch := make([]imap.UID, end-i)
+		for j, uid := range uids[i:end] {
+			batch[j] = imap.UID(uid)
+		}
+		raws, err := client.FetchBodies(batch)
+		if err != nil {
+			return err
+		}
+
+		// Messages that can't be parsed are stored with no text so they
+		// aren't fetched again
+		texts := make(map[uint32]string, len(raws))
+		for uid, raw := range raws {
+			if msg, err := ParseMessage(raw); err == nil {
+				texts[uint32(uid)] = msg.Body
+			} else {
+				texts[uint32(uid)] = ""
+			}
+		}
+		if err := cache.SetBodies(mailbox, texts); err != nil {
+			return err
+		}
+		result.BodiesIndexed += len(texts)
+
+		if opts.Progress != nil {
+			fmt.Fprintf(opts.Progress, "\rIndexing: %d / %d bodies (%.1f%%)", end, len(uids), float64(end)/float64(len(uids))*100)
+		}
+	}
+
+	if opts.Progress != nil {
+		fmt.Fprintln(opts.Progress)
+	}
+
+	return nil
+}
+
 // fetchMissingUIDs fetches specific UIDs sequentially
 func fetchMissingUIDs(cache *Cache, mailbox string, uidValidity uint32, uids []imap.UID, opts *SyncOptions) (int, error) {
 	const batchSize = 500
@@ -0,0 +1,143 @@
+package mail
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+// plainMessage returns a short message with the given subject
+func plainMessage(n int, subject string) string {
+	return fmt.Sprintf(`From: Bob Lim <bob@example.com>
+To: me@example.com
+Subject: %s
+Date: Tue, 20 Oct 2026 12:00:00 +0800
+Message-ID: <msg-%d@example.com>
+
+Hello.
+`, subject, n)
+}
+
+func TestSyncTracksFlagsAndExpunges(t *testing.T) {
+	creds, user := newTestIMAPServer(t, "Archive")
+	useTestConfig(t, creds)
+
+	for i := 1; i <= 4; i++ {
+		appendTestMessage(t, user, "INBOX", plainMessage(i, fmt.Sprintf("Message %d", i)))
+	}
+	if _, err := Sync("INBOX", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read one message, flag another, move one away and delete one
+	client, err := ConnectWithCredentials(creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SelectMailbox("INBOX"); err != nil {
+		t.Fatal(err)
+	}
+	for uid, flag := range map[imap.UID]imap.Flag{1: imap.FlagSeen, 2: imap.FlagFlagged, 4: imap.FlagDeleted} {
+		store := &imap.StoreFlags{Op: imap.StoreFlagsAdd, Flags: []imap.Flag{flag}, Silent: true}
+		if err := client.imap.Store(imap.UIDSetNum(uid), store, nil).Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.imap.Move(imap.UIDSetNum(3), "Archive").Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.imap.UIDExpunge(imap.UIDSetNum(4)).Close(); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	appendTestMessage(t, user, "INBOX", plainMessage(5, "Message 5"))
+
+	result, err := Sync("INBOX", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SyncResult{
+		Mailbox:      "INBOX",
+		NewMessages:  1,
+		Expunged:     2,
+		FlagsUpdated: 2,
+		TotalCached:  3,
+		Message:      "synced 1 new messages, removed 2 deleted or moved messages, updated flags of 2 messages",
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("sync result = %+v", result)
+	}
+
+	cache, err := OpenCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+	for uid, want := range map[uint32][]string{1: {`\Seen`}, 2: {`\Flagged`}, 5: {}} {
+		env, err := cache.GetEnvelope("INBOX", uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(env.Flags, want) {
+			t.Errorf("UID %d flags = %v, want %v", uid, env.Flags, want)
+		}
+	}
+
+	result, err = Sync("INBOX", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Message != "already up to date" {
+		t.Errorf("second sync: %+v", result)
+	}
+}
+
+func TestSyncAll(t *testing.T) {
+	creds, user := newTestIMAPServer(t, "Archive", "Projects", "Projects/Alpha", "Projects/Beta", "Trash")
+	useTestConfig(t, creds)
+
+	appendTestMessage(t, user, "INBOX", plainMessage(1, "Inbox"))
+	appendTestMessage(t, user, "Projects/Alpha", plainMessage(2, "Alpha"))
+	appendTestMessage(t, user, "Projects/Beta", plainMessage(3, "Beta"))
+	appendTestMessage(t, user, "Trash", plainMessage(4, "Trash"))
+
+	result, err := SyncAll([]string{"inbox", "projects/*"}, []string{"*/beta"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var synced []string
+	for _, r := range result.Mailboxes {
+		synced = append(synced, r.Mailbox)
+	}
+	if !reflect.DeepEqual(synced, []string{"INBOX", "Projects/Alpha"}) {
+		t.Errorf("synced %v", synced)
+	}
+	if result.Synced != 2 || result.NewMessages != 2 || result.TotalCached != 2 {
+		t.Errorf("result = %+v", result)
+	}
+
+	if _, err := SyncAll([]string{"nothing*"}, nil, nil); err == nil {
+		t.Error("expected an error when no mailbox matches")
+	}
+}
+
+func TestFilterMailboxes(t *testing.T) {
+	names := []string{"INBOX", "Archive", "Archive/2025", "Junk", "Junk E-mail", "Trash"}
+	got, err := filterMailboxes(names, nil, []string{"junk*", "trash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"INBOX", "Archive", "Archive/2025"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got, err = filterMailboxes(names, []string{"Archive?2025", "[Gmail]"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Archive/2025"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,211 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mdMentionPattern matches mentions inside md elements
+var mdMentionPattern = regexp.MustCompile(`<at user_id="([^"]*)"(?:\s[^>]*)?>([^<]*)</at>`)
+
+// FromPost renders a post as markdown
+func FromPost(p *Post) string {
+	var lines []string
+	if p.Title != "" {
+		lines = append(lines, "# "+escapeText(p.Title, true), "")
+	}
+
+	for i, line := range p.Content {
+		block := len(line) == 1 && line[0].isBlock()
+		// Blocks need blank lines around them to parse back as blocks
+		if block && len(lines) > 0 && lines[len(lines)-1] != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, renderLine(line))
+		if block && i+1 < len(p.Content) && !isBlank(p.Content[i+1]) {
+			lines = append(lines, "")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PlainText renders a post as text without markup. Images and videos
+// become [Image] and [Video] and links keep their URL after the text.
+func PlainText(p *Post) string {
+	var lines []string
+	if p.Title != "" {
+		lines = append(lines, p.Title, "")
+	}
+	for _, line := range p.Content {
+		var b strings.Builder
+		for _, e := range line {
+			switch e.Tag {
+			case "text", "code_block":
+				b.WriteString(e.Text)
+			case "a":
+				b.WriteString(e.Text)
+				if e.Href != "" && e.Href != e.Text {
+					b.WriteString(" (" + e.Href + ")")
+				}
+			case "at":
+				b.WriteString(mentionText(e.UserID, e.UserName))
+			case "emotion":
+				b.WriteString(":" + e.EmojiType + ":")
+			case "img":
+				b.WriteString("[Image]")
+			case "media":
+				b.WriteString("[Video]")
+			case "hr":
+				b.WriteString("---")
+			case "md":
+				b.WriteString(mdMentionPattern.ReplaceAllStringFunc(e.Text, func(m string) string {
+					sub := mdMentionPattern.FindStringSubmatch(m)
+					return mentionText(sub[1], sub[2])
+				}))
+			default:
+				b.WriteString(e.Text)
+			}
+		}
+		lines = append(lines, b.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mentionText returns @name, or @id when the name is unknown
+func mentionText(id, name string) string {
+	if id == "all" {
+		return "@all"
+	}
+	if name != "" {
+		return "@" + name
+	}
+	return "@" + id
+}
+
+func isBlank(line []Element) bool {
+	return len(line) == 0 || (len(line) == 1 && line[0].Tag == "text" && line[0].Text == "")
+}
+
+func renderLine(line []Element) string {
+	var b strings.Builder
+	for i, e := range line {
+		switch e.Tag {
+		case "text":
+			b.WriteString(styled(escapeText(e.Text, i == 0 && b.Len() == 0), e))
+		case "a":
+			if e.Text == e.Href && len(e.Style) == 0 && strings.Contains(e.Href, "://") {
+				b.WriteString("<" + e.Href + ">")
+			} else {
+				b.WriteString("[" + styled(escapeText(e.Text, false), e) + "](" + escapeURL(e.Href) + ")")
+			}
+		case "at":
+			if e.UserID == "all" {
+				b.WriteString("@all")
+			} else {
+				b.WriteString("@{" + e.UserID + "}")
+			}
+		case "emotion":
+			b.WriteString(":" + e.EmojiType + ":")
+		case "img":
+			b.WriteString("![](" + e.ImageKey + ")")
+		case "media":
+			b.WriteString("![](" + e.FileKey + ")")
+		case "hr":
+			b.WriteString("---")
+		case "code_block":
+			fence := "```"
+			for strings.Contains(e.Text, fence) {
+				fence += "`"
+			}
+			b.WriteString(fence + strings.ToLower(e.Language) + "\n" + e.Text + "\n" + fence)
+		case "md":
+			b.WriteString(mdMentionPattern.ReplaceAllStringFunc(e.Text, func(m string) string {
+				id := mdMentionPattern.FindStringSubmatch(m)[1]
+				if id == "all" {
+					return "@all"
+				}
+				return "@{" + id + "}"
+			}))
+		default:
+			b.WriteString(escapeText(e.Text, false))
+		}
+	}
+	return b.String()
+}
+
+// styled wraps s in the markup for e's styles, keeping surrounding spaces
+// outside the delimiters so they still count as emphasis
+func styled(s string, e Element) string {
+	if len(e.Style) == 0 || strings.TrimSpace(s) == "" {
+		return s
+	}
+	core := strings.TrimSpace(s)
+	lead := s[:strings.Index(s, core)]
+	trail := s[len(lead)+len(core):]
+
+	if e.hasStyle(StyleLineThrough) {
+		core = "~~" + core + "~~"
+	}
+	if e.hasStyle(StyleUnderline) {
+		core = "<u>" + core + "</u>"
+	}
+	if e.hasStyle(StyleItalic) {
+		core = "*" + core + "*"
+	}
+	if e.hasStyle(StyleBold) {
+		core = "**" + core + "**"
+	}
+	return lead + core + trail
+}
+
+// lineStartPattern matches text that would start a block if it began a line
+var lineStartPattern = regexp.MustCompile(`^\s*(#{1,6}(\s|$)|>|[-+](\s|$)|\d{1,9}[.)](\s|$)|[=-]+\s*$)`)
+
+// entityPattern matches HTML entity and character references
+var entityPattern = regexp.MustCompile(`^&(#[0-9]+|#[xX][0-9a-fA-F]+|[A-Za-z][A-Za-z0-9]*);`)
+
+// escapeText escapes characters markdown would otherwise interpret.
+// lineStart is set when the text begins a line.
+func escapeText(s string, lineStart bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch ch {
+		case '\\', '*', '_', '`', '[', ']', '<':
+			b.WriteByte('\\')
+		case '~':
+			if strings.HasPrefix(s[i:], "~~") || (i > 0 && s[i-1] == '~') {
+				b.WriteByte('\\')
+			}
+		case '&':
+			if entityPattern.MatchString(s[i:]) {
+				b.WriteByte('\\')
+			}
+		case '@', ':':
+			if loc := inlinePattern.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteByte(ch)
+	}
+	out := b.String()
+	if lineStart {
+		if m := lineStartPattern.FindStringSubmatchIndex(out); m != nil {
+			// Escape the punctuation that would start a heading, quote or list
+			at := m[2]
+			for out[at] >= '0' && out[at] <= '9' {
+				at++
+			}
+			out = out[:at] + "\\" + out[at:]
+		}
+	}
+	return out
+}
+
+// escapeURL keeps a link destination from ending early
+func escapeURL(u string) string {
+	if strings.ContainsAny(u, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(u) + ">"
+	}
+	return u
+}
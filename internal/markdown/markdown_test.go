@@ -0,0 +1,168 @@
+package markdown
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// roundTrips are markdown in the form FromPost writes, so converting to a
+// post and back must give the same text
+var roundTrips = []struct {
+	name string
+	md   string
+}{
+	{"plain", "Hello, world"},
+	{"lines", "Line 1\nLine 2\n\nParagraph 2"},
+	{"bold and italic", "**Status:** *Green* and ***both***"},
+	{"strikethrough and underline", "~~old~~ <u>new</u> **<u>loud</u>**"},
+	{"link", "Check [our docs](https://docs.example.com) and <https://example.com>"},
+	{"styled link", "[**bold link**](https://example.com)"},
+	{"mentions", "Please review @{ou_user1}, @all"},
+	{"emoji", "Shipped :THUMBSUP: :Fire:"},
+	{"escaped", "2 \\* 3 = 6, a\\_b, \\`x\\`, \\[x\\], \\<u>, \\@{ou\\_x}, \\:SMILE:, \\~\\~no\\~\\~, \\&amp;"},
+	{"line start escapes", "\\# not a heading\n\\- not a list\n1\\. not a list\n\\> not a quote"},
+	{"title", "# Release notes\n\nShipped it"},
+	{"heading styles stay bold", "**Details**\n\nMore text"},
+	{"hr", "Above\n\n---\n\nBelow"},
+	{"code block", "Run:\n\n```go\nfmt.Println(\"hi\")\n\nreturn\n```"},
+	{"code block without language", "```\nplain\n```"},
+	{"list", "Todo:\n\n- one\n- two with `code`\n  - nested"},
+	{"ordered list", "1. first\n2. second"},
+	{"quote", "> quoted **text**\n> more"},
+	{"inline code", "Run `make build` then @{ou_user1}"},
+	{"list with code", "- step\n\n  ```sh\n  make\n  ```\n\nAfter"},
+	{"image", "Intro\n\n![](img_v3_abc)\n\nOutro"},
+	{"video", "![](file_v3_demo)"},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, tc := range roundTrips {
+		t.Run(tc.name, func(t *testing.T) {
+			post := ToPost(tc.md)
+			got := FromPost(post)
+			if got != tc.md {
+				t.Errorf("FromPost(ToPost(md)) differs\n--- got\n%s\n--- want\n%s\n--- post\n%s", got, tc.md, dump(post))
+			}
+			if again := ToPost(got); !reflect.DeepEqual(again, post) {
+				t.Errorf("ToPost(FromPost(post)) differs\n--- got\n%s\n--- want\n%s", dump(again), dump(post))
+			}
+		})
+	}
+}
+
+func TestToPost(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			name: "styles",
+			md:   "a **b** *c* ~~d~~ <u>e</u>",
+			want: `{"title":"","content":[[{"tag":"text","text":"a "},{"style":["bold"],"tag":"text","text":"b"},{"tag":"text","text":" "},{"style":["italic"],"tag":"text","text":"c"},{"tag":"text","text":" "},{"style":["lineThrough"],"tag":"text","text":"d"},{"tag":"text","text":" "},{"style":["underline"],"tag":"text","text":"e"}]]}`,
+		},
+		{
+			name: "mentions and emoji",
+			md:   "@all :SMILE: @{ou_1}",
+			want: `{"title":"","content":[[{"tag":"at","user_id":"all"},{"tag":"text","text":" "},{"emoji_type":"SMILE","tag":"emotion"},{"tag":"text","text":" "},{"tag":"at","user_id":"ou_1"}]]}`,
+		},
+		{
+			name: "colons inside words",
+			md:   "Status:Blocked: waiting",
+			want: `{"title":"","content":[[{"tag":"text","text":"Status:Blocked: waiting"}]]}`,
+		},
+		{
+			name: "colons in a URL",
+			md:   "see http://example.com/a:B:c",
+			want: `{"title":"","content":[[{"tag":"text","text":"see http://example.com/a:B:c"}]]}`,
+		},
+		{
+			name: "emoji in punctuation",
+			md:   "(:SMILE:)",
+			want: `{"title":"","content":[[{"tag":"text","text":"("},{"emoji_type":"SMILE","tag":"emotion"},{"tag":"text","text":")"}]]}`,
+		},
+		{
+			name: "unclosed underline ends with its block",
+			md:   "<u>open\n\nnext",
+			want: `{"title":"","content":[[{"style":["underline"],"tag":"text","text":"open"}],[{"tag":"text","text":""}],[{"tag":"text","text":"next"}]]}`,
+		},
+		{
+			name: "email is not a mention",
+			md:   "mail team@all.example.com",
+			want: `{"title":"","content":[[{"tag":"text","text":"mail team@all.example.com"}]]}`,
+		},
+		{
+			name: "escaped asterisks",
+			md:   `\*not italic\*`,
+			want: `{"title":"","content":[[{"tag":"text","text":"*not italic*"}]]}`,
+		},
+		{
+			name: "blocks",
+			md:   "# Title\n\n```go\nx := 1\n```\n\n---\n\n- a @{ou_1}",
+			want: `{"title":"Title","content":[[{"language":"GO","tag":"code_block","text":"x := 1"}],[{"tag":"text","text":""}],[{"tag":"hr"}],[{"tag":"text","text":""}],[{"tag":"md","text":"- a \u003cat user_id=\"ou_1\"\u003e\u003c/at\u003e"}]]}`,
+		},
+		{
+			name: "image mid-line",
+			md:   "before ![](img_1) after",
+			want: `{"title":"","content":[[{"tag":"text","text":"before "}],[{"image_key":"img_1","tag":"img"}],[{"tag":"text","text":" after"}]]}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := dump(ToPost(tc.md)); got != tc.want {
+				t.Errorf("ToPost(%q)\n got %s\nwant %s", tc.md, got, tc.want)
+			}
+		})
+	}
+}
+
+// Some markdown has no exact post equivalent and comes back normalized
+func TestNormalized(t *testing.T) {
+	tests := []struct {
+		md, want string
+	}{
+		{"## Section\n\ntext", "**Section**\n\ntext"},
+		{"__bold__ _italic_", "**bold** *italic*"},
+		{"hard  \nbreak", "hard\nbreak"},
+		{"* item", "* item"},
+		{"***\n\nx", "---\n\nx"},
+	}
+	for _, tc := range tests {
+		if got := FromPost(ToPost(tc.md)); got != tc.want {
+			t.Errorf("FromPost(ToPost(%q)) = %q, want %q", tc.md, got, tc.want)
+		}
+	}
+}
+
+func TestFromPostReceived(t *testing.T) {
+	// Posts from other clients put blocks next to text without blank lines
+	post := &Post{Content: [][]Element{
+		{{Tag: "text", Text: "Look"}},
+		{{Tag: "img", ImageKey: "img_1"}},
+		{{Tag: "at", UserID: "ou_1", UserName: "Alice"}, {Tag: "text", Text: " 1. done"}},
+		{{Tag: "md", Text: `<at user_id="ou_2">Bob</at> said hi`}},
+	}}
+	want := "Look\n\n![](img_1)\n\n@{ou_1} 1. done\n\n@{ou_2} said hi"
+	if got := FromPost(post); got != want {
+		t.Errorf("FromPost() = %q, want %q", got, want)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	post := &Post{Title: "Status", Content: [][]Element{
+		{{Tag: "text", Text: "Hi "}, {Tag: "at", UserID: "ou_1", UserName: "Alice"}, {Tag: "text", Text: " *done*", Style: []string{StyleBold}}},
+		{{Tag: "a", Text: "docs", Href: "https://example.com"}, {Tag: "text", Text: " "}, {Tag: "emotion", EmojiType: "SMILE"}},
+		{{Tag: "img", ImageKey: "img_1"}},
+		{{Tag: "md", Text: `- <at user_id="all"></at> <at user_id="ou_2">Bob</at>`}},
+	}}
+	want := "Status\n\nHi @Alice *done*\ndocs (https://example.com) :SMILE:\n[Image]\n- @all @Bob"
+	if got := PlainText(post); got != want {
+		t.Errorf("PlainText() = %q, want %q", got, want)
+	}
+}
+
+func dump(p *Post) string {
+	data, _ := json.Marshal(p)
+	return string(data)
+}
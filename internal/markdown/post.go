@@ -0,0 +1,107 @@
+// Package markdown converts between CommonMark and Lark rich text posts.
+//
+// ToPost maps markdown onto native post elements where a post can express
+// it: paragraphs become lines of styled text, links, @mentions and emoji,
+// fenced code becomes code_block and thematic breaks become hr. Blocks a
+// post has no element for (lists, block quotes and paragraphs with inline
+// code) become md elements. A leading level-one heading becomes the post
+// title; other headings become bold lines.
+//
+// On top of CommonMark, ~~text~~ is strikethrough, <u>text</u> underline,
+// @{ou_xxx} mentions a user, @all mentions everyone and :SMILE: is an emoji.
+// Images whose URL is an image key (img_...) become img elements and file
+// keys (file_...) become media elements.
+//
+// FromPost renders a post back to markdown such that ToPost(FromPost(p))
+// gives p again for any post ToPost produced.
+package markdown
+
+import (
+	"encoding/json"
+)
+
+// Post is the content of a post message in one language
+type Post struct {
+	Title   string      `json:"title"`
+	Content [][]Element `json:"content"`
+}
+
+// Element is one element of a post line
+type Element struct {
+	Tag       string   `json:"tag"`
+	Text      string   `json:"text,omitempty"`
+	Href      string   `json:"href,omitempty"`
+	UserID    string   `json:"user_id,omitempty"`
+	UserName  string   `json:"user_name,omitempty"`
+	ImageKey  string   `json:"image_key,omitempty"`
+	FileKey   string   `json:"file_key,omitempty"`
+	EmojiType string   `json:"emoji_type,omitempty"`
+	Language  string   `json:"language,omitempty"`
+	Style     []string `json:"style,omitempty"`
+}
+
+// Post element styles, in the order they are written
+const (
+	StyleBold        = "bold"
+	StyleItalic      = "italic"
+	StyleUnderline   = "underline"
+	StyleLineThrough = "lineThrough"
+)
+
+// MarshalJSON writes only the fields the element's tag uses, always
+// including text for text elements
+func (e Element) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{"tag": e.Tag}
+	switch e.Tag {
+	case "text", "a":
+		m["text"] = e.Text
+		if e.Tag == "a" {
+			m["href"] = e.Href
+		}
+		if len(e.Style) > 0 {
+			m["style"] = e.Style
+		}
+	case "at":
+		m["user_id"] = e.UserID
+	case "img":
+		m["image_key"] = e.ImageKey
+	case "media":
+		m["file_key"] = e.FileKey
+		if e.ImageKey != "" {
+			m["image_key"] = e.ImageKey
+		}
+	case "emotion":
+		m["emoji_type"] = e.EmojiType
+	case "hr":
+	case "code_block":
+		if e.Language != "" {
+			m["language"] = e.Language
+		}
+		m["text"] = e.Text
+	case "md":
+		m["text"] = e.Text
+	default:
+		type plain Element
+		return json.Marshal(plain(e))
+	}
+	return json.Marshal(m)
+}
+
+// hasStyle reports whether the element has a style
+func (e Element) hasStyle(style string) bool {
+	for _, s := range e.Style {
+		if s == style {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlock reports whether the element fills a line on its own
+func (e Element) isBlock() bool {
+	switch e.Tag {
+	case "hr", "code_block", "md", "img", "media":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,414 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var parser = goldmark.New(goldmark.WithExtensions(extension.Strikethrough)).Parser()
+
+// inlinePattern matches @{id} and @all mentions and :EMOJI: shortcodes in text
+var inlinePattern = regexp.MustCompile(`@\{([^{}\s]+)\}|\B@all\b|:([A-Z][A-Za-z0-9_]*):`)
+
+// ToPost converts markdown to a post
+func ToPost(src string) *Post {
+	source := []byte(src)
+	doc := parser.Parse(text.NewReader(source))
+	c := &converter{source: source}
+
+	block := doc.FirstChild()
+	if h, ok := block.(*ast.Heading); ok && h.Level == 1 {
+		c.inlines(h, style{})
+		c.flush()
+		c.title = lineText(c.cur)
+		c.cur = nil
+		block = block.NextSibling()
+	}
+	for first := true; block != nil; block = block.NextSibling() {
+		if !first {
+			c.lines = append(c.lines, []Element{{Tag: "text"}})
+		}
+		first = false
+		c.underline = 0 // an unclosed <u> ends with its block
+		c.block(block)
+	}
+
+	if c.lines == nil {
+		c.lines = [][]Element{}
+	}
+	return &Post{Title: c.title, Content: c.lines}
+}
+
+// style is the formatting applied to inline text
+type style struct {
+	bold, italic, underline, lineThrough bool
+	href                                 string
+}
+
+type converter struct {
+	source    []byte
+	title     string
+	lines     [][]Element
+	cur       []Element // line being built
+	underline int       // open <u> tags
+
+	pending      string // raw text not yet added to cur
+	pendingStyle style
+}
+
+func (c *converter) block(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Paragraph:
+		if hasCodeSpan(n) {
+			c.lines = append(c.lines, []Element{c.md(n)})
+			return
+		}
+		c.inlines(n, style{})
+		c.flush()
+		c.endLine()
+	case *ast.Heading:
+		c.inlines(n, style{bold: true})
+		c.flush()
+		c.endLine()
+	case *ast.ThematicBreak:
+		c.lines = append(c.lines, []Element{{Tag: "hr"}})
+	case *ast.FencedCodeBlock:
+		c.lines = append(c.lines, []Element{{
+			Tag:      "code_block",
+			Language: strings.ToUpper(string(n.Language(c.source))),
+			Text:     c.blockLines(n),
+		}})
+	case *ast.CodeBlock:
+		c.lines = append(c.lines, []Element{{Tag: "code_block", Text: c.blockLines(n)}})
+	case *ast.HTMLBlock:
+		for _, line := range strings.Split(c.blockLines(n), "\n") {
+			c.lines = append(c.lines, []Element{{Tag: "text", Text: line}})
+		}
+	default:
+		// Lists, block quotes and anything else a post can't express
+		c.lines = append(c.lines, []Element{c.md(n)})
+	}
+}
+
+// md wraps a block's markdown source in an md element, with mentions in
+// the form md elements understand
+func (c *converter) md(n ast.Node) Element {
+	src := c.blockSource(n)
+	src = inlinePattern.ReplaceAllStringFunc(src, func(m string) string {
+		if m == "@all" {
+			return `<at user_id="all"></at>`
+		}
+		if strings.HasPrefix(m, "@{") {
+			return `<at user_id="` + m[2:len(m)-1] + `"></at>`
+		}
+		return m
+	})
+	return Element{Tag: "md", Text: src}
+}
+
+// blockSource returns the source lines a block spans, including list
+// markers and quote prefixes
+func (c *converter) blockSource(n ast.Node) string {
+	start, stop, fenced := span(n)
+	if start < 0 {
+		return ""
+	}
+	start = bytes.LastIndexByte(c.source[:start], '\n') + 1
+	if end := bytes.IndexByte(c.source[stop:], '\n'); end >= 0 {
+		stop += end
+	} else {
+		stop = len(c.source)
+	}
+
+	// A closing code fence isn't part of any block's lines
+	limit := len(c.source)
+	for next := n.NextSibling(); next != nil; next = next.NextSibling() {
+		if nextStart, _, _ := span(next); nextStart >= 0 {
+			limit = nextStart
+			break
+		}
+	}
+	for fenced && stop < limit {
+		line := c.source[stop+1:]
+		if end := bytes.IndexByte(line, '\n'); end >= 0 {
+			line = line[:end]
+		}
+		trimmed := strings.TrimLeft(string(line), " \t>")
+		if !strings.HasPrefix(trimmed, "```") && !strings.HasPrefix(trimmed, "~~~") {
+			break
+		}
+		stop += 1 + len(line)
+		fenced = false
+	}
+	return strings.TrimRight(string(c.source[start:stop]), "\n")
+}
+
+// span returns the source range covered by a block's lines, and whether
+// the last of them belongs to fenced code
+func span(n ast.Node) (start, stop int, fenced bool) {
+	start, stop = -1, -1
+	ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || child.Type() != ast.TypeBlock {
+			return ast.WalkContinue, nil
+		}
+		lines := child.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			if start < 0 || seg.Start < start {
+				start = seg.Start
+			}
+			if seg.Stop >= stop {
+				stop = seg.Stop
+				_, fenced = child.(*ast.FencedCodeBlock)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return start, stop, fenced
+}
+
+// blockLines returns the raw text of a code or HTML block
+func (c *converter) blockLines(n ast.Node) string {
+	var b strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		b.Write(seg.Value(c.source))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (c *converter) inlines(parent ast.Node, st style) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		c.inline(n, st)
+	}
+}
+
+func (c *converter) inline(n ast.Node, st style) {
+	if t, ok := n.(*ast.Text); ok {
+		// Text arrives split at every delimiter; collect it so mentions
+		// and emoji can be found across the pieces
+		if c.pending != "" && c.pendingStyle != st {
+			c.flush()
+		}
+		c.pending += string(t.Value(c.source))
+		c.pendingStyle = st
+		if t.SoftLineBreak() || t.HardLineBreak() {
+			c.flush()
+			c.endLine()
+		}
+		return
+	}
+	c.flush()
+
+	switch n := n.(type) {
+	case *ast.String:
+		c.appendText(string(n.Value), st)
+	case *ast.Emphasis:
+		if n.Level >= 2 {
+			st.bold = true
+		} else {
+			st.italic = true
+		}
+		c.inlines(n, st)
+	case *east.Strikethrough:
+		st.lineThrough = true
+		c.inlines(n, st)
+	case *ast.Link:
+		st.href = string(n.Destination)
+		c.inlines(n, st)
+	case *ast.AutoLink:
+		st.href = string(n.URL(c.source))
+		c.appendText(string(n.Label(c.source)), st)
+	case *ast.Image:
+		dest := string(n.Destination)
+		c.endLine()
+		if strings.HasPrefix(dest, "file_") {
+			c.lines = append(c.lines, []Element{{Tag: "media", FileKey: dest}})
+		} else {
+			c.lines = append(c.lines, []Element{{Tag: "img", ImageKey: dest}})
+		}
+	case *ast.CodeSpan:
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			if t, ok := child.(*ast.Text); ok {
+				c.appendText(string(t.Value(c.source)), st)
+			}
+		}
+	case *ast.RawHTML:
+		var raw strings.Builder
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			raw.Write(seg.Value(c.source))
+		}
+		switch strings.ToLower(raw.String()) {
+		case "<u>":
+			c.underline++
+		case "</u>":
+			if c.underline > 0 {
+				c.underline--
+			}
+		default:
+			c.appendText(raw.String(), st)
+		}
+	default:
+		c.inlines(n, st)
+	}
+	c.flush()
+}
+
+// flush adds collected text, splitting out mentions and emoji outside
+// links. Backslash-escaped mentions and emoji stay text.
+func (c *converter) flush() {
+	s, st := c.pending, c.pendingStyle
+	c.pending = ""
+	if s == "" {
+		return
+	}
+	if st.href != "" {
+		c.appendText(unescape(s), st)
+		return
+	}
+
+	start := 0
+	for _, loc := range inlinePattern.FindAllStringSubmatchIndex(s, -1) {
+		if escaped(s, loc[0]) || loc[4] >= 0 && !standalone(s, loc[0], loc[1]) {
+			continue
+		}
+		c.appendText(unescape(s[start:loc[0]]), st)
+		switch {
+		case loc[2] >= 0:
+			c.cur = append(c.cur, Element{Tag: "at", UserID: unescape(s[loc[2]:loc[3]])})
+		case loc[4] >= 0:
+			c.cur = append(c.cur, Element{Tag: "emotion", EmojiType: s[loc[4]:loc[5]]})
+		default:
+			c.cur = append(c.cur, Element{Tag: "at", UserID: "all"})
+		}
+		start = loc[1]
+	}
+	c.appendText(unescape(s[start:]), st)
+}
+
+// standalone reports whether s[start:end] is set apart from the words
+// around it, so "Status:Blocked:" and "a:B:c" in a URL aren't shortcodes
+func standalone(s string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(s[:start]); start > 0 && !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(s[end:]); end < len(s) && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+		return false
+	}
+	return true
+}
+
+// escaped reports whether s[i] is preceded by an odd number of backslashes
+func escaped(s string, i int) bool {
+	n := 0
+	for i > 0 && s[i-1] == '\\' {
+		n++
+		i--
+	}
+	return n%2 == 1
+}
+
+// unescape resolves backslash escapes and entity references in one pass,
+// so an escaped & doesn't start a reference
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && util.IsPunct(s[i+1]):
+			i++
+			b.WriteByte(s[i])
+		case s[i] == '&':
+			if ref := entityPattern.FindString(s[i:]); ref != "" {
+				r := util.ResolveNumericReferences([]byte(ref))
+				b.Write(util.ResolveEntityNames(r))
+				i += len(ref) - 1
+				continue
+			}
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// appendText adds styled text to the current line, merging it into the
+// previous element when their formatting matches
+func (c *converter) appendText(s string, st style) {
+	if s == "" {
+		return
+	}
+	e := Element{Tag: "text", Text: s, Href: st.href}
+	if st.href != "" {
+		e.Tag = "a"
+	}
+	if st.bold {
+		e.Style = append(e.Style, StyleBold)
+	}
+	if st.italic {
+		e.Style = append(e.Style, StyleItalic)
+	}
+	if st.underline || c.underline > 0 {
+		e.Style = append(e.Style, StyleUnderline)
+	}
+	if st.lineThrough {
+		e.Style = append(e.Style, StyleLineThrough)
+	}
+
+	if n := len(c.cur); n > 0 {
+		prev := &c.cur[n-1]
+		if prev.Tag == e.Tag && prev.Href == e.Href && strings.Join(prev.Style, ",") == strings.Join(e.Style, ",") {
+			prev.Text += e.Text
+			return
+		}
+	}
+	c.cur = append(c.cur, e)
+}
+
+// endLine finishes the current line, if it has anything on it
+func (c *converter) endLine() {
+	if len(c.cur) > 0 {
+		c.lines = append(c.lines, c.cur)
+		c.cur = nil
+	}
+}
+
+func hasCodeSpan(n ast.Node) bool {
+	found := false
+	ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if _, ok := child.(*ast.CodeSpan); ok {
+			found = true
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return found
+}
+
+// lineText returns the plain text of a line
+func lineText(line []Element) string {
+	var b strings.Builder
+	for _, e := range line {
+		switch e.Tag {
+		case "text", "a":
+			b.WriteString(e.Text)
+		case "at":
+			b.WriteString("@" + e.UserID)
+		case "emotion":
+			b.WriteString(":" + e.EmojiType + ":")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,332 @@
+// Package msgarchive stores chat messages in a local SQLite database with
+// a full-text index, so they can be searched without the API.
+package msgarchive
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/yjwong/lark-cli/internal/config"
+	_ "modernc.org/sqlite"
+)
+
+// Archive is the local message archive
+type Archive struct {
+	db *sql.DB
+}
+
+// FilePath returns the path to the message archive database
+func FilePath() string {
+	return filepath.Join(config.GetProfileDir(), "msg_archive.db")
+}
+
+// Open opens or creates the archive database
+func Open() (*Archive, error) {
+	if err := config.EnsureProfileDir(); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", FilePath())
+	if err != nil {
+		return nil, fmt.Errorf("opening message archive: %w", err)
+	}
+
+	a := &Archive{db: db}
+	if err := a.init(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// Close closes the archive database
+func (a *Archive) Close() error {
+	if a.db != nil {
+		return a.db.Close()
+	}
+	return nil
+}
+
+func (a *Archive) init() error {
+	// messages_fts indexes the plain text of each message. It is an
+	// external content table kept in step with messages by triggers.
+	schema := `
+		CREATE TABLE IF NOT EXISTS chats (
+			chat_id TEXT PRIMARY KEY,
+			last_create_time INTEGER NOT NULL DEFAULT 0,
+			last_sync INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS messages (
+			id INTEGER PRIMARY KEY,
+			message_id TEXT NOT NULL UNIQUE,
+			chat_id TEXT NOT NULL,
+			root_id TEXT,
+			parent_id TEXT,
+			thread_id TEXT,
+			msg_type TEXT,
+			sender_id TEXT,
+			sender_type TEXT,
+			create_time INTEGER,
+			deleted INTEGER NOT NULL DEFAULT 0,
+			content TEXT,
+			text TEXT,
+			mentions TEXT,
+			reactions TEXT,
+			attachments TEXT
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_messages_chat_time ON messages(chat_id, create_time DESC);
+		CREATE INDEX IF NOT EXISTS idx_messages_sender ON messages(sender_id);
+
+		CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+			text, content='messages', content_rowid='id'
+		);
+
+		CREATE TRIGGER IF NOT EXISTS messages_ai AFTER INSERT ON messages BEGIN
+			INSERT INTO messages_fts(rowid, text) VALUES (new.id, new.text);
+		END;
+		CREATE TRIGGER IF NOT EXISTS messages_ad AFTER DELETE ON messages BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, text) VALUES ('delete', old.id, old.text);
+		END;
+		CREATE TRIGGER IF NOT EXISTS messages_au AFTER UPDATE ON messages BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, text) VALUES ('delete', old.id, old.text);
+			INSERT INTO messages_fts(rowid, text) VALUES (new.id, new.text);
+		END;
+	`
+
+	if _, err := a.db.Exec(schema); err != nil {
+		return fmt.Errorf("initializing archive schema: %w", err)
+	}
+	return nil
+}
+
+// Message is an archived message
+type Message struct {
+	MessageID   string         `json:"message_id"`
+	ChatID      string         `json:"chat_id"`
+	RootID      string         `json:"root_id,omitempty"`
+	ParentID    string         `json:"parent_id,omitempty"`
+	ThreadID    string         `json:"thread_id,omitempty"`
+	MsgType     string         `json:"msg_type"`
+	SenderID    string         `json:"sender_id"`
+	SenderType  string         `json:"sender_type,omitempty"`
+	CreateTime  time.Time      `json:"create_time"`
+	Deleted     bool           `json:"deleted,omitempty"`
+	Content     string         `json:"-"`
+	Text        string         `json:"text"`
+	Mentions    []Mention      `json:"mentions,omitempty"`
+	Reactions   map[string]int `json:"reactions,omitempty"`
+	Attachments []Attachment   `json:"attachments,omitempty"`
+}
+
+// Mention is a user mentioned in a message
+type Mention struct {
+	Key  string `json:"key"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Attachment is an image or file in a message
+type Attachment struct {
+	Type string `json:"type"`
+	Key  string `json:"key"`
+	Name string `json:"name,omitempty"`
+}
+
+// ChatState holds sync state for a chat
+type ChatState struct {
+	ChatID string
+	// LastCreateTime is the creation time of the newest archived message
+	LastCreateTime time.Time
+	LastSync       time.Time
+}
+
+// GetChatState returns the sync state of a chat, or nil if it has never
+// been synced
+func (a *Archive) GetChatState(chatID string) (*ChatState, error) {
+	row := a.db.QueryRow(
+		`SELECT chat_id, last_create_time, last_sync FROM chats WHERE chat_id = ?`,
+		chatID,
+	)
+
+	var state ChatState
+	var lastCreateMs, lastSyncUnix int64
+	err := row.Scan(&state.ChatID, &lastCreateMs, &lastSyncUnix)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying chat state: %w", err)
+	}
+
+	// 0 means no message has been archived yet
+	if lastCreateMs > 0 {
+		state.LastCreateTime = time.UnixMilli(lastCreateMs)
+	}
+	state.LastSync = time.Unix(lastSyncUnix, 0)
+	return &state, nil
+}
+
+// UpdateChatState records a sync of a chat. The newest message time only
+// moves forward; a zero time leaves it unset.
+func (a *Archive) UpdateChatState(chatID string, lastCreateTime time.Time) error {
+	var lastCreateMs int64
+	if !lastCreateTime.IsZero() {
+		lastCreateMs = lastCreateTime.UnixMilli()
+	}
+	_, err := a.db.Exec(
+		`INSERT INTO chats (chat_id, last_create_time, last_sync)
+		 VALUES (?, ?, ?)
+		 ON CONFLICT(chat_id) DO UPDATE SET
+			last_create_time = MAX(last_create_time, excluded.last_create_time),
+			last_sync = excluded.last_sync`,
+		chatID, lastCreateMs, time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("updating chat state: %w", err)
+	}
+	return nil
+}
+
+// ThreadIDs returns the topic threads archived for a chat
+func (a *Archive) ThreadIDs(chatID string) ([]string, error) {
+	rows, err := a.db.Query(
+		`SELECT DISTINCT thread_id FROM messages
+		 WHERE chat_id = ? AND thread_id IS NOT NULL AND thread_id != ''
+		 ORDER BY thread_id`,
+		chatID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying threads: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning thread: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CountMessages returns the number of archived messages in a chat, or in
+// all chats if chatID is empty
+func (a *Archive) CountMessages(chatID string) (int, error) {
+	query := `SELECT COUNT(*) FROM messages`
+	var args []any
+	if chatID != "" {
+		query += ` WHERE chat_id = ?`
+		args = append(args, chatID)
+	}
+
+	var count int
+	if err := a.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("counting messages: %w", err)
+	}
+	return count, nil
+}
+
+// SaveMessages adds messages to the archive, replacing earlier copies
+func (a *Archive) SaveMessages(messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO messages (message_id, chat_id, root_id, parent_id, thread_id, msg_type,
+			sender_id, sender_type, create_time, deleted, content, text, mentions, reactions, attachments)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(message_id) DO UPDATE SET
+			chat_id = excluded.chat_id,
+			root_id = excluded.root_id,
+			parent_id = excluded.parent_id,
+			thread_id = excluded.thread_id,
+			msg_type = excluded.msg_type,
+			sender_id = excluded.sender_id,
+			sender_type = excluded.sender_type,
+			create_time = excluded.create_time,
+			deleted = excluded.deleted,
+			content = excluded.content,
+			text = excluded.text,
+			mentions = excluded.mentions,
+			reactions = COALESCE(excluded.reactions, messages.reactions),
+			attachments = excluded.attachments`,
+	)
+	if err != nil {
+		return fmt.Errorf("preparing insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, m := range messages {
+		// A nil Reactions map means reactions weren't fetched, so the
+		// archived ones are kept
+		var reactions any
+		if m.Reactions != nil {
+			reactions = jsonText(m.Reactions)
+		}
+		_, err := stmt.Exec(
+			m.MessageID, m.ChatID, m.RootID, m.ParentID, m.ThreadID, m.MsgType,
+			m.SenderID, m.SenderType, m.CreateTime.UnixMilli(), m.Deleted, m.Content, m.Text,
+			jsonText(m.Mentions), reactions, jsonText(m.Attachments),
+		)
+		if err != nil {
+			return fmt.Errorf("archiving message %s: %w", m.MessageID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// jsonText encodes v for a JSON text column
+func jsonText(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// formatFreshness describes how long ago a sync happened
+func formatFreshness(t time.Time) string {
+	if t.IsZero() {
+		return "never synced"
+	}
+
+	d := time.Since(t)
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		mins := int(d.Minutes())
+		if mins == 1 {
+			return "1 minute ago"
+		}
+		return fmt.Sprintf("%d minutes ago", mins)
+	case d < 24*time.Hour:
+		hours := int(d.Hours())
+		if hours == 1 {
+			return "1 hour ago"
+		}
+		return fmt.Sprintf("%d hours ago", hours)
+	default:
+		days := int(d.Hours() / 24)
+		if days == 1 {
+			return "1 day ago"
+		}
+		return fmt.Sprintf("%d days ago", days)
+	}
+}
@@ -0,0 +1,176 @@
+package msgarchive
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yjwong/lark-cli/internal/fts"
+)
+
+// SearchOptions specifies search filters
+type SearchOptions struct {
+	Query  string // full-text query; every term must match
+	ChatID string
+	From   string // sender ID
+	Since  *time.Time
+	Before *time.Time
+	Limit  int
+}
+
+// SearchResult contains search results with archive metadata
+type SearchResult struct {
+	Query   string        `json:"query,omitempty"`
+	Chats   []ChatSummary `json:"chats"`
+	Results []SearchHit   `json:"results"`
+	Count   int           `json:"count"`
+}
+
+// ChatSummary describes how fresh a searched chat's archive is
+type ChatSummary struct {
+	ChatID    string    `json:"chat_id"`
+	LastSync  time.Time `json:"last_sync"`
+	Freshness string    `json:"freshness"`
+	Messages  int       `json:"messages"`
+}
+
+// SearchHit is a matching message with the matched text highlighted
+type SearchHit struct {
+	Message
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Search queries the archive. Results are ordered by relevance when there
+// is a query, newest first otherwise; a blank query counts as none.
+func (a *Archive) Search(opts *SearchOptions) (*SearchResult, error) {
+	result := &SearchResult{
+		Query:   opts.Query,
+		Chats:   []ChatSummary{},
+		Results: []SearchHit{},
+	}
+
+	chats, err := a.chatSummaries(opts.ChatID)
+	if err != nil {
+		return nil, err
+	}
+	result.Chats = chats
+
+	query := `SELECT m.message_id, m.chat_id, m.root_id, m.parent_id, m.thread_id, m.msg_type,
+			m.sender_id, m.sender_type, m.create_time, m.deleted, m.text, m.mentions,
+			m.reactions, m.attachments`
+	var args []any
+	match := fts.Query(opts.Query)
+	if match != "" {
+		query += `, snippet(messages_fts, 0, '[', ']', '…', 16)
+			FROM messages_fts JOIN messages m ON m.id = messages_fts.rowid
+			WHERE messages_fts MATCH ?`
+		args = append(args, match)
+	} else {
+		query += `, '' FROM messages m WHERE 1 = 1`
+	}
+
+	if opts.ChatID != "" {
+		query += ` AND m.chat_id = ?`
+		args = append(args, opts.ChatID)
+	}
+	if opts.From != "" {
+		query += ` AND m.sender_id = ?`
+		args = append(args, opts.From)
+	}
+	if opts.Since != nil {
+		query += ` AND m.create_time >= ?`
+		args = append(args, opts.Since.UnixMilli())
+	}
+	if opts.Before != nil {
+		query += ` AND m.create_time < ?`
+		args = append(args, opts.Before.UnixMilli())
+	}
+
+	if match != "" {
+		query += ` ORDER BY bm25(messages_fts), m.create_time DESC`
+	} else {
+		query += ` ORDER BY m.create_time DESC`
+	}
+
+	limit := 50
+	if opts.Limit > 0 {
+		limit = opts.Limit
+	}
+	query += fmt.Sprintf(` LIMIT %d`, limit)
+
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("searching archive: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hit SearchHit
+		var createMs int64
+		var rootID, parentID, threadID, senderType, text, mentions, reactions, attachments sql.NullString
+
+		err := rows.Scan(&hit.MessageID, &hit.ChatID, &rootID, &parentID, &threadID, &hit.MsgType,
+			&hit.SenderID, &senderType, &createMs, &hit.Deleted, &text, &mentions,
+			&reactions, &attachments, &hit.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		hit.RootID = rootID.String
+		hit.ParentID = parentID.String
+		hit.ThreadID = threadID.String
+		hit.SenderType = senderType.String
+		hit.CreateTime = time.UnixMilli(createMs)
+		hit.Text = text.String
+		unmarshalColumn(mentions, &hit.Mentions)
+		unmarshalColumn(reactions, &hit.Reactions)
+		unmarshalColumn(attachments, &hit.Attachments)
+
+		result.Results = append(result.Results, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("searching archive: %w", err)
+	}
+
+	result.Count = len(result.Results)
+	return result, nil
+}
+
+// chatSummaries returns the state of one synced chat, or of all of them
+func (a *Archive) chatSummaries(chatID string) ([]ChatSummary, error) {
+	query := `SELECT c.chat_id, c.last_sync,
+			(SELECT COUNT(*) FROM messages m WHERE m.chat_id = c.chat_id)
+		FROM chats c`
+	var args []any
+	if chatID != "" {
+		query += ` WHERE c.chat_id = ?`
+		args = append(args, chatID)
+	}
+	query += ` ORDER BY c.chat_id`
+
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying chats: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []ChatSummary{}
+	for rows.Next() {
+		var s ChatSummary
+		var lastSyncUnix int64
+		if err := rows.Scan(&s.ChatID, &lastSyncUnix, &s.Messages); err != nil {
+			return nil, fmt.Errorf("scanning chat: %w", err)
+		}
+		s.LastSync = time.Unix(lastSyncUnix, 0)
+		s.Freshness = formatFreshness(s.LastSync)
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+func unmarshalColumn(col sql.NullString, v any) {
+	if col.Valid && col.String != "" {
+		json.Unmarshal([]byte(col.String), v)
+	}
+}
@@ -0,0 +1,189 @@
+// Package names resolves user open_ids and chat IDs to display names for
+// --resolve-names, caching them in the active profile's directory so
+// repeated commands don't look up the same IDs again.
+package names
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yjwong/lark-cli/internal/api"
+	"github.com/yjwong/lark-cli/internal/config"
+)
+
+// TTL is how long a resolved name is trusted before it is looked up again
+const TTL = 24 * time.Hour
+
+// batchSize is the most users the contact API returns in one request
+const batchSize = 50
+
+// FilePath returns the path to the name cache file
+func FilePath() string {
+	return filepath.Join(config.GetProfileDir(), "names.json")
+}
+
+// entry is a cached name. An empty name records that the ID couldn't be
+// resolved, so it isn't looked up again until it expires.
+type entry struct {
+	Name       string    `json:"name"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// cacheFile is the layout of the cache file
+type cacheFile struct {
+	Users map[string]entry `json:"users"`
+	Chats map[string]entry `json:"chats"`
+}
+
+// Resolver looks up names through the API and the cache. A nil Resolver
+// resolves nothing, so commands can pass one around unconditionally.
+type Resolver struct {
+	client *api.Client
+	cache  cacheFile
+	dirty  bool
+}
+
+// NewResolver returns a resolver backed by the profile's name cache. A
+// missing or unreadable cache starts empty.
+func NewResolver(client *api.Client) *Resolver {
+	r := &Resolver{client: client}
+	if data, err := os.ReadFile(FilePath()); err == nil {
+		json.Unmarshal(data, &r.cache)
+	}
+	if r.cache.Users == nil {
+		r.cache.Users = map[string]entry{}
+	}
+	if r.cache.Chats == nil {
+		r.cache.Chats = map[string]entry{}
+	}
+	return r
+}
+
+// Resolve looks up the names of the given users (open_ids) and chats that
+// aren't cached or have expired, then saves the cache. IDs that aren't
+// open_ids or chat IDs are ignored. Lookups that fail are skipped, leaving
+// those IDs unnamed, and reported together in the returned error. A chat
+// that doesn't exist or that the app can't read is cached as unresolved like
+// an unknown user; other failures are retried on the next call.
+func (r *Resolver) Resolve(userIDs, chatIDs []string) error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	users := r.missing(r.cache.Users, userIDs, "ou_")
+	for start := 0; start < len(users); start += batchSize {
+		batch := users[start:min(start+batchSize, len(users))]
+		found, err := r.client.BatchGetUsers(batch)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resolve user names: %w", err))
+			continue
+		}
+		now := time.Now()
+		for _, id := range batch {
+			r.cache.Users[id] = entry{ResolvedAt: now}
+		}
+		for _, u := range found {
+			name := u.Name
+			if name == "" {
+				name = u.EnName
+			}
+			r.cache.Users[u.OpenID] = entry{Name: name, ResolvedAt: now}
+		}
+		r.dirty = true
+	}
+
+	for _, id := range r.missing(r.cache.Chats, chatIDs, "oc_") {
+		e := entry{ResolvedAt: time.Now()}
+		if chat, err := r.client.GetChat(id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to resolve chat %s: %w", id, err))
+			if !unreadable(err) {
+				continue
+			}
+		} else {
+			e.Name = chat.Name
+		}
+		r.cache.Chats[id] = e
+		r.dirty = true
+	}
+
+	errs = append(errs, r.save())
+	return errors.Join(errs...)
+}
+
+// unreadable reports whether err says a chat doesn't exist or is off-limits
+// to the app, rather than a failure worth retrying
+func unreadable(err error) bool {
+	var apiErr *api.Error
+	return errors.As(err, &apiErr) &&
+		(apiErr.HTTPStatus == http.StatusNotFound || apiErr.HTTPStatus == http.StatusForbidden)
+}
+
+// User returns a user's name, or "" if it isn't known
+func (r *Resolver) User(openID string) string {
+	if r == nil {
+		return ""
+	}
+	return r.cache.Users[openID].Name
+}
+
+// Chat returns a chat's name, or "" if it isn't known
+func (r *Resolver) Chat(chatID string) string {
+	if r == nil {
+		return ""
+	}
+	return r.cache.Chats[chatID].Name
+}
+
+// missing returns the IDs with the given prefix that have no fresh cache
+// entry, sorted and without duplicates
+func (r *Resolver) missing(cached map[string]entry, ids []string, prefix string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, id := range ids {
+		if !strings.HasPrefix(id, prefix) || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if e, ok := cached[id]; ok && time.Since(e.ResolvedAt) < TTL {
+			continue
+		}
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// save writes the cache if anything was resolved, dropping expired entries
+func (r *Resolver) save() error {
+	if !r.dirty {
+		return nil
+	}
+	for _, m := range []map[string]entry{r.cache.Users, r.cache.Chats} {
+		for id, e := range m {
+			if time.Since(e.ResolvedAt) >= TTL {
+				delete(m, id)
+			}
+		}
+	}
+
+	if err := config.EnsureProfileDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r.cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(FilePath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to save name cache: %w", err)
+	}
+	r.dirty = false
+	return nil
+}
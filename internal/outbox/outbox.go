@@ -0,0 +1,260 @@
+// Package outbox queues messages to be sent later in a local SQLite
+// database. 'lark msg schedule' adds to it and 'lark msg outbox run' sends
+// the messages that are due.
+package outbox
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/yjwong/lark-cli/internal/config"
+	_ "modernc.org/sqlite"
+)
+
+// Entry statuses
+const (
+	StatusPending  = "pending"
+	StatusSending  = "sending" // claimed by a runner; stays here if it crashed mid-send
+	StatusSent     = "sent"
+	StatusFailed   = "failed"
+	StatusCanceled = "canceled"
+)
+
+// Outbox is the local queue of scheduled messages
+type Outbox struct {
+	db *sql.DB
+}
+
+// Entry is a queued message. Message holds what to send as JSON; the
+// outbox doesn't look inside it.
+type Entry struct {
+	ID         int64
+	SendAt     time.Time
+	Status     string
+	Message    json.RawMessage
+	CreatedAt  time.Time
+	SentAt     time.Time // zero until sent
+	ChatID     string
+	MessageIDs []string // the messages sent, kept for recalling them
+	Error      string
+}
+
+// FilePath returns the path to the outbox database
+func FilePath() string {
+	return filepath.Join(config.GetProfileDir(), "outbox.db")
+}
+
+// Open opens or creates the outbox database
+func Open() (*Outbox, error) {
+	if err := config.EnsureProfileDir(); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", FilePath())
+	if err != nil {
+		return nil, fmt.Errorf("opening outbox: %w", err)
+	}
+
+	o := &Outbox{db: db}
+	if err := o.init(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return o, nil
+}
+
+// Close closes the outbox database
+func (o *Outbox) Close() error {
+	if o.db != nil {
+		return o.db.Close()
+	}
+	return nil
+}
+
+func (o *Outbox) init() error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS entries (
+			id INTEGER PRIMARY KEY,
+			send_at INTEGER NOT NULL,
+			status TEXT NOT NULL,
+			message TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			sent_at INTEGER NOT NULL DEFAULT 0,
+			chat_id TEXT NOT NULL DEFAULT '',
+			message_ids TEXT NOT NULL DEFAULT '[]',
+			error TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_entries_status_send_at ON entries(status, send_at);
+	`
+
+	// Runners and 'msg schedule' may use the database at the same time
+	if _, err := o.db.Exec(`PRAGMA busy_timeout = 5000`); err != nil {
+		return fmt.Errorf("initializing outbox: %w", err)
+	}
+	if _, err := o.db.Exec(schema); err != nil {
+		return fmt.Errorf("initializing outbox schema: %w", err)
+	}
+	return nil
+}
+
+// Add queues a message to be sent at sendAt
+func (o *Outbox) Add(sendAt time.Time, message json.RawMessage) (*Entry, error) {
+	now := time.Now()
+	res, err := o.db.Exec(
+		`INSERT INTO entries (send_at, status, message, created_at) VALUES (?, ?, ?, ?)`,
+		sendAt.Unix(), StatusPending, string(message), now.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("queueing message: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("queueing message: %w", err)
+	}
+	return o.Get(id)
+}
+
+// Get returns an entry, or an error if there is none with that ID
+func (o *Outbox) Get(id int64) (*Entry, error) {
+	entries, err := o.query(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no scheduled message with ID %d", id)
+	}
+	return &entries[0], nil
+}
+
+// List returns the entries with the given status, or all entries if status
+// is empty, in the order they are due
+func (o *Outbox) List(status string) ([]Entry, error) {
+	if status == "" {
+		return o.query(``)
+	}
+	return o.query(`WHERE status = ?`, status)
+}
+
+// Due returns the pending entries due at or before now, oldest first
+func (o *Outbox) Due(now time.Time) ([]Entry, error) {
+	return o.query(`WHERE status = ? AND send_at <= ?`, StatusPending, now.Unix())
+}
+
+// Claim marks a pending entry as being sent. It returns false if the entry
+// is no longer pending, e.g. because another runner claimed it first.
+func (o *Outbox) Claim(id int64) (bool, error) {
+	res, err := o.db.Exec(
+		`UPDATE entries SET status = ? WHERE id = ? AND status = ?`,
+		StatusSending, id, StatusPending,
+	)
+	if err != nil {
+		return false, fmt.Errorf("claiming message %d: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("claiming message %d: %w", id, err)
+	}
+	return n == 1, nil
+}
+
+// Finish records the outcome of sending an entry. A non-nil sendErr marks
+// it failed; messageIDs are kept either way, since a send can fail after
+// some of its messages went out.
+func (o *Outbox) Finish(id int64, chatID string, messageIDs []string, sendErr error) error {
+	status, errText := StatusSent, ""
+	if sendErr != nil {
+		status, errText = StatusFailed, sendErr.Error()
+	}
+	if messageIDs == nil {
+		messageIDs = []string{}
+	}
+	ids, err := json.Marshal(messageIDs)
+	if err != nil {
+		return err
+	}
+	_, err = o.db.Exec(
+		`UPDATE entries SET status = ?, sent_at = ?, chat_id = ?, message_ids = ?, error = ? WHERE id = ?`,
+		status, time.Now().Unix(), chatID, string(ids), errText, id,
+	)
+	if err != nil {
+		return fmt.Errorf("updating message %d: %w", id, err)
+	}
+	return nil
+}
+
+// Cancel cancels a pending entry
+func (o *Outbox) Cancel(id int64) (*Entry, error) {
+	res, err := o.db.Exec(
+		`UPDATE entries SET status = ? WHERE id = ? AND status = ?`,
+		StatusCanceled, id, StatusPending,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("canceling message %d: %w", id, err)
+	}
+	entry, err := o.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("scheduled message %d is %s, not pending", id, entry.Status)
+	}
+	return entry, nil
+}
+
+// Retry queues a failed entry, or one left sending by a runner that
+// crashed, to be sent on the next run. The outcome of the earlier attempt
+// is cleared.
+func (o *Outbox) Retry(id int64) (*Entry, error) {
+	res, err := o.db.Exec(
+		`UPDATE entries SET status = ?, sent_at = 0, chat_id = '', message_ids = '[]', error = ''
+		 WHERE id = ? AND status IN (?, ?)`,
+		StatusPending, id, StatusFailed, StatusSending,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("requeueing message %d: %w", id, err)
+	}
+	entry, err := o.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("scheduled message %d is %s, not failed or sending", id, entry.Status)
+	}
+	return entry, nil
+}
+
+func (o *Outbox) query(where string, args ...any) ([]Entry, error) {
+	rows, err := o.db.Query(
+		`SELECT id, send_at, status, message, created_at, sent_at, chat_id, message_ids, error
+		 FROM entries `+where+` ORDER BY send_at, id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var sendAt, createdAt, sentAt int64
+		var message, messageIDs string
+		err := rows.Scan(&e.ID, &sendAt, &e.Status, &message, &createdAt, &sentAt, &e.ChatID, &messageIDs, &e.Error)
+		if err != nil {
+			return nil, fmt.Errorf("scanning outbox: %w", err)
+		}
+		e.SendAt = time.Unix(sendAt, 0)
+		e.CreatedAt = time.Unix(createdAt, 0)
+		if sentAt > 0 {
+			e.SentAt = time.Unix(sentAt, 0)
+		}
+		e.Message = json.RawMessage(message)
+		json.Unmarshal([]byte(messageIDs), &e.MessageIDs)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
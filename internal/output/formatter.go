@@ -2,29 +2,150 @@ package output
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/template"
 )
 
-// JSON outputs data as JSON to stdout
+// Format identifies how command results are rendered
+type Format string
+
+// Supported output formats
+const (
+	FormatJSON     Format = "json"
+	FormatYAML     Format = "yaml"
+	FormatTable    Format = "table"
+	FormatNDJSON   Format = "ndjson"
+	FormatTemplate Format = "go-template"
+	FormatJSONPath Format = "jsonpath"
+)
+
+// FormatsUsage describes the accepted --output values for flag help
+const FormatsUsage = "json, yaml, table, ndjson, go-template=<template>, jsonpath=<expression>"
+
+var (
+	currentFormat = FormatJSON
+	outputTmpl    *template.Template
+	outputPath    path
+)
+
+// Detailer is implemented by errors that carry structured fields
+// (such as a Lark error code or log ID) to include in error output
+type Detailer interface {
+	Details() map[string]interface{}
+}
+
+// SetFormat selects the output format from an --output value such as
+// "table", "go-template={{.id}}" or "jsonpath={.events[*].summary}"
+func SetFormat(spec string) error {
+	name, arg, hasArg := strings.Cut(spec, "=")
+
+	switch Format(name) {
+	case "", FormatJSON:
+		currentFormat = FormatJSON
+	case FormatYAML, FormatTable, FormatNDJSON:
+		currentFormat = Format(name)
+	case FormatTemplate, "template":
+		if !hasArg || arg == "" {
+			return fmt.Errorf("go-template output requires a template, e.g. -o 'go-template={{.id}}'")
+		}
+		tmpl, err := template.New("output").Funcs(template.FuncMap{
+			"json": func(v interface{}) (string, error) {
+				data, err := json.Marshal(v)
+				return string(data), err
+			},
+		}).Parse(arg)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		currentFormat = FormatTemplate
+		outputTmpl = tmpl
+	case FormatJSONPath:
+		if !hasArg || arg == "" {
+			return fmt.Errorf("jsonpath output requires an expression, e.g. -o 'jsonpath={.events[*].id}'")
+		}
+		p, err := parsePath(arg)
+		if err != nil {
+			return fmt.Errorf("invalid jsonpath: %w", err)
+		}
+		currentFormat = FormatJSONPath
+		outputPath = p
+	default:
+		return fmt.Errorf("unknown output format %q (supported: %s)", spec, FormatsUsage)
+	}
+
+	return nil
+}
+
+// GetFormat returns the selected output format
+func GetFormat() Format {
+	return currentFormat
+}
+
+// JSON outputs data to stdout in the selected format (JSON by default)
 func JSON(v interface{}) {
-	enc := json.NewEncoder(os.Stdout)
+	if err := render(os.Stdout, v); err != nil {
+		Fatal("OUTPUT_ERROR", err)
+	}
+}
+
+// render writes v to w in the selected format
+func render(w io.Writer, v interface{}) error {
+	switch currentFormat {
+	case FormatYAML:
+		return writeYAML(w, v)
+	case FormatTable:
+		return writeTable(w, v)
+	case FormatNDJSON:
+		return writeNDJSON(w, v)
+	case FormatTemplate:
+		data, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		return outputTmpl.Execute(w, data)
+	case FormatJSONPath:
+		return writeJSONPath(w, v)
+	default:
+		return writeJSON(w, v)
+	}
+}
+
+// writeJSON writes indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
-	enc.Encode(v)
+	return enc.Encode(v)
 }
 
-// Error outputs an error in JSON format
+// Error outputs an error in JSON format.
+// Errors are always JSON so scripts can detect them regardless of --output.
 func Error(code, message string) {
-	JSON(map[string]interface{}{
+	writeJSON(os.Stdout, map[string]interface{}{
 		"error":   true,
 		"code":    code,
 		"message": message,
 	})
 }
 
-// ErrorFromErr outputs an error from a Go error
+// ErrorFromErr outputs an error from a Go error, including any
+// structured details the error carries
 func ErrorFromErr(code string, err error) {
-	Error(code, err.Error())
+	payload := map[string]interface{}{
+		"error":   true,
+		"code":    code,
+		"message": err.Error(),
+	}
+	var detailer Detailer
+	if errors.As(err, &detailer) {
+		for k, v := range detailer.Details() {
+			payload[k] = v
+		}
+	}
+	writeJSON(os.Stdout, payload)
 }
 
 // Success outputs a success message
@@ -37,7 +158,7 @@ func Success(message string) {
 
 // Fatal outputs an error and exits with code 1
 func Fatal(code string, err error) {
-	Error(code, err.Error())
+	ErrorFromErr(code, err)
 	os.Exit(1)
 }
 
@@ -0,0 +1,123 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// path is a parsed jsonpath-like field selection such as
+// {.events[*].summary}, .messages[0].sender.id or start
+type path []pathStep
+
+// pathStep is a single field, index or wildcard step
+type pathStep struct {
+	field string // object key; empty for index steps
+	index int    // list index, negative counts from the end
+	all   bool   // [*] selects every element
+}
+
+// parsePath parses a field selection. Braces, a leading "$" and a
+// leading "." are optional, so "{.a.b}", "$.a.b" and "a.b" are equivalent.
+func parsePath(expr string) (path, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "{") && strings.HasSuffix(expr, "}") {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	expr = strings.TrimPrefix(expr, "$")
+	if expr == "" || expr == "." {
+		return path{}, nil
+	}
+	if expr[0] != '.' && expr[0] != '[' {
+		expr = "." + expr
+	}
+
+	var p path
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '.':
+			start := i + 1
+			end := start
+			for end < len(expr) && expr[end] != '.' && expr[end] != '[' {
+				end++
+			}
+			if end == start {
+				return nil, fmt.Errorf("empty field name at offset %d in %q", start, expr)
+			}
+			p = append(p, pathStep{field: expr[start:end]})
+			i = end
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '[' in %q", expr)
+			}
+			inner := strings.TrimSpace(expr[i+1 : i+end])
+			if inner == "*" {
+				p = append(p, pathStep{all: true})
+			} else {
+				n, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q in %q", inner, expr)
+				}
+				p = append(p, pathStep{index: n})
+			}
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d in %q", expr[i], i, expr)
+		}
+	}
+
+	return p, nil
+}
+
+// eval returns every value selected by the path. Missing fields and
+// out-of-range indexes select nothing rather than failing.
+func (p path) eval(v interface{}) []interface{} {
+	values := []interface{}{v}
+	for _, step := range p {
+		var next []interface{}
+		for _, cur := range values {
+			switch val := cur.(type) {
+			case *object:
+				if step.all {
+					for _, key := range val.keys {
+						next = append(next, val.values[key])
+					}
+				} else if step.field != "" {
+					if field, ok := val.values[step.field]; ok {
+						next = append(next, field)
+					}
+				}
+			case []interface{}:
+				if step.all {
+					next = append(next, val...)
+				} else if step.field == "" {
+					idx := step.index
+					if idx < 0 {
+						idx += len(val)
+					}
+					if idx >= 0 && idx < len(val) {
+						next = append(next, val[idx])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// writeJSONPath writes each selected value on its own line
+func writeJSONPath(w io.Writer, v interface{}) error {
+	ordered, err := decodeOrdered(v)
+	if err != nil {
+		return err
+	}
+	for _, result := range outputPath.eval(ordered) {
+		if _, err := fmt.Fprintln(w, formatValue(result)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// maxCellWidth caps table cells so long message bodies don't wrap the table
+const maxCellWidth = 60
+
+// Column is a single table column
+type Column struct {
+	Header string // column heading, e.g. "SUMMARY"
+	Path   string // field path within a row, e.g. "sender.id"
+}
+
+// Table describes how values of a registered type render with -o table.
+// The same definition selects the records emitted with -o ndjson.
+type Table struct {
+	Items   string   // path to the list of rows, e.g. "events"; empty if the value itself is the row
+	Columns []Column // columns in display order
+}
+
+var tables = map[reflect.Type]Table{}
+
+// RegisterTable registers the table layout for values of v's type.
+// Commands call this from init() for the Output* types they print.
+func RegisterTable(v interface{}, table Table) {
+	tables[baseType(v)] = table
+}
+
+// baseType returns the type of v with pointers removed
+func baseType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// records returns the rows of a decoded value. Registered types use their
+// Items path; otherwise lists are used as-is and objects with exactly one
+// list field use that list. ok is false when v is a single record.
+func records(v interface{}, ordered interface{}) (rows []interface{}, table Table, ok bool) {
+	table, registered := tables[baseType(v)]
+
+	if arr, isArr := ordered.([]interface{}); isArr {
+		return arr, table, true
+	}
+
+	if registered {
+		if table.Items == "" {
+			return []interface{}{ordered}, table, true
+		}
+		p, err := parsePath(table.Items)
+		if err != nil {
+			return nil, table, false
+		}
+		for _, found := range p.eval(ordered) {
+			if arr, isArr := found.([]interface{}); isArr {
+				return arr, table, true
+			}
+		}
+		return []interface{}{}, table, true
+	}
+
+	if obj, isObj := ordered.(*object); isObj {
+		var list []interface{}
+		lists := 0
+		for _, key := range obj.keys {
+			if arr, isArr := obj.values[key].([]interface{}); isArr {
+				list = arr
+				lists++
+			}
+		}
+		if lists == 1 {
+			return list, table, true
+		}
+	}
+
+	return nil, table, false
+}
+
+// writeNDJSON writes one compact JSON record per line
+func writeNDJSON(w io.Writer, v interface{}) error {
+	ordered, err := decodeOrdered(v)
+	if err != nil {
+		return err
+	}
+
+	rows, _, ok := records(v, ordered)
+	if !ok {
+		rows = []interface{}{ordered}
+	}
+
+	enc := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTable writes rows as aligned columns, or a FIELD/VALUE listing
+// for single objects without a registered table
+func writeTable(w io.Writer, v interface{}) error {
+	ordered, err := decodeOrdered(v)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	rows, table, ok := records(v, ordered)
+	if !ok {
+		obj, isObj := ordered.(*object)
+		if !isObj {
+			_, err := fmt.Fprintln(w, formatValue(ordered))
+			return err
+		}
+		fmt.Fprintln(tw, "FIELD\tVALUE")
+		for _, key := range obj.keys {
+			fmt.Fprintf(tw, "%s\t%s\n", key, cell(obj.values[key]))
+		}
+		return tw.Flush()
+	}
+
+	columns := table.Columns
+	if len(columns) == 0 {
+		columns = inferColumns(rows)
+	}
+
+	paths := make([]path, len(columns))
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		p, err := parsePath(col.Path)
+		if err != nil {
+			return fmt.Errorf("invalid column %s: %w", col.Header, err)
+		}
+		paths[i] = p
+		headers[i] = col.Header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		cells := make([]string, len(paths))
+		for i, p := range paths {
+			var values []string
+			for _, val := range p.eval(row) {
+				values = append(values, formatValue(val))
+			}
+			cells[i] = cell(strings.Join(values, ", "))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// inferColumns builds columns from the fields of object rows, in the
+// order they first appear, or a single VALUE column for scalar rows
+func inferColumns(rows []interface{}) []Column {
+	var columns []Column
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		obj, ok := row.(*object)
+		if !ok {
+			continue
+		}
+		for _, key := range obj.keys {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, Column{Header: strings.ToUpper(key), Path: key})
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return []Column{{Header: "VALUE", Path: "."}}
+	}
+	return columns
+}
+
+// cell renders a value on a single line, truncated to maxCellWidth
+func cell(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		s = formatValue(v)
+	}
+	s = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ").Replace(s)
+	if utf8.RuneCountInString(s) > maxCellWidth {
+		runes := []rune(s)
+		s = string(runes[:maxCellWidth-1]) + "…"
+	}
+	return s
+}
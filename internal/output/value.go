@@ -0,0 +1,149 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// object is a decoded JSON object that keeps its keys in document order,
+// so YAML and table output list fields the same way the JSON output does
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// MarshalJSON encodes the object with its original key order
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered converts v to its JSON form using *object for objects,
+// []interface{} for arrays and json.Number for numbers
+func decodeOrdered(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode output: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return readValue(dec)
+}
+
+// readValue reads one JSON value from the token stream
+func readValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := &object{values: make(map[string]interface{})}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyTok.(string)
+			val, err := readValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.keys = append(obj.keys, key)
+			obj.values[key] = val
+		}
+		_, err := dec.Token() // closing '}'
+		return obj, err
+	case '[':
+		arr := []interface{}{}
+		for dec.More() {
+			val, err := readValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		_, err := dec.Token() // closing ']'
+		return arr, err
+	}
+
+	return nil, fmt.Errorf("unexpected JSON delimiter %q", delim)
+}
+
+// toGeneric converts v to plain maps and slices for template execution,
+// so templates address fields by their JSON names
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode output: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+	return generic, nil
+}
+
+// formatValue renders a decoded value as plain text: scalars as-is,
+// lists of scalars comma-separated, anything else as compact JSON
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	case []interface{}:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			switch item.(type) {
+			case *object, []interface{}:
+				return compactJSON(val)
+			}
+			parts = append(parts, formatValue(item))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return compactJSON(v)
+}
+
+// compactJSON encodes v on a single line
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
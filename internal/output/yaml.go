@@ -0,0 +1,55 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// writeYAML writes v as YAML, keeping JSON field names and order
+func writeYAML(w io.Writer, v interface{}) error {
+	ordered, err := decodeOrdered(v)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlNode(ordered)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// yamlNode converts a decoded JSON value into a YAML node tree
+func yamlNode(v interface{}) *yaml.Node {
+	switch val := v.(type) {
+	case *object:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range val.keys {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+				yamlNode(val.values[key]))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range val {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: val}
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(val.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: val.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: formatValue(val)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
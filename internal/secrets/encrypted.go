@@ -0,0 +1,132 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"github.com/yjwong/lark-cli/internal/config"
+)
+
+// passphraseEnv holds the passphrase for the encrypted backend when no
+// identity file is configured. It is never read from the config file.
+const passphraseEnv = "LARK_SECRETS_PASSPHRASE"
+
+// encryptedStore keeps each secret in an age-encrypted file in the profile
+// directory (tokens.json.age, ...), protected by an X25519 identity file
+// or by a passphrase
+type encryptedStore struct {
+	identityFile string
+}
+
+func (s *encryptedStore) Name() string {
+	return BackendEncrypted
+}
+
+func (s *encryptedStore) path(key string) string {
+	return filepath.Join(config.GetProfileDir(), key+".json.age")
+}
+
+// keys returns the age identities and recipients to decrypt and encrypt with
+func (s *encryptedStore) keys() ([]age.Identity, []age.Recipient, error) {
+	if s.identityFile != "" {
+		f, err := os.Open(s.identityFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open identity file: %w", err)
+		}
+		defer f.Close()
+
+		identities, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse identity file: %w", err)
+		}
+
+		var recipients []age.Recipient
+		for _, identity := range identities {
+			if x, ok := identity.(*age.X25519Identity); ok {
+				recipients = append(recipients, x.Recipient())
+			}
+		}
+		if len(recipients) == 0 {
+			return nil, nil, fmt.Errorf("identity file %s contains no X25519 identities", s.identityFile)
+		}
+		return identities, recipients, nil
+	}
+
+	passphrase := os.Getenv(passphraseEnv)
+	if passphrase == "" {
+		return nil, nil, fmt.Errorf("encrypted secrets require %s or secrets.identity_file", passphraseEnv)
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []age.Identity{identity}, []age.Recipient{recipient}, nil
+}
+
+func (s *encryptedStore) Get(key string) ([]byte, error) {
+	f, err := os.Open(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	defer f.Close()
+
+	identities, _, err := s.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := age.Decrypt(f, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", key, err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", key, err)
+	}
+	return data, nil
+}
+
+func (s *encryptedStore) Set(key string, data []byte) error {
+	_, recipients, err := s.keys()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", key, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", key, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", key, err)
+	}
+
+	if err := config.EnsureProfileDir(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path(key), buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *encryptedStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", key, err)
+	}
+	return nil
+}
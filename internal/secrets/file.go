@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yjwong/lark-cli/internal/config"
+)
+
+// fileStore keeps each secret in a plaintext JSON file in the profile
+// directory (tokens.json, tenant_tokens.json, mail.json)
+type fileStore struct{}
+
+func (s *fileStore) Name() string {
+	return BackendFile
+}
+
+func (s *fileStore) path(key string) string {
+	return filepath.Join(config.GetProfileDir(), key+".json")
+}
+
+func (s *fileStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+func (s *fileStore) Set(key string, data []byte) error {
+	if err := config.EnsureProfileDir(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path(key), data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *fileStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keyringService is the service name secrets are filed under in the OS keyring
+const keyringService = "lark-cli"
+
+// keyringStore keeps secrets in the OS keyring: the Secret Service API over
+// D-Bus on Linux (GNOME Keyring, KWallet), Keychain on macOS and Credential
+// Manager on Windows
+type keyringStore struct {
+	profile string
+}
+
+func (s *keyringStore) Name() string {
+	return BackendKeyring
+}
+
+// account scopes a key to the store's profile
+func (s *keyringStore) account(key string) string {
+	return s.profile + "/" + key
+}
+
+func (s *keyringStore) Get(key string) ([]byte, error) {
+	secret, err := keyring.Get(keyringService, s.account(key))
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read %s from keyring: %w", key, err)
+	}
+	return []byte(secret), nil
+}
+
+func (s *keyringStore) Set(key string, data []byte) error {
+	if err := keyring.Set(keyringService, s.account(key), string(data)); err != nil {
+		return fmt.Errorf("failed to write %s to keyring: %w", key, err)
+	}
+	return nil
+}
+
+func (s *keyringStore) Delete(key string) error {
+	err := keyring.Delete(keyringService, s.account(key))
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("failed to remove %s from keyring: %w", key, err)
+	}
+	return nil
+}
+
+// PurgeKeyring removes all of a profile's secrets from the OS keyring.
+// The file-based backends keep secrets in the profile directory instead.
+func PurgeKeyring(profile string) error {
+	store := &keyringStore{profile: profile}
+	for _, key := range Keys() {
+		if err := store.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Package secrets stores OAuth tokens and mail credentials for the active
+// profile in a configurable backend: plaintext files, the OS keyring, or
+// age-encrypted files.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yjwong/lark-cli/internal/config"
+)
+
+// Secret keys stored by the CLI
+const (
+	KeyTokens       = "tokens"
+	KeyTenantTokens = "tenant_tokens"
+	KeyMail         = "mail"
+)
+
+// Backend names accepted in secrets.backend
+const (
+	BackendFile      = "file"
+	BackendKeyring   = "keyring"
+	BackendEncrypted = "encrypted"
+)
+
+// ErrNotFound is returned when a secret has not been stored
+var ErrNotFound = errors.New("secret not found")
+
+// Store persists secrets for the active profile
+type Store interface {
+	// Name returns the backend name
+	Name() string
+	// Get returns the secret, or ErrNotFound if it does not exist
+	Get(key string) ([]byte, error)
+	// Set stores the secret, replacing any existing value
+	Set(key string, data []byte) error
+	// Delete removes the secret; deleting a missing secret is not an error
+	Delete(key string) error
+}
+
+// Keys returns every secret key the CLI stores
+func Keys() []string {
+	return []string{KeyTokens, KeyTenantTokens, KeyMail}
+}
+
+// Backends returns the supported backend names
+func Backends() []string {
+	return []string{BackendFile, BackendKeyring, BackendEncrypted}
+}
+
+// Open returns the backend selected by secrets.backend in the config
+func Open() (Store, error) {
+	return OpenBackend(config.GetSecretsBackend())
+}
+
+// OpenBackend returns the named backend
+func OpenBackend(name string) (Store, error) {
+	switch name {
+	case "", BackendFile:
+		return &fileStore{}, nil
+	case BackendKeyring:
+		return &keyringStore{profile: config.GetProfile()}, nil
+	case BackendEncrypted:
+		return &encryptedStore{
+			identityFile: config.GetSecretsIdentityFile(),
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown secrets backend %q (must be one of: %s)", name, strings.Join(Backends(), ", "))
+}
+
+// Load reads a secret from the configured backend.
+// A missing secret returns (nil, nil) so callers can treat it as empty.
+func Load(key string) ([]byte, error) {
+	store, err := Open()
+	if err != nil {
+		return nil, err
+	}
+	data, err := store.Get(key)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Save writes a secret to the configured backend
+func Save(key string, data []byte) error {
+	store, err := Open()
+	if err != nil {
+		return err
+	}
+	return store.Set(key, data)
+}
+
+// Remove deletes a secret from the configured backend
+func Remove(key string) error {
+	store, err := Open()
+	if err != nil {
+		return err
+	}
+	return store.Delete(key)
+}
+
+// Migrate moves every known secret from one backend to another and
+// returns the keys that were moved. Secrets are removed from the source
+// only after they have been written to the destination.
+func Migrate(from, to Store) ([]string, error) {
+	moved := []string{}
+	for _, key := range Keys() {
+		data, err := from.Get(key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return moved, err
+		}
+		if err := to.Set(key, data); err != nil {
+			return moved, err
+		}
+		if err := from.Delete(key); err != nil {
+			return moved, err
+		}
+		moved = append(moved, key)
+	}
+	return moved, nil
+}
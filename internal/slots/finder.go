@@ -0,0 +1,169 @@
+package slots
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval is a half-open time range [Start, End)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Attendee is a participant and the periods they are busy
+type Attendee struct {
+	ID       string
+	Optional bool
+	Busy     []Interval
+}
+
+// Options configures slot finding
+type Options struct {
+	From            time.Time
+	To              time.Time
+	Duration        time.Duration
+	Step            time.Duration // spacing between candidate start times
+	WorkStart       time.Duration // offset from midnight; WorkStart == WorkEnd means all day
+	WorkEnd         time.Duration
+	Location        *time.Location
+	IncludeWeekends bool
+	BufferMinutes   int        // free time required around other events, as in conflicts.Options
+	MaxConflicts    int        // required attendees allowed to be busy in a proposed slot
+	Allowed         []Interval // if non-nil, slots must fall inside one of these
+}
+
+// Slot is a proposed meeting time
+type Slot struct {
+	Start       time.Time
+	End         time.Time
+	Conflicts   []string // required attendees who are busy
+	Available   []string // optional attendees who are free
+	Unavailable []string // optional attendees who are busy
+}
+
+// Find returns candidate slots ranked by fewest conflicting required
+// attendees, then most available optional attendees, then earliest start
+func Find(attendees []Attendee, opts Options) []Slot {
+	if opts.Duration <= 0 {
+		return nil
+	}
+	step := opts.Step
+	if step <= 0 {
+		step = 30 * time.Minute
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	buffer := time.Duration(opts.BufferMinutes) * time.Minute
+
+	var result []Slot
+	from, to := opts.From.In(loc), opts.To.In(loc)
+	for day := midnight(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !opts.IncludeWeekends && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+
+		windowStart, windowEnd := day, day.AddDate(0, 0, 1)
+		if opts.WorkStart != opts.WorkEnd {
+			windowStart = day.Add(opts.WorkStart)
+			windowEnd = day.Add(opts.WorkEnd)
+		}
+		if windowStart.Before(from) {
+			windowStart = from
+		}
+		if windowEnd.After(to) {
+			windowEnd = to
+		}
+
+		// Align candidates to the step, counted from midnight
+		start := day.Add(windowStart.Sub(day).Truncate(step))
+		if start.Before(windowStart) {
+			start = start.Add(step)
+		}
+
+		for ; !start.Add(opts.Duration).After(windowEnd); start = start.Add(step) {
+			candidate := Interval{Start: start, End: start.Add(opts.Duration)}
+			if opts.Allowed != nil && !within(candidate, opts.Allowed) {
+				continue
+			}
+
+			slot := Slot{Start: candidate.Start, End: candidate.End}
+			for _, a := range attendees {
+				busy := isBusy(a.Busy, candidate, buffer)
+				switch {
+				case !a.Optional && busy:
+					slot.Conflicts = append(slot.Conflicts, a.ID)
+				case a.Optional && busy:
+					slot.Unavailable = append(slot.Unavailable, a.ID)
+				case a.Optional:
+					slot.Available = append(slot.Available, a.ID)
+				}
+			}
+			if len(slot.Conflicts) > opts.MaxConflicts {
+				continue
+			}
+			result = append(result, slot)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if len(a.Conflicts) != len(b.Conflicts) {
+			return len(a.Conflicts) < len(b.Conflicts)
+		}
+		if len(a.Unavailable) != len(b.Unavailable) {
+			return len(a.Unavailable) < len(b.Unavailable)
+		}
+		return a.Start.Before(b.Start)
+	})
+
+	return result
+}
+
+// Intersect returns the periods covered by both a and b
+func Intersect(a, b []Interval) []Interval {
+	var result []Interval
+	for _, x := range a {
+		for _, y := range b {
+			start, end := x.Start, x.End
+			if y.Start.After(start) {
+				start = y.Start
+			}
+			if y.End.Before(end) {
+				end = y.End
+			}
+			if start.Before(end) {
+				result = append(result, Interval{Start: start, End: end})
+			}
+		}
+	}
+	return result
+}
+
+// isBusy reports whether any busy period falls within buffer of the slot.
+// Periods that end exactly buffer before the slot starts are not conflicts.
+func isBusy(busy []Interval, slot Interval, buffer time.Duration) bool {
+	start, end := slot.Start.Add(-buffer), slot.End.Add(buffer)
+	for _, b := range busy {
+		if b.Start.Before(end) && start.Before(b.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether slot lies entirely inside one of the periods
+func within(slot Interval, periods []Interval) bool {
+	for _, p := range periods {
+		if !slot.Start.Before(p.Start) && !slot.End.After(p.End) {
+			return true
+		}
+	}
+	return false
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
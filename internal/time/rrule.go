@@ -0,0 +1,170 @@
+package timex
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence is an RFC 5545 RRULE as used by the Lark calendar
+// "recurrence" field, e.g. FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20261231T155959Z
+type Recurrence struct {
+	Freq     string    // DAILY, WEEKLY, MONTHLY or YEARLY
+	Interval int       // repeat every N periods; 0 or 1 means every period
+	ByDay    []string  // weekdays such as MO, WE, or 1MO / -1FR for monthly rules
+	Until    time.Time // last possible occurrence; zero if unbounded
+	UntilDay bool      // Until is a bare date covering that whole day
+	Count    int       // number of occurrences; 0 if unbounded
+	Extra    []string  // other rule parts kept verbatim (e.g. BYMONTHDAY=15)
+}
+
+var byDayRe = regexp.MustCompile(`^([+-]?\d{1,2})?(MO|TU|WE|TH|FR|SA|SU)$`)
+
+// weekdayNames maps longer weekday spellings to RRULE codes
+var weekdayNames = map[string]string{
+	"MON": "MO", "MONDAY": "MO",
+	"TUE": "TU", "TUESDAY": "TU",
+	"WED": "WE", "WEDNESDAY": "WE",
+	"THU": "TH", "THURSDAY": "TH",
+	"FRI": "FR", "FRIDAY": "FR",
+	"SAT": "SA", "SATURDAY": "SA",
+	"SUN": "SU", "SUNDAY": "SU",
+}
+
+// ParseFrequency converts a --repeat value (daily, weekly, monthly, yearly) to an RRULE FREQ
+func ParseFrequency(input string) (string, error) {
+	freq := strings.ToUpper(strings.TrimSpace(input))
+	switch freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+		return freq, nil
+	}
+	return "", fmt.Errorf("invalid repeat frequency: %s (use daily, weekly, monthly, or yearly)", input)
+}
+
+// ParseByDay parses a comma-separated weekday list such as "MO,WE", "mon,wed" or "1MO"
+func ParseByDay(input string) ([]string, error) {
+	var days []string
+	for _, part := range strings.Split(input, ",") {
+		day := strings.ToUpper(strings.TrimSpace(part))
+		if day == "" {
+			continue
+		}
+		if code, ok := weekdayNames[day]; ok {
+			day = code
+		}
+		if !byDayRe.MatchString(day) {
+			return nil, fmt.Errorf("invalid weekday: %s (use MO, TU, WE, TH, FR, SA, SU)", part)
+		}
+		days = append(days, day)
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("no weekdays specified")
+	}
+	return days, nil
+}
+
+// ParseRRule parses an RRULE string, with or without the "RRULE:" prefix.
+// A floating UNTIL (no Z suffix) is read in loc, the event's time zone.
+func ParseRRule(input string, loc *time.Location) (Recurrence, error) {
+	var r Recurrence
+
+	input = strings.TrimSpace(input)
+	if len(input) >= 6 && strings.EqualFold(input[:6], "RRULE:") {
+		input = input[6:]
+	}
+	if input == "" {
+		return r, fmt.Errorf("empty recurrence rule")
+	}
+
+	for _, part := range strings.Split(input, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return r, fmt.Errorf("invalid recurrence rule part: %s", part)
+		}
+		key = strings.ToUpper(key)
+
+		switch key {
+		case "FREQ":
+			freq, err := ParseFrequency(value)
+			if err != nil {
+				return r, err
+			}
+			r.Freq = freq
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return r, fmt.Errorf("invalid INTERVAL: %s", value)
+			}
+			r.Interval = n
+		case "BYDAY":
+			days, err := ParseByDay(value)
+			if err != nil {
+				return r, err
+			}
+			r.ByDay = days
+		case "UNTIL":
+			until, day, err := parseUntil(value, loc)
+			if err != nil {
+				return r, err
+			}
+			r.Until, r.UntilDay = until, day
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return r, fmt.Errorf("invalid COUNT: %s", value)
+			}
+			r.Count = n
+		default:
+			r.Extra = append(r.Extra, key+"="+value)
+		}
+	}
+
+	if r.Freq == "" {
+		return r, fmt.Errorf("recurrence rule must include FREQ")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return r, fmt.Errorf("recurrence rule cannot have both COUNT and UNTIL")
+	}
+
+	return r, nil
+}
+
+// parseUntil parses an RRULE UNTIL value, reporting whether it is a bare date
+func parseUntil(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid UNTIL: %s", value)
+}
+
+// String formats the rule for the Lark recurrence field (without the "RRULE:" prefix)
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(r.ByDay, ","))
+	}
+	if r.UntilDay {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	} else if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	parts = append(parts, r.Extra...)
+	return strings.Join(parts, ";")
+}
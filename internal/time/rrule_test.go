@@ -0,0 +1,104 @@
+package timex
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseByDay(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"MO,WE", []string{"MO", "WE"}, false},
+		{"mon, Wednesday", []string{"MO", "WE"}, false},
+		{"1MO,-1fr", []string{"1MO", "-1FR"}, false},
+		{"MO,,FR,", []string{"MO", "FR"}, false},
+		{"", nil, true},
+		{"MO,XX", nil, true},
+		{"100MO", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseByDay(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseByDay(%q) error = %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseByDay(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseRRule(t *testing.T) {
+	sgt := time.FixedZone("SGT", 8*3600)
+	tests := []struct {
+		input   string
+		want    Recurrence
+		wantErr bool
+	}{
+		{"FREQ=DAILY", Recurrence{Freq: "DAILY"}, false},
+		{
+			"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20261231T155959Z",
+			Recurrence{Freq: "WEEKLY", Interval: 2, ByDay: []string{"MO", "WE"}, Until: time.Date(2026, 12, 31, 15, 59, 59, 0, time.UTC)},
+			false,
+		},
+		{"freq=monthly;byday=-1fr;count=6", Recurrence{Freq: "MONTHLY", ByDay: []string{"-1FR"}, Count: 6}, false},
+		{"FREQ=MONTHLY;BYMONTHDAY=15;WKST=SU", Recurrence{Freq: "MONTHLY", Extra: []string{"BYMONTHDAY=15", "WKST=SU"}}, false},
+		{"FREQ=YEARLY;UNTIL=20270101", Recurrence{Freq: "YEARLY", Until: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), UntilDay: true}, false},
+		{"FREQ=DAILY;UNTIL=20261231T235959", Recurrence{Freq: "DAILY", Until: time.Date(2026, 12, 31, 23, 59, 59, 0, sgt)}, false},
+		{"", Recurrence{}, true},
+		{"INTERVAL=2", Recurrence{}, true},
+		{"FREQ=HOURLY", Recurrence{}, true},
+		{"FREQ=DAILY;INTERVAL=0", Recurrence{}, true},
+		{"FREQ=DAILY;COUNT=x", Recurrence{}, true},
+		{"FREQ=DAILY;COUNT=3;UNTIL=20270101", Recurrence{}, true},
+		{"FREQ=DAILY;UNTIL=tomorrow", Recurrence{}, true},
+		{"FREQ=DAILY;BYDAY", Recurrence{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRRule(tt.input, sgt)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRRule(%q) error = %v", tt.input, err)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRRule(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceString(t *testing.T) {
+	for _, rule := range []string{
+		"FREQ=DAILY",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20261231T155959Z",
+		"FREQ=MONTHLY;BYDAY=-1FR;COUNT=6",
+		"FREQ=MONTHLY;BYMONTHDAY=15",
+		"FREQ=DAILY;UNTIL=20261231",
+	} {
+		r, err := ParseRRule(rule, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.String(); got != rule {
+			t.Errorf("round trip of %q = %q", rule, got)
+		}
+	}
+
+	sgt := time.FixedZone("SGT", 8*3600)
+	r := Recurrence{Freq: "WEEKLY", Interval: 1, Until: time.Date(2026, 10, 22, 9, 0, 0, 0, sgt)}
+	if got, want := r.String(), "FREQ=WEEKLY;UNTIL=20261022T010000Z"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// A floating UNTIL is emitted in UTC from the event's zone
+	r, err := ParseRRule("FREQ=DAILY;UNTIL=20261231T235959", sgt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.String(), "FREQ=DAILY;UNTIL=20261231T155959Z"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}